@@ -0,0 +1,114 @@
+package block
+
+import (
+	"encoding/binary"
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/crypto/privacy"
+	"github.com/Acoustical/maskash/errors"
+	"github.com/Acoustical/maskash/ledger"
+)
+
+// Header commits to the parent block and to the body by the Merkle roots of the transaction hashes
+// and of the output slot hashes, a light client verifies inclusions against the header only
+type Header struct {
+	ParentHash crypto.Hash
+	Height uint64
+	Timestamp int64
+	TxRoot crypto.Hash
+	OutputRoot crypto.Hash
+}
+
+func (header *Header) Hash() crypto.Hash {return crypto.Hash_(header)}
+
+// VerifyTx checks the transaction of txHash is in the block of the header
+func (header *Header) VerifyTx(txHash crypto.Hash, proof *MerkleProof) bool {return proof.Verify(header.TxRoot, txHash)}
+
+// VerifyOutput checks the output slot is in the block of the header
+func (header *Header) VerifyOutput(slot privacy.Slot, proof *MerkleProof) bool {
+	return proof.Verify(header.OutputRoot, crypto.Hash_(slot))
+}
+
+func (header *Header) Bytes() []byte {
+	bytes := make([]byte, common.BlockHeaderLength)
+	copy(bytes[:common.HashLength], header.ParentHash[:])
+	binary.BigEndian.PutUint64(bytes[common.HashLength:common.HashLength+8], header.Height)
+	binary.BigEndian.PutUint64(bytes[common.HashLength+8:common.HashLength+16], uint64(header.Timestamp))
+	copy(bytes[common.HashLength+16:2*common.HashLength+16], header.TxRoot[:])
+	copy(bytes[2*common.HashLength+16:], header.OutputRoot[:])
+	return bytes
+}
+
+func (header *Header) SetBytes(b []byte) (*Header, error) {
+	bLen := len(b)
+	if bLen != common.BlockHeaderLength {return nil, errors.NewWrongInputLength(bLen)}
+	copy(header.ParentHash[:], b[:common.HashLength])
+	header.Height = binary.BigEndian.Uint64(b[common.HashLength:common.HashLength+8])
+	header.Timestamp = int64(binary.BigEndian.Uint64(b[common.HashLength+8:common.HashLength+16]))
+	copy(header.TxRoot[:], b[common.HashLength+16:2*common.HashLength+16])
+	copy(header.OutputRoot[:], b[2*common.HashLength+16:])
+	return header, nil
+}
+
+// Block is a header with the transactions it commits to
+type Block struct {
+	Header *Header
+	Txs []*ledger.Tx
+}
+
+// NewBlock builds the child block of parent, a nil parent starts the chain at height 0
+func NewBlock(parent *Header, timestamp int64, txs []*ledger.Tx) *Block {
+	block := &Block{Header: &Header{Timestamp: timestamp}, Txs: txs}
+	if parent != nil {
+		block.Header.ParentHash = parent.Hash()
+		block.Header.Height = parent.Height + 1
+	}
+	block.Header.TxRoot = MerkleRoot(block.txHashes())
+	block.Header.OutputRoot = MerkleRoot(block.outputHashes())
+	return block
+}
+
+func (block *Block) Hash() crypto.Hash {return block.Header.Hash()}
+
+// txHashes returns the leaves of the transaction tree
+func (block *Block) txHashes() []crypto.Hash {
+	hashes := make([]crypto.Hash, len(block.Txs))
+	for i, tx := range block.Txs {
+		hashes[i] = tx.Hash()
+	}
+	return hashes
+}
+
+// outputHashes returns the leaves of the output tree, the outputs of every transaction in order
+func (block *Block) outputHashes() []crypto.Hash {
+	hashes := make([]crypto.Hash, 0)
+	for _, tx := range block.Txs {
+		for _, slot := range tx.Outputs {
+			hashes = append(hashes, crypto.Hash_(slot))
+		}
+	}
+	return hashes
+}
+
+// Check checks the roots of the header match the body
+func (block *Block) Check() bool {
+	return block.Header.TxRoot == MerkleRoot(block.txHashes()) && block.Header.OutputRoot == MerkleRoot(block.outputHashes())
+}
+
+// CheckParent checks the block extends parent
+func (block *Block) CheckParent(parent *Header) bool {
+	return block.Header.ParentHash == parent.Hash() && block.Header.Height == parent.Height + 1
+}
+
+// ProveTx returns the inclusion proof of the i-th transaction
+func (block *Block) ProveTx(i int) (*MerkleProof, error) {return NewMerkleProof(block.txHashes(), i)}
+
+// ProveOutput returns the inclusion proof of an output slot of the block
+func (block *Block) ProveOutput(slot privacy.Slot) (*MerkleProof, error) {
+	hashes := block.outputHashes()
+	hash := crypto.Hash_(slot)
+	for i, h := range hashes {
+		if h == hash {return NewMerkleProof(hashes, i)}
+	}
+	return nil, errors.NewRecordNotFoundError(hash.Bytes())
+}
@@ -0,0 +1,52 @@
+package block
+
+import (
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/crypto/privacy"
+	"github.com/Acoustical/maskash/errors"
+	"github.com/Acoustical/maskash/ledger"
+	"math/big"
+	"testing"
+)
+
+func TestBlock(t *testing.T) {
+	prv := privacy.NewRandomPrivateKey()
+	rl, _ := crypto.RandomZq(3)
+	txs := make([]*ledger.Tx, 3)
+	for i := range txs {
+		slot, err := prv.GenSecretBase().NewSecretOutputSlot(big.NewInt(int64(i)), rl[i], true, common.NoneContractSlot, nil, nil)
+		errors.Handle(err)
+		plaintext, err := prv.GenPlaintextBase().NewPlaintextOutputSlot(big.NewInt(int64(i)), common.NoneContractSlot, nil)
+		errors.Handle(err)
+		txs[i] = &ledger.Tx{Outputs: []privacy.Slot{plaintext, slot}}
+	}
+
+	genesis := NewBlock(nil, 1600000000, nil)
+	block := NewBlock(genesis.Header, 1600000010, txs)
+	if !block.Check() || !block.CheckParent(genesis.Header) || block.Header.Height != 1 {
+		t.Errorf("block check failed")
+	}
+
+	header, err := new(Header).SetBytes(block.Header.Bytes())
+	errors.Handle(err)
+	if header.Hash() != block.Hash() {
+		t.Errorf("header bytes mismatch")
+	}
+	proof, err := block.ProveTx(2)
+	errors.Handle(err)
+	if !header.VerifyTx(txs[2].Hash(), proof) {
+		t.Errorf("transaction inclusion proof failed")
+	}
+	secret := txs[1].Outputs[1]
+	proof, err = block.ProveOutput(secret)
+	errors.Handle(err)
+	if !header.VerifyOutput(secret, proof) || header.VerifyOutput(txs[2].Outputs[1], proof) {
+		t.Errorf("output inclusion proof failed")
+	}
+
+	block.Txs = block.Txs[:2]
+	if block.Check() {
+		t.Errorf("block accepted a changed body")
+	}
+}
@@ -0,0 +1,115 @@
+package block
+
+import (
+	"encoding/binary"
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/errors"
+)
+
+// Leaves and inner nodes are hashed under different prefixes, so an inner node can not be passed off as a leaf
+var leafPrefix = crypto.HashBytes{0}
+var nodePrefix = crypto.HashBytes{1}
+
+func leafHash(leaf crypto.Hash) crypto.Hash {return crypto.Hash_(leafPrefix, leaf)}
+
+func nodeHash(left, right crypto.Hash) crypto.Hash {return crypto.Hash_(nodePrefix, left, right)}
+
+// MerkleRoot returns the root of the tree over leaves, an unpaired node is promoted to the next level,
+// the root of no leaves is the zero hash
+func MerkleRoot(leaves []crypto.Hash) crypto.Hash {
+	if len(leaves) == 0 {return crypto.Hash{}}
+	level := make([]crypto.Hash, len(leaves))
+	for i, leaf := range leaves {
+		level[i] = leafHash(leaf)
+	}
+	for len(level) > 1 {
+		next := make([]crypto.Hash, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+			} else {
+				next = append(next, nodeHash(level[i], level[i+1]))
+			}
+		}
+		level = next
+	}
+	return level[0]
+}
+
+// MerkleProof proves the leaf at Index is one of Count leaves under a root
+type MerkleProof struct {
+	Index, Count uint32
+	Siblings []crypto.Hash
+}
+
+// NewMerkleProof returns the proof of the leaf at index
+func NewMerkleProof(leaves []crypto.Hash, index int) (*MerkleProof, error) {
+	if index < 0 || index >= len(leaves) {return nil, errors.NewWrongInputLength(index)}
+	proof := &MerkleProof{Index: uint32(index), Count: uint32(len(leaves))}
+	level := make([]crypto.Hash, len(leaves))
+	for i, leaf := range leaves {
+		level[i] = leafHash(leaf)
+	}
+	for len(level) > 1 {
+		sibling := index ^ 1
+		if sibling < len(level) {proof.Siblings = append(proof.Siblings, level[sibling])}
+		next := make([]crypto.Hash, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+			} else {
+				next = append(next, nodeHash(level[i], level[i+1]))
+			}
+		}
+		level = next
+		index /= 2
+	}
+	return proof, nil
+}
+
+// Verify checks leaf is under root at the position of the proof
+func (proof *MerkleProof) Verify(root, leaf crypto.Hash) bool {
+	if proof.Index >= proof.Count {return false}
+	node := leafHash(leaf)
+	index, width := proof.Index, proof.Count
+	siblings := proof.Siblings
+	for width > 1 {
+		if index ^ 1 < width {
+			if len(siblings) == 0 {return false}
+			if index & 1 == 0 {
+				node = nodeHash(node, siblings[0])
+			} else {
+				node = nodeHash(siblings[0], node)
+			}
+			siblings = siblings[1:]
+		}
+		index /= 2
+		width = (width + 1) / 2
+	}
+	return len(siblings) == 0 && node == root
+}
+
+func (proof *MerkleProof) Bytes() []byte {
+	bytes := make([]byte, common.MerkleProofHeaderLength + len(proof.Siblings) * common.HashLength)
+	binary.BigEndian.PutUint32(bytes[:4], proof.Index)
+	binary.BigEndian.PutUint32(bytes[4:8], proof.Count)
+	for i, sibling := range proof.Siblings {
+		start := common.MerkleProofHeaderLength + i * common.HashLength
+		copy(bytes[start:start+common.HashLength], sibling[:])
+	}
+	return bytes
+}
+
+func (proof *MerkleProof) SetBytes(b []byte) (*MerkleProof, error) {
+	bLen := len(b)
+	if bLen < common.MerkleProofHeaderLength || (bLen - common.MerkleProofHeaderLength) % common.HashLength != 0 {return nil, errors.NewWrongInputLength(bLen)}
+	proof.Index = binary.BigEndian.Uint32(b[:4])
+	proof.Count = binary.BigEndian.Uint32(b[4:8])
+	proof.Siblings = make([]crypto.Hash, (bLen - common.MerkleProofHeaderLength) / common.HashLength)
+	for i := range proof.Siblings {
+		start := common.MerkleProofHeaderLength + i * common.HashLength
+		copy(proof.Siblings[i][:], b[start:start+common.HashLength])
+	}
+	return proof, nil
+}
@@ -0,0 +1,30 @@
+package block
+
+import (
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/errors"
+	"math/big"
+	"testing"
+)
+
+func TestMerkleProof(t *testing.T) {
+	for n := 1; n <= 9; n++ {
+		leaves := make([]crypto.Hash, n)
+		for i := range leaves {
+			leaves[i] = crypto.Hash_(big.NewInt(int64(i)))
+		}
+		root := MerkleRoot(leaves)
+		for i := range leaves {
+			proof, err := NewMerkleProof(leaves, i)
+			errors.Handle(err)
+			proof, err = new(MerkleProof).SetBytes(proof.Bytes())
+			errors.Handle(err)
+			if !proof.Verify(root, leaves[i]) {
+				t.Errorf("merkle proof of leaf %d of %d failed", i, n)
+			}
+			if proof.Verify(root, leaves[(i+1)%n]) && n > 1 {
+				t.Errorf("merkle proof of leaf %d of %d accepted another leaf", i, n)
+			}
+		}
+	}
+}
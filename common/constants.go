@@ -4,12 +4,46 @@ const ByteBits int = 8
 const Bn256ZqBits int = 256
 const Bn256PointBits int = 256 + 8
 
+const HashLength = 32
+
 const RangeProofShortBits int = 20
 const RangeProofLongBits int = 40
 const MaxShortValue int = 1 << RangeProofShortBits - 1
 const MaxLongValue int = 1 << RangeProofLongBits - 1
 
+const ExtensionFlagLength = 1
+const MemoLength = 32
+const MemoCipherLength = MemoLength + 16
+
+const AssetIDLength = 32
+const AssetTagLength = Bn256PointBits / ByteBits
+const AssetSetSizeLength = 1
+const AssetSetProofUnitLength = 2 * Bn256ZqBits / ByteBits
+const MaxAssetSetSize = 1 << (8 * AssetSetSizeLength) - 1
+
+const ElGamalPublicKeyLength = 2 * Bn256PointBits / ByteBits
+const ElGamalCiphertextLength = 2 * Bn256PointBits / ByteBits
+const AuditTagLength = ElGamalCiphertextLength + AuditProofLength
+
+const DecryptionProofLength = Bn256ZqBits / ByteBits + EqualityProofLength
+const PaymentProofLength = Bn256ZqBits / ByteBits + EqualityProofLength
+const NonNegativeProofLength = 1 + SecretSolvableValueLength + SecretZKsLength + EqualityProofLength
+const SpendProofLength = EqualityProofLength
+const ChainIDLength = 8
+
+const AggregateCountLength = 2
+const LinearSystemCountLength = 2
+const MaxAggregateSize = 1 << (8 * AggregateCountLength) - 1
+
 const FormatProofLength = 3 * Bn256ZqBits / ByteBits
+const EqualityProofLength = 2 * Bn256ZqBits / ByteBits
+const ConversionProofLength = 4 * Bn256ZqBits / ByteBits
+const RerandomizationProofLength = 3 * Bn256ZqBits / ByteBits
+const AuditProofLength = 4 * Bn256ZqBits / ByteBits
+const ShuffleCountLength = 4
+const ShuffleHeaderLength = ShuffleCountLength + 1
+const MaxShuffleSize = 1 << 20
+const MaxShuffleWidth = 1 << 8 - 1
 const RangeProofShortLength = (4 * Bn256PointBits + (2 + 2 * RangeProofShortBits) * Bn256ZqBits) / ByteBits
 
 const PlaintextBaseLength int = 20
@@ -17,25 +51,29 @@ const PlaintextInputValueLength = 2 * Bn256ZqBits / ByteBits
 const PlaintextOutputValueLength = Bn256ZqBits / ByteBits
 const PlaintextZKsLength = (Bn256PointBits + Bn256ZqBits) / ByteBits
 const PlaintextInputSlotLength = 1 + PlaintextBaseLength + PlaintextInputValueLength + PlaintextZKsLength
-const PlaintextOutputSlotLength = 1 + PlaintextBaseLength + PlaintextOutputValueLength
+const PlaintextOutputSlotLength = 1 + PlaintextBaseLength + PlaintextOutputValueLength + ExtensionFlagLength
 
 const SecretBaseLength = Bn256PointBits / ByteBits
 const SecretSolvableValueLength = 2 * Bn256PointBits / ByteBits
 const SecretNonSolvableValueLength = Bn256PointBits / ByteBits
 const SecretZKsLength = FormatProofLength + RangeProofShortLength
-const SecretInputSolvableSlotLength = 1 + SecretBaseLength + SecretSolvableValueLength
-const SecretInputNonSolvableSlotLength = 1 + SecretBaseLength + SecretNonSolvableValueLength
-const SecretOutputSolvableSlotLength = 1 + SecretBaseLength + SecretSolvableValueLength + SecretZKsLength
-const SecretOutputNonSolvableSlotLength = 1 + SecretBaseLength + SecretNonSolvableValueLength + SecretZKsLength
+const SpendBaseLength = Bn256PointBits / ByteBits
+const SecretInputSolvableSlotLength = 1 + SecretBaseLength + SecretSolvableValueLength + SpendBaseLength
+const SecretInputNonSolvableSlotLength = 1 + SecretBaseLength + SecretNonSolvableValueLength + SpendBaseLength
+const SecretOutputSolvableSlotLength = 1 + SecretBaseLength + SecretSolvableValueLength + SecretZKsLength + ExtensionFlagLength
+const SecretOutputNonSolvableSlotLength = 1 + SecretBaseLength + SecretNonSolvableValueLength + SecretZKsLength + ExtensionFlagLength
 
 const AnonymousBaseLength = 2 * Bn256PointBits / ByteBits
 const AnonymousSolvableValueLength = 2 * Bn256PointBits / ByteBits
 const AnonymousNonSolvableValueLength = Bn256PointBits / ByteBits
 const AnonymousZKsLength = FormatProofLength + RangeProofShortLength
-const AnonymousInputSolvableSlotLength = 1 + AnonymousBaseLength + AnonymousSolvableValueLength
-const AnonymousInputNonSolvableSlotLength = 1 + AnonymousBaseLength + AnonymousNonSolvableValueLength
-const AnonymousOutputSolvableSlotLength = 1 + AnonymousBaseLength + AnonymousSolvableValueLength + AnonymousZKsLength
-const AnonymousOutputNonSolvableSlotLength = 1 + AnonymousBaseLength + AnonymousNonSolvableValueLength + AnonymousZKsLength
+const AnonymousInputSolvableSlotLength = 1 + AnonymousBaseLength + AnonymousSolvableValueLength + SpendBaseLength
+const AnonymousInputNonSolvableSlotLength = 1 + AnonymousBaseLength + AnonymousNonSolvableValueLength + SpendBaseLength
+const AnonymousOutputSolvableSlotLength = 1 + AnonymousBaseLength + AnonymousSolvableValueLength + AnonymousZKsLength + ExtensionFlagLength
+const AnonymousOutputNonSolvableSlotLength = 1 + AnonymousBaseLength + AnonymousNonSolvableValueLength + AnonymousZKsLength + ExtensionFlagLength
+
+const SecretRerandomizationLength = SecretBaseLength + 2 * SecretSolvableValueLength + EqualityProofLength
+const AnonymousRerandomizationLength = 2 * AnonymousBaseLength + 2 * AnonymousSolvableValueLength + RerandomizationProofLength
 
 const PrivacyMode uint8 = 0b11000000
 const Plaintext uint8 = 0b00000000
@@ -48,6 +86,9 @@ const NoneContractSlot uint8 = 0b00000000
 const ContractCreation uint8 = 0b00010000
 const ContractCall uint8 = 0b00100000
 const ContractReceipt uint8 = 0b00110000
+const ContractLengthLength = 2
+const MaxContractBodyLength = 1 << (8 * ContractLengthLength) - 1
+const MaxContractCount = 1 << 8 - 1
 
 const TxSlotKind uint8 = 0b00001000
 const InputSlot uint8 = 0b00000000
@@ -59,4 +100,14 @@ const NonSolvable uint8 = 0b00000000
 
 const IsGasSlot uint8 = 0b00000011
 
+const AccountKeyLength = 2 * Bn256ZqBits / ByteBits
+const ViewKeyLength = (Bn256ZqBits + Bn256PointBits) / ByteBits
+const AccountBaseLength = 2 * Bn256PointBits / ByteBits
+
+const HardenedKeyStart uint32 = 1 << 31
+const ChainCodeLength = 32
+const ExtendedPrivateKeyLength = 1 + 4 + ChainCodeLength + Bn256ZqBits / ByteBits
+const ExtendedBaseLength = 1 + 4 + ChainCodeLength + Bn256PointBits / ByteBits
 
+const MerkleProofHeaderLength = 8
+const BlockHeaderLength = 3 * HashLength + 16
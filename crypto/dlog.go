@@ -0,0 +1,47 @@
+package crypto
+
+import (
+	"github.com/Acoustical/maskash/errors"
+	"golang.org/x/crypto/bn256"
+	"math/big"
+)
+
+// DLogTable is a baby-step giant-step table solving p = vg for v in [0, 2^bits)
+type DLogTable struct {
+	m, steps int64
+	baby map[string]int64
+	giant *bn256.G1
+}
+
+// NewDLogTable precomputes the baby steps of generator g for values in [0, 2^bits)
+func NewDLogTable(g *Generator, bits int) *DLogTable {
+	babyBits := (bits + 1) / 2
+	m := int64(1) << babyBits
+	table := &DLogTable{
+		m: m,
+		steps: int64(1) << (bits - babyBits),
+		baby: make(map[string]int64, m),
+	}
+
+	step := new(bn256.G1).ScalarMult(g.G1, big.NewInt(0))
+	for j := int64(0); j < m; j++ {
+		table.baby[string(step.Marshal())] = j
+		step = new(bn256.G1).Add(step, g.G1)
+	}
+
+	table.giant = new(bn256.G1).ScalarMult(g.G1, big.NewInt(m))
+	table.giant.Neg(table.giant)
+	return table
+}
+
+// Solve returns v which satisfies p = vg
+func (table *DLogTable) Solve(p *Commitment) (*big.Int, error) {
+	cur := p.G1
+	for i := int64(0); i < table.steps; i++ {
+		if j, ok := table.baby[string(cur.Marshal())]; ok {
+			return big.NewInt(i*table.m + j), nil
+		}
+		cur = new(bn256.G1).Add(cur, table.giant)
+	}
+	return nil, errors.NewCannotFindValueError()
+}
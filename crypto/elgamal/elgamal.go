@@ -0,0 +1,221 @@
+// Package elgamal implements exponential ElGamal over bn256 G1,
+// a ciphertext of v under the public key (g, h = sk g) is c = v g + r h, d = r g
+package elgamal
+
+import (
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/errors"
+	"golang.org/x/crypto/bn256"
+	"math/big"
+	"sync"
+)
+
+// PublicKey is h = sk g with the generator g of the key
+type PublicKey struct {g, h *crypto.Generator}
+
+// PrivateKey is the scalar sk of a PublicKey
+type PrivateKey struct {
+	*PublicKey
+	sk *big.Int
+}
+
+// KeyGen returns a random key pair with the generator g
+func KeyGen(g *crypto.Generator) (*PrivateKey, error) {
+	ks, err := crypto.RandomZq(1)
+	if err != nil {return nil, err}
+	return NewPrivateKey(g, ks[0]), nil
+}
+
+func NewPrivateKey(g *crypto.Generator, sk *big.Int) *PrivateKey {
+	h := new(crypto.Generator).Mul(g, sk)
+	return &PrivateKey{&PublicKey{g, h}, sk}
+}
+
+func NewPublicKey(g, h *crypto.Generator) *PublicKey {return &PublicKey{g, h}}
+
+func (pk *PublicKey) G() *crypto.Generator {return pk.g}
+
+func (pk *PublicKey) H() *crypto.Generator {return pk.h}
+
+// Encrypt encrypts v with a random r and returns the ciphertext with r
+func (pk *PublicKey) Encrypt(v *big.Int) (*Ciphertext, *big.Int, error) {
+	rs, err := crypto.RandomZq(1)
+	if err != nil {return nil, nil, err}
+	return pk.EncryptWith(v, rs[0]), rs[0], nil
+}
+
+// EncryptWith encrypts v with the given r
+func (pk *PublicKey) EncryptWith(v, r *big.Int) *Ciphertext {return pk.EncryptBy(pk.g, v, r)}
+
+// EncryptBy encrypts v against the value generator gv, c = v gv + r h, d = r g
+func (pk *PublicKey) EncryptBy(gv *crypto.Generator, v, r *big.Int) *Ciphertext {
+	c := new(crypto.Commitment).FixedSet(gv, pk.h, v, r)
+	d := new(crypto.Commitment).SetIntByGenerator(pk.g, r)
+	return &Ciphertext{c, d}
+}
+
+// Rerandomize adds a fresh encryption of zero and returns the new ciphertext with its randomness
+func (pk *PublicKey) Rerandomize(ct *Ciphertext) (*Ciphertext, *big.Int, error) {
+	rs, err := crypto.RandomZq(1)
+	if err != nil {return nil, nil, err}
+	out, err := pk.RerandomizeWith(ct, rs[0])
+	return out, rs[0], err
+}
+
+// RerandomizeWith returns c + r h, d + r g
+func (pk *PublicKey) RerandomizeWith(ct *Ciphertext, r *big.Int) (*Ciphertext, error) {
+	if !ct.Solvable() {return nil, errors.NewCannotSolveError()}
+	zero := pk.EncryptWith(big.NewInt(0), r)
+	return new(Ciphertext).Add(ct, zero), nil
+}
+
+func (pk *PublicKey) Bytes() []byte {
+	pointBytes := common.Bn256PointBits / common.ByteBits
+	bytes := make([]byte, common.ElGamalPublicKeyLength)
+	copy(bytes[:pointBytes], pk.g.Bytes())
+	copy(bytes[pointBytes:], pk.h.Bytes())
+	return bytes
+}
+
+func (pk *PublicKey) SetBytes(b []byte) (*PublicKey, error) {
+	bLen := len(b)
+	if bLen != common.ElGamalPublicKeyLength {return nil, errors.NewWrongInputLength(bLen)}
+	pointBytes := common.Bn256PointBits / common.ByteBits
+	g, err := setPoint(b[:pointBytes])
+	if err != nil {return nil, err}
+	h, err := setPoint(b[pointBytes:])
+	if err != nil {return nil, err}
+	pk.g, pk.h = &crypto.Generator{G1: g}, &crypto.Generator{G1: h}
+	return pk, nil
+}
+
+// DecryptPoint returns v gv = c - sk d
+func (key *PrivateKey) DecryptPoint(ct *Ciphertext) (*crypto.Commitment, error) {
+	if !ct.Solvable() {return nil, errors.NewCannotSolveError()}
+	p := new(crypto.Commitment).Mul(ct.d, key.sk)
+	p.Neg()
+	return p.AddBy(ct.c), nil
+}
+
+// Decrypt solves v in [0, 2^bits) against the generator of the key, the tables are cached by bits
+func (key *PrivateKey) Decrypt(ct *Ciphertext, bits int) (*big.Int, error) {
+	return key.DecryptWith(Table(key.g, bits), ct)
+}
+
+// DecryptOnce solves v like Decrypt without caching the table, for a generator used by one ciphertext only
+func (key *PrivateKey) DecryptOnce(ct *Ciphertext, bits int) (*big.Int, error) {
+	return key.DecryptWith(crypto.NewDLogTable(key.g, bits), ct)
+}
+
+// DecryptWith solves v with a precomputed table of the value generator
+func (key *PrivateKey) DecryptWith(table *crypto.DLogTable, ct *Ciphertext) (*big.Int, error) {
+	p, err := key.DecryptPoint(ct)
+	if err != nil {return nil, err}
+	return table.Solve(p)
+}
+
+func (key *PrivateKey) Int() *big.Int {return key.sk}
+
+type tableKey struct {
+	g string
+	bits int
+}
+
+// MaxCachedTables bounds the cached tables, the oldest table is dropped first
+const MaxCachedTables = 64
+
+var tables = struct {
+	sync.Mutex
+	m map[tableKey]*crypto.DLogTable
+	order []tableKey
+}{m: make(map[tableKey]*crypto.DLogTable)}
+
+// Table returns the cached discrete log table of g for values in [0, 2^bits)
+func Table(g *crypto.Generator, bits int) *crypto.DLogTable {
+	k := tableKey{string(g.Marshal()), bits}
+	tables.Lock()
+	table, ok := tables.m[k]
+	tables.Unlock()
+	if ok {return table}
+
+	table = crypto.NewDLogTable(g, bits)
+	tables.Lock()
+	defer tables.Unlock()
+	if cached, ok := tables.m[k]; ok {return cached}
+	tables.m[k] = table
+	tables.order = append(tables.order, k)
+	if len(tables.order) > MaxCachedTables {
+		delete(tables.m, tables.order[0])
+		tables.order = tables.order[1:]
+	}
+	return table
+}
+
+// Ciphertext is (c, d), d is nil if the ciphertext is not solvable
+type Ciphertext struct {c, d *crypto.Commitment}
+
+func NewCiphertext(c, d *crypto.Commitment) *Ciphertext {return &Ciphertext{c, d}}
+
+func (ct *Ciphertext) C() *crypto.Commitment {return ct.c}
+
+func (ct *Ciphertext) D() *crypto.Commitment {return ct.d}
+
+func (ct *Ciphertext) Solvable() bool {return ct.d != nil}
+
+// Add sets ct to a + b, the sum is solvable only if both are solvable
+func (ct *Ciphertext) Add(a, b *Ciphertext) *Ciphertext {
+	c := &crypto.Commitment{G1: new(bn256.G1).Add(a.c.G1, b.c.G1)}
+	var d *crypto.Commitment
+	if a.Solvable() && b.Solvable() {d = &crypto.Commitment{G1: new(bn256.G1).Add(a.d.G1, b.d.G1)}}
+	ct.c, ct.d = c, d
+	return ct
+}
+
+// Sub sets ct to a - b
+func (ct *Ciphertext) Sub(a, b *Ciphertext) *Ciphertext {
+	return ct.Add(a, new(Ciphertext).ScalarMul(b, big.NewInt(-1)))
+}
+
+// ScalarMul sets ct to k a
+func (ct *Ciphertext) ScalarMul(a *Ciphertext, k *big.Int) *Ciphertext {
+	k = new(big.Int).Mod(k, bn256.Order)
+	c := new(crypto.Commitment).Mul(a.c, k)
+	var d *crypto.Commitment
+	if a.Solvable() {d = new(crypto.Commitment).Mul(a.d, k)}
+	ct.c, ct.d = c, d
+	return ct
+}
+
+// Bytes returns c | d, or c alone if the ciphertext is not solvable
+func (ct *Ciphertext) Bytes() []byte {
+	if !ct.Solvable() {return ct.c.Bytes()}
+	pointBytes := common.Bn256PointBits / common.ByteBits
+	bytes := make([]byte, common.ElGamalCiphertextLength)
+	copy(bytes[:pointBytes], ct.c.Bytes())
+	copy(bytes[pointBytes:], ct.d.Bytes())
+	return bytes
+}
+
+func (ct *Ciphertext) SetBytes(b []byte) (*Ciphertext, error) {
+	bLen := len(b)
+	pointBytes := common.Bn256PointBits / common.ByteBits
+	if bLen != common.ElGamalCiphertextLength && bLen != pointBytes {return nil, errors.NewWrongInputLength(bLen)}
+	c, err := setPoint(b[:pointBytes])
+	if err != nil {return nil, err}
+	ct.c, ct.d = &crypto.Commitment{G1: c}, nil
+	if bLen == common.ElGamalCiphertextLength {
+		d, err := setPoint(b[pointBytes:])
+		if err != nil {return nil, err}
+		ct.d = &crypto.Commitment{G1: d}
+	}
+	return ct, nil
+}
+
+// setPoint decodes a point, bytes off the curve are an error
+func setPoint(b []byte) (*bn256.G1, error) {
+	point, err := crypto.SetBytes(b)
+	if err != nil {return nil, err}
+	if point == nil {return nil, errors.NewInvalidPointError()}
+	return point, nil
+}
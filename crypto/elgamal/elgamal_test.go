@@ -0,0 +1,113 @@
+package elgamal
+
+import (
+	"fmt"
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/errors"
+	"math/big"
+	"testing"
+)
+
+func TestElGamal(t *testing.T) {
+	g := new(crypto.Generator).Init(big.NewInt(1))
+	key, err := KeyGen(g)
+	errors.Handle(err)
+	pk := key.PublicKey
+	bits := common.RangeProofShortBits
+
+	a, _, err := pk.Encrypt(big.NewInt(1145))
+	errors.Handle(err)
+	b, _, err := pk.Encrypt(big.NewInt(14))
+	errors.Handle(err)
+	fmt.Printf("Ciphertext\n%x\n\n", a.Bytes())
+
+	cases := []struct {
+		name string
+		ct *Ciphertext
+		v int64
+	}{
+		{"add", new(Ciphertext).Add(a, b), 1159},
+		{"sub", new(Ciphertext).Sub(a, b), 1131},
+		{"mul", new(Ciphertext).ScalarMul(b, big.NewInt(3)), 42},
+	}
+	for _, c := range cases {
+		v, err := key.Decrypt(c.ct, bits)
+		if err != nil || v.Int64() != c.v {
+			t.Errorf("%s decrypted to %d, want %d", c.name, v, c.v)
+		}
+	}
+
+	re, _, err := pk.Rerandomize(a)
+	errors.Handle(err)
+	if re.c.Cmp(a.c) || re.d.Cmp(a.d) {
+		t.Errorf("rerandomized ciphertext not changed")
+	}
+	if v, err := key.Decrypt(re, bits); err != nil || v.Int64() != 1145 {
+		t.Errorf("rerandomized ciphertext decrypted to %d", v)
+	}
+
+	ct, err := new(Ciphertext).SetBytes(a.Bytes())
+	errors.Handle(err)
+	pk1, err := new(PublicKey).SetBytes(pk.Bytes())
+	errors.Handle(err)
+	if !ct.c.Cmp(a.c) || !ct.d.Cmp(a.d) || pk1.h.String() != pk.h.String() {
+		t.Errorf("encoding round trip failed")
+	}
+
+	offCurve := a.Bytes()
+	for {
+		offCurve[len(offCurve)-1]++
+		if point, _ := crypto.SetBytes(offCurve[len(offCurve)/2:]); point == nil {break}
+	}
+	if _, err = new(Ciphertext).SetBytes(offCurve); err == nil {
+		t.Errorf("ciphertext decoded from a point off the curve")
+	}
+	if _, err = new(PublicKey).SetBytes(offCurve); err == nil {
+		t.Errorf("public key decoded from a point off the curve")
+	}
+
+	gv := crypto.HashToGenerator(crypto.HashBytes("elgamal test"))
+	ct = pk.EncryptBy(gv, big.NewInt(810), big.NewInt(19))
+	if v, err := key.DecryptWith(crypto.NewDLogTable(gv, bits), ct); err != nil || v.Int64() != 810 {
+		t.Errorf("ciphertext of another value generator decrypted to %d", v)
+	}
+
+	other, err := KeyGen(g)
+	errors.Handle(err)
+	if _, err := other.Decrypt(a, bits); err == nil {
+		t.Errorf("ciphertext decrypted by another key")
+	}
+}
+
+func TestTableCache(t *testing.T) {
+	g := crypto.HashToGenerator(crypto.HashBytes("table cache test"))
+	if Table(g, 8) != Table(g, 8) {
+		t.Errorf("table of one generator not cached")
+	}
+	for i := 0; i < MaxCachedTables; i++ {
+		Table(crypto.HashToGenerator(crypto.HashBytes{byte(i)}), 4)
+	}
+	tables.Lock()
+	n := len(tables.m)
+	_, ok := tables.m[tableKey{string(g.Marshal()), 8}]
+	tables.Unlock()
+	if n > MaxCachedTables || ok {
+		t.Errorf("table cache holds %d tables, the oldest kept %v", n, ok)
+	}
+
+	// a generator used once is solved without entering the cache
+	key, err := KeyGen(g)
+	errors.Handle(err)
+	ct, _, err := key.Encrypt(big.NewInt(7))
+	errors.Handle(err)
+	if v, err := key.DecryptOnce(ct, 4); err != nil || v.Int64() != 7 {
+		t.Errorf("ciphertext decrypted once to %d", v)
+	}
+	tables.Lock()
+	_, ok = tables.m[tableKey{string(g.Marshal()), 4}]
+	tables.Unlock()
+	if ok {
+		t.Errorf("table of a generator used once cached")
+	}
+}
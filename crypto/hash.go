@@ -9,6 +9,11 @@ type HashVariable interface {
 	Bytes() []byte
 }
 
+// HashBytes wraps raw bytes such as domain tags as a HashVariable
+type HashBytes []byte
+
+func (b HashBytes) Bytes() []byte {return b}
+
 type Hash [32]byte
 
 func Hash_(args ...HashVariable) Hash {
@@ -41,6 +41,22 @@ func RandomPoints(n int) ([]*Generator, []*big.Int, error) {
 	return g, k, nil
 }
 
+// HashToGenerator maps args to a Generator of unknown discrete log by try-and-increment
+func HashToGenerator(args ...HashVariable) *Generator {
+	P, _ := new(big.Int).SetString("65000549695646603732796438742359905742825358107623003571877145026864184071783", 10)
+	zqBytes := common.Bn256ZqBits / common.ByteBits
+	for counter := int64(0); ; counter++ {
+		x := Hash_(append(args, big.NewInt(counter))...).BigInt()
+		x.Mod(x, P)
+		xBytes := x.Bytes()
+		bytes := make([]byte, common.Bn256PointBits / common.ByteBits)
+		bytes[0] = 2
+		copy(bytes[1+zqBytes-len(xBytes):], xBytes)
+		g, _ := SetBytes(bytes)
+		if g != nil {return &Generator{g}}
+	}
+}
+
 // Generator is a curve generator
 type Generator struct {
 	*bn256.G1
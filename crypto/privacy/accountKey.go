@@ -0,0 +1,173 @@
+package privacy
+
+import (
+	"bytes"
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/errors"
+	"golang.org/x/crypto/bn256"
+	"math/big"
+)
+
+var viewKeyDomain = crypto.HashBytes("maskash view key")
+
+// AccountKey is a two-key scheme, the spend key authorizes spends and the view key solves values
+type AccountKey struct {spend, view *PrivateKey}
+
+func NewRandomAccountKey() *AccountKey {return NewAccountKey(NewRandomPrivateKey())}
+
+// NewAccountKey derives the view key from the spend key, so one backup covers both
+func NewAccountKey(spend *PrivateKey) *AccountKey {
+	view := crypto.Hash_(spend, viewKeyDomain).BigInt()
+	view.Mod(view, bn256.Order)
+	return &AccountKey{spend, &PrivateKey{view}}
+}
+
+// NewSeparateAccountKey uses an independent view key
+func NewSeparateAccountKey(spend, view *PrivateKey) *AccountKey {return &AccountKey{spend, view}}
+
+func (key *AccountKey) SpendKey() *PrivateKey {return key.spend}
+
+func (key *AccountKey) ViewKey() *ViewKey {
+	return &ViewKey{key.view, new(crypto.Generator).Init(key.spend.Int)}
+}
+
+func (key *AccountKey) GenAccountBase() *AccountBase {return key.ViewKey().GenAccountBase()}
+
+// GenPlaintextBase returns the address of the spend key
+func (key *AccountKey) GenPlaintextBase() *PlaintextBase {return key.spend.GenPlaintextBase()}
+
+// GenSecretBase returns the base of the view key with the spend base of the spend key
+func (key *AccountKey) GenSecretBase() *SecretBase {return key.GenAccountBase().GenSecretBase()}
+
+func (key *AccountKey) GenAnonymousBase() *AnonymousBase {return key.GenAccountBase().GenAnonymousBase()}
+
+// Spendable returns whether the spend key of the account can spend the slot
+func (key *AccountKey) Spendable(slot Slot) bool {
+	g, spend, err := spendParts(slot.Base())
+	if err != nil {return false}
+	return bytes.Equal(new(crypto.Generator).Mul(g, key.spend.Int).Bytes(), spend.Bytes())
+}
+
+// NewSpendProof authorizes the spend of a slot of the account for the message e with the spend key
+func (key *AccountKey) NewSpendProof(slot Slot, e *big.Int) (*SpendProof, error) {return NewSpendProof(key.spend, slot, e)}
+
+// NewPlaintextInputSlot signs the input with the spend key
+func (key *AccountKey) NewPlaintextInputSlot(nonce, value *big.Int) *PlaintextSlot {
+	return key.spend.NewPlaintextInputSlot(nonce, value)
+}
+
+func (key *AccountKey) Bytes() []byte {
+	zqBytes := common.Bn256ZqBits / common.ByteBits
+	bytes := make([]byte, common.AccountKeyLength)
+	spendBytes := key.spend.Bytes()
+	viewBytes := key.view.Bytes()
+	copy(bytes[zqBytes-len(spendBytes):zqBytes], spendBytes)
+	copy(bytes[2*zqBytes-len(viewBytes):], viewBytes)
+	return bytes
+}
+
+func (key *AccountKey) SetBytes(b []byte) (*AccountKey, error) {
+	bLen := len(b)
+	if bLen != common.AccountKeyLength {return nil, errors.NewWrongInputLength(bLen)}
+	zqBytes := common.Bn256ZqBits / common.ByteBits
+	key.spend = &PrivateKey{new(big.Int).SetBytes(b[:zqBytes])}
+	key.view = &PrivateKey{new(big.Int).SetBytes(b[zqBytes:])}
+	return key, nil
+}
+
+// ViewKey solves and scans the values of an account but can not spend them
+type ViewKey struct {
+	view *PrivateKey
+	spend *crypto.Generator
+}
+
+func (key *ViewKey) GenAccountBase() *AccountBase {
+	return &AccountBase{new(crypto.Generator).Init(key.view.Int), key.spend}
+}
+
+func (key *ViewKey) GenPlaintextBase() *PlaintextBase {return key.GenAccountBase().GenPlaintextBase()}
+
+func (key *ViewKey) GenSecretBase() *SecretBase {return key.GenAccountBase().GenSecretBase()}
+
+func (key *ViewKey) GenAnonymousBase() *AnonymousBase {return key.GenAccountBase().GenAnonymousBase()}
+
+// Owns returns whether the AnonymousBase belongs to the account
+func (key *ViewKey) Owns(base *AnonymousBase) bool {return key.view.Owns(base)}
+
+// Spendable returns whether the spend base of a slot of the account is the spend base of the account, the spend base
+// of an Anonymous slot is hidden by the randomness of its base so only its presence is checked
+func (key *ViewKey) Spendable(slot Slot) bool {
+	spend := SpendBase(slot.Base())
+	if spend == nil {return false}
+	if slot.Base().BaseMode() == common.Anonymous {return true}
+	return bytes.Equal(spend.Bytes(), key.spend.Bytes())
+}
+
+func (key *ViewKey) NewAnonymousScanner(workers, batchSize int) *AnonymousScanner {
+	return key.view.NewAnonymousScanner(workers, batchSize)
+}
+
+// SolveSlot solves the value of a slot owned by the account
+func (key *ViewKey) SolveSlot(slot Slot) (*big.Int, error) {
+	switch s := slot.(type) {
+	case *AnonymousSlot:
+		return key.view.SolveAnonymous(s.AnonymousBase, s.AnonymousValue)
+	default:
+		return slot.Value().Solve(key.view)
+	}
+}
+
+func (key *ViewKey) Bytes() []byte {
+	zqBytes := common.Bn256ZqBits / common.ByteBits
+	bytes := make([]byte, common.ViewKeyLength)
+	viewBytes := key.view.Bytes()
+	copy(bytes[zqBytes-len(viewBytes):zqBytes], viewBytes)
+	copy(bytes[zqBytes:], key.spend.Bytes())
+	return bytes
+}
+
+func (key *ViewKey) SetBytes(b []byte) (*ViewKey, error) {
+	bLen := len(b)
+	if bLen != common.ViewKeyLength {return nil, errors.NewWrongInputLength(bLen)}
+	zqBytes := common.Bn256ZqBits / common.ByteBits
+	key.view = &PrivateKey{new(big.Int).SetBytes(b[:zqBytes])}
+	key.spend = new(crypto.Generator).SetBytes(b[zqBytes:])
+	return key, nil
+}
+
+// AccountBase is the public address of an AccountKey, made of the view base and the spend base
+type AccountBase struct {view, spend *crypto.Generator}
+
+func (base *AccountBase) GenPlaintextBase() *PlaintextBase {
+	return &PlaintextBase{crypto.NewAddress(base.spend)}
+}
+
+// GenSecretBase returns the base encrypting to the view key, its spends are authorized by the spend key only
+func (base *AccountBase) GenSecretBase() *SecretBase {return &SecretBase{base.view, base.spend}}
+
+func (base *AccountBase) GenAnonymousBase() *AnonymousBase {return base.GenSecretBase().GenAnonymousBase()}
+
+func (base *AccountBase) Bytes() []byte {
+	pointBytes := common.Bn256PointBits / common.ByteBits
+	bytes := make([]byte, common.AccountBaseLength)
+	copy(bytes[:pointBytes], base.view.Bytes())
+	copy(bytes[pointBytes:], base.spend.Bytes())
+	return bytes
+}
+
+func (base *AccountBase) SetBytes(b []byte) error {
+	bLen := len(b)
+	if bLen != common.AccountBaseLength {return errors.NewWrongInputLength(bLen)}
+	pointBytes := common.Bn256PointBits / common.ByteBits
+	base.view = new(crypto.Generator).SetBytes(b[:pointBytes])
+	base.spend = new(crypto.Generator).SetBytes(b[pointBytes:])
+	return nil
+}
+
+// OpenAsset opens the asset tag and solves the value of an asset tagged slot owned by the account
+func (key *ViewKey) OpenAsset(slot Slot) (AssetID, *big.Int, error) {return key.view.OpenAsset(slot)}
+
+// Key returns the view private key, it solves the values and proves the balance of the slots of the account,
+// the spends are authorized by the SpendProofs of the spend key
+func (key *ViewKey) Key() *PrivateKey {return key.view}
@@ -0,0 +1,112 @@
+package privacy
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/errors"
+	"math/big"
+	"testing"
+)
+
+func TestAccountKey(t *testing.T) {
+	key := NewRandomAccountKey()
+	keyBytes := key.Bytes()
+	fmt.Printf("Account Key\n%x\n\n", keyBytes)
+
+	key0, err := new(AccountKey).SetBytes(keyBytes)
+	errors.Handle(err)
+	if !bytes.Equal(key0.Bytes(), keyBytes) {
+		t.Errorf("account key encoding mismatch")
+	}
+
+	viewBytes := key.ViewKey().Bytes()
+	fmt.Printf("View Key\n%x\n\n", viewBytes)
+	viewKey, err := new(ViewKey).SetBytes(viewBytes)
+	errors.Handle(err)
+
+	baseBytes := key.GenAccountBase().Bytes()
+	fmt.Printf("Account Base\n%x\n\n", baseBytes)
+	accountBase := new(AccountBase)
+	err = accountBase.SetBytes(baseBytes)
+	errors.Handle(err)
+
+	if !bytes.Equal(accountBase.GenPlaintextBase().Bytes(), key.GenPlaintextBase().Bytes()) ||
+		!bytes.Equal(viewKey.GenPlaintextBase().Bytes(), key.GenPlaintextBase().Bytes()) {
+		t.Errorf("plaintext base mismatch")
+	}
+	if bytes.Equal(viewKey.view.GenPlaintextBase().Bytes(), key.GenPlaintextBase().Bytes()) {
+		t.Errorf("view key can sign for the plaintext base")
+	}
+
+	value := big.NewInt(114514)
+	rl, _ := crypto.RandomZq(2)
+
+	secretSlot, err := accountBase.GenSecretBase().NewSecretOutputSlot(value, rl[0], true, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+	v, err := viewKey.SolveSlot(secretSlot)
+	errors.Handle(err)
+	fmt.Printf("Secret value solved by view key\n%d\n\n", v)
+	if v.Cmp(value) != 0 {
+		t.Errorf("secret value solved wrong")
+	}
+
+	anonymousSlot, err := accountBase.GenAnonymousBase().NewAnonymousOutputSlot(value, rl[1], true, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+	if !viewKey.Owns(anonymousSlot.AnonymousBase) {
+		t.Errorf("view key can not recognize the anonymous slot")
+	}
+	v, err = viewKey.SolveSlot(anonymousSlot)
+	errors.Handle(err)
+	fmt.Printf("Anonymous value solved by view key\n%d\n\n", v)
+	if v.Cmp(value) != 0 {
+		t.Errorf("anonymous value solved wrong")
+	}
+
+	e := big.NewInt(1919)
+	for _, slot := range []Slot{secretSlot, anonymousSlot} {
+		decoded, err := newOutputSlot(slot.Bytes())
+		errors.Handle(err)
+		proof, err := key.NewSpendProof(decoded, e)
+		errors.Handle(err)
+		proof1, err := new(SpendProof).SetBytes(proof.Bytes())
+		errors.Handle(err)
+		if !proof1.Check(slot, e) {
+			t.Errorf("spend proof of the spend key rejected")
+		}
+		if proof1.Check(slot, big.NewInt(810)) {
+			t.Errorf("spend proof accepted for another message")
+		}
+		if _, err = NewSpendProof(viewKey.Key(), slot, e); err == nil {
+			t.Errorf("view key produced a spend proof")
+		}
+	}
+
+	// the input slot carries the spend base of the output
+	inputs := []Slot{NewSecretInputSlot(secretSlot), NewAnonymousInputSlot(anonymousSlot)}
+	for i, input := range inputs {
+		decoded, err := newOutputSlot(input.Bytes())
+		errors.Handle(err)
+		if !key.Spendable(decoded) || !viewKey.Spendable(decoded) || !bytes.Equal(decoded.Bytes(), input.Bytes()) {
+			t.Errorf("input slot %d lost its spend base", i)
+		}
+	}
+
+	// an output to the view base without the spend base can not be spent by any key
+	stripped := new(SecretBase)
+	errors.Handle(stripped.SetBytes(accountBase.GenSecretBase().Bytes()))
+	strippedSlot, err := stripped.NewSecretOutputSlot(value, rl[0], true, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+	if _, err = NewSpendProof(viewKey.Key(), strippedSlot, e); err == nil {
+		t.Errorf("view key spent an output without a spend base")
+	}
+	if key.Spendable(strippedSlot) || viewKey.Spendable(strippedSlot) {
+		t.Errorf("output without a spend base reported spendable")
+	}
+}
+
+func newOutputSlot(b []byte) (Slot, error) {
+	if b[0] & common.PrivacyMode == common.Anonymous {return new(AnonymousSlot).Init().SetBytes(b)}
+	return new(SecretSlot).Init().SetBytes(b)
+}
@@ -0,0 +1,140 @@
+package privacy
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/crypto/elgamal"
+	"github.com/Acoustical/maskash/crypto/zkproofs"
+	"github.com/Acoustical/maskash/errors"
+	"math/big"
+	mathbits "math/bits"
+)
+
+// Add sets value to a + b, both values must be solvable and committed against G
+func (value *SecretValue) Add(a, b *SecretValue) (*SecretValue, error) {
+	if !a.Solvable() || !b.Solvable() {return nil, errors.NewCannotSolveError()}
+	if a.asset != nil || b.asset != nil {return nil, errors.NewCannotSolveError()}
+	ct := new(elgamal.Ciphertext).Add(a.Ciphertext(), b.Ciphertext())
+	value.c, value.d, value.asset = ct.C(), ct.D(), nil
+	return value, nil
+}
+
+// AggregateSecretValues sums the solvable values into one ciphertext which decrypts to the total amount
+func AggregateSecretValues(values ...*SecretValue) (*SecretValue, error) {
+	if len(values) == 0 {return nil, errors.NewWrongInputLength(0)}
+	sum := &SecretValue{values[0].c, values[0].d, values[0].asset}
+	if !sum.Solvable() || sum.asset != nil {return nil, errors.NewCannotSolveError()}
+	var err error
+	for _, value := range values[1:] {
+		sum, err = new(SecretValue).Add(sum, value)
+		if err != nil {return nil, err}
+	}
+	return sum, nil
+}
+
+// aggregateBits is the bound of the total of n values in the short range
+func aggregateBits(n int) int {return common.RangeProofShortBits + mathbits.Len(uint(n-1))}
+
+// SecretAggregate is the decrypted total of the values received by one SecretBase,
+// the proof shows the total is the decryption of the sum of the listed values
+type SecretAggregate struct {
+	Base *SecretBase
+	Values []*SecretValue
+	Total *big.Int
+	zk *zkproofs.EqualityZK
+}
+
+// NewSecretAggregate sums the solvable SecretSlots owned by prv and decrypts the total once
+func (prv *PrivateKey) NewSecretAggregate(slots ...*SecretSlot) (*SecretAggregate, error) {
+	n := len(slots)
+	if n == 0 || n > common.MaxAggregateSize {return nil, errors.NewWrongInputLength(n)}
+	base := prv.GenSecretBase()
+	aggregate := &SecretAggregate{Base: base, Values: make([]*SecretValue, n)}
+	for i, slot := range slots {
+		if !bytes.Equal(slot.SecretBase.Bytes(), base.Bytes()) {return nil, errors.NewCannotSolveError()}
+		aggregate.Values[i] = slot.SecretValue
+	}
+
+	sum, err := AggregateSecretValues(aggregate.Values...)
+	if err != nil {return nil, err}
+	g := new(crypto.Generator).Init(big.NewInt(1))
+	aggregate.Total, err = prv.ElGamal(g).Decrypt(sum.Ciphertext(), aggregateBits(n))
+	if err != nil {return nil, err}
+
+	// h = sk G and c - total G = sk d
+	g1, y1, g2, y2 := aggregate.statement(sum)
+	aggregate.zk = new(zkproofs.EqualityZK).Init()
+	aggregate.zk.SetPrivate(prv.Int, g1, y1, g2, y2, aggregate.context(sum))
+	err = aggregate.zk.Proof()
+	if err != nil {return nil, err}
+	return aggregate, nil
+}
+
+func (aggregate *SecretAggregate) statement(sum *SecretValue) (*crypto.Generator, *crypto.Commitment, *crypto.Generator, *crypto.Commitment) {
+	g := new(crypto.Generator).Init(big.NewInt(1))
+	h := &crypto.Commitment{G1: aggregate.Base.h.G1}
+	d := &crypto.Generator{G1: sum.d.G1}
+	y2 := new(crypto.Commitment).SetInt(aggregate.Total).Neg().AddBy(sum.c)
+	return g, h, d, y2
+}
+
+func (aggregate *SecretAggregate) context(sum *SecretValue) *big.Int {
+	return crypto.Hash_(aggregate.Base, sum, aggregate.Total).BigInt()
+}
+
+// Sum returns the aggregate ciphertext of the listed values
+func (aggregate *SecretAggregate) Sum() (*SecretValue, error) {return AggregateSecretValues(aggregate.Values...)}
+
+// Check verifies the total is the decryption of the sum of the listed values under the base
+func (aggregate *SecretAggregate) Check() bool {
+	if aggregate.zk == nil || aggregate.Total.Sign() < 0 {return false}
+	sum, err := aggregate.Sum()
+	if err != nil {return false}
+	g1, y1, g2, y2 := aggregate.statement(sum)
+	aggregate.zk.SetPublic(g1, y1, g2, y2, aggregate.context(sum))
+	return aggregate.zk.Check()
+}
+
+// Bytes returns base | total | n | n values | proof
+func (aggregate *SecretAggregate) Bytes() []byte {
+	zqBytes := common.Bn256ZqBits / common.ByteBits
+	total := make([]byte, zqBytes)
+	totalBytes := aggregate.Total.Bytes()
+	copy(total[zqBytes-len(totalBytes):], totalBytes)
+	count := make([]byte, common.AggregateCountLength)
+	binary.BigEndian.PutUint16(count, uint16(len(aggregate.Values)))
+
+	parts := [][]byte{aggregate.Base.Bytes(), total, count}
+	for _, value := range aggregate.Values {
+		parts = append(parts, value.Bytes())
+	}
+	return concatBytes(append(parts, aggregate.zk.Bytes())...)
+}
+
+func (aggregate *SecretAggregate) SetBytes(b []byte) (*SecretAggregate, error) {
+	bLen := len(b)
+	zqBytes := common.Bn256ZqBits / common.ByteBits
+	headLength := common.SecretBaseLength + zqBytes + common.AggregateCountLength
+	if bLen < headLength {return nil, errors.NewWrongInputLength(bLen)}
+	n := int(binary.BigEndian.Uint16(b[headLength-common.AggregateCountLength:headLength]))
+	if n == 0 || bLen != headLength + n * common.SecretSolvableValueLength + common.EqualityProofLength {return nil, errors.NewWrongInputLength(bLen)}
+
+	aggregate.Base = new(SecretBase)
+	err := aggregate.Base.SetBytes(b[:common.SecretBaseLength])
+	if err != nil {return nil, err}
+	aggregate.Total = new(big.Int).SetBytes(b[common.SecretBaseLength:common.SecretBaseLength+zqBytes])
+
+	aggregate.Values = make([]*SecretValue, n)
+	start := headLength
+	for i := range aggregate.Values {
+		aggregate.Values[i], err = new(SecretValue).SetBytes(b[start:start+common.SecretSolvableValueLength])
+		if err != nil {return nil, err}
+		start += common.SecretSolvableValueLength
+	}
+	aggregate.zk = new(zkproofs.EqualityZK).Init()
+	err = aggregate.zk.SetBytes(b[start:])
+	if err != nil {return nil, err}
+	return aggregate, nil
+}
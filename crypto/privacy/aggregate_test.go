@@ -0,0 +1,55 @@
+package privacy
+
+import (
+	"fmt"
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/errors"
+	"math/big"
+	"testing"
+)
+
+func TestSecretAggregate(t *testing.T) {
+	merchant := NewRandomPrivateKey()
+	base := merchant.GenSecretBase()
+	amounts := []int64{1145, 14, 1919, 810, int64(common.MaxShortValue)}
+	rl, _ := crypto.RandomZq(len(amounts))
+
+	slots := make([]*SecretSlot, len(amounts))
+	var want int64
+	for i, v := range amounts {
+		slot, err := base.NewSecretOutputSlot(big.NewInt(v), rl[i], true, common.NoneContractSlot, nil, nil)
+		errors.Handle(err)
+		slots[i] = slot
+		want += v
+	}
+
+	aggregate, err := merchant.NewSecretAggregate(slots...)
+	errors.Handle(err)
+	fmt.Printf("Total\n%d\n\n", aggregate.Total)
+	if aggregate.Total.Int64() != want {
+		t.Errorf("aggregate total %d, want %d", aggregate.Total, want)
+	}
+
+	aggregate1, err := new(SecretAggregate).SetBytes(aggregate.Bytes())
+	errors.Handle(err)
+	if !aggregate1.Check() {
+		t.Errorf("aggregate check failed")
+	}
+
+	aggregate1.Total = big.NewInt(want + 1)
+	if aggregate1.Check() {
+		t.Errorf("aggregate accepted with a wrong total")
+	}
+	aggregate1.Total = big.NewInt(want)
+	aggregate1.Values = aggregate1.Values[1:]
+	if aggregate1.Check() {
+		t.Errorf("aggregate accepted without a listed value")
+	}
+
+	other, err := NewRandomPrivateKey().GenSecretBase().NewSecretOutputSlot(big.NewInt(1), rl[0], true, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+	if _, err = merchant.NewSecretAggregate(append(slots, other)...); err == nil {
+		t.Errorf("aggregate accepted a slot of another base")
+	}
+}
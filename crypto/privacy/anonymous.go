@@ -3,6 +3,7 @@ package privacy
 import (
 	"github.com/Acoustical/maskash/common"
 	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/crypto/elgamal"
 	"github.com/Acoustical/maskash/crypto/zkproofs"
 	"github.com/Acoustical/maskash/errors"
 	"math/big"
@@ -21,7 +22,8 @@ func NewAnonymousInputSlot(outputSlot *AnonymousSlot) *AnonymousSlot {
 	return slot
 }
 
-func (base *AnonymousBase) NewAnonymousOutputSlot(value, r *big.Int, solvable bool, contractMode uint8,  c ContractSlot) (*AnonymousSlot, error) {
+// NewAnonymousOutputSlot commits value with the blinding factor r, the slot carries an AuditTag to auditor unless it is nil
+func (base *AnonymousBase) NewAnonymousOutputSlot(value, r *big.Int, solvable bool, contractMode uint8,  c ContractSlot, auditor *elgamal.PublicKey) (*AnonymousSlot, error) {
 	slot := new(AnonymousSlot).Init()
 
 	mode := common.Anonymous | common.OutputSlot | contractMode
@@ -30,6 +32,8 @@ func (base *AnonymousBase) NewAnonymousOutputSlot(value, r *big.Int, solvable bo
 	slot.SetBase(base)
 	slot.SetValue(value, r)
 	slot.AnonymousZK, _ = slot.Proof(value, r, slot.AnonymousValue)
+	err := slot.setAudit(auditor, value, r)
+	if err != nil {return nil, err}
 
 	if contractMode != common.NoneContractSlot {
 		if c == nil {return nil, errors.NewNonContractSlotError()}
@@ -45,6 +49,8 @@ type AnonymousSlot struct {
 	*AnonymousValue
 	*AnonymousZK
 	ContractSlot
+	memo []byte
+	audit *AuditTag
 }
 
 func (slot *AnonymousSlot) Init() *AnonymousSlot {
@@ -73,6 +79,7 @@ func (slot *AnonymousSlot) Bytes() []byte {
 			bytes = make([]byte, common.AnonymousInputNonSolvableSlotLength)
 			copy(bytes[1+common.AnonymousBaseLength:1+common.AnonymousBaseLength+common.AnonymousNonSolvableValueLength], slot.AnonymousValue.Bytes())
 		}
+		if slot.AnonymousBase.spend != nil {copy(bytes[len(bytes)-common.SpendBaseLength:], slot.AnonymousBase.spend.Bytes())}
 	} else {
 		var contractLength int
 		var contractBytes []byte
@@ -80,24 +87,32 @@ func (slot *AnonymousSlot) Bytes() []byte {
 			contractBytes = slot.ContractSlot.Bytes()
 			contractLength = len(contractBytes)
 		}
+		memo := extensionBytes(slot.memo, slot.AnonymousValue.asset, slot.audit, slot.AnonymousBase.spend)
 		if slot.mode & common.Solvability == common.Solvable {
-			bytes = make([]byte, common.AnonymousOutputSolvableSlotLength+contractLength)
+			zkEnd := common.AnonymousOutputSolvableSlotLength - common.ExtensionFlagLength
+			bytes = make([]byte, zkEnd+len(memo)+contractLength)
 			copy(bytes[1+common.AnonymousBaseLength:1+common.AnonymousBaseLength+common.AnonymousSolvableValueLength], slot.AnonymousValue.Bytes())
-			copy(bytes[common.AnonymousOutputSolvableSlotLength-common.AnonymousZKsLength:common.AnonymousOutputSolvableSlotLength], slot.AnonymousZK.Bytes())
+			copy(bytes[zkEnd-common.AnonymousZKsLength:zkEnd], slot.AnonymousZK.Bytes())
+			copy(bytes[zkEnd:zkEnd+len(memo)], memo)
 			if contractLength > 0 {
-				copy(bytes[common.AnonymousOutputSolvableSlotLength:], contractBytes)
+				copy(bytes[zkEnd+len(memo):], contractBytes)
 			}
 		} else {
-			bytes = make([]byte, common.AnonymousOutputNonSolvableSlotLength+contractLength)
+			zkEnd := common.AnonymousOutputNonSolvableSlotLength - common.ExtensionFlagLength
+			bytes = make([]byte, zkEnd+len(memo)+contractLength)
 			copy(bytes[1+common.AnonymousBaseLength:1+common.AnonymousBaseLength+common.AnonymousNonSolvableValueLength], slot.AnonymousValue.Bytes())
-			copy(bytes[common.AnonymousOutputNonSolvableSlotLength - common.AnonymousZKsLength:common.AnonymousOutputNonSolvableSlotLength], slot.AnonymousZK.Bytes())
+			copy(bytes[zkEnd-common.AnonymousZKsLength:zkEnd], slot.AnonymousZK.Bytes())
+			copy(bytes[zkEnd:zkEnd+len(memo)], memo)
 			if contractLength > 0 {
-				copy(bytes[common.AnonymousOutputNonSolvableSlotLength:], contractBytes)
+				copy(bytes[zkEnd+len(memo):], contractBytes)
 			}
 		}
 	}
 	bytes[0] = slot.mode
 	copy(bytes[1:1+common.AnonymousBaseLength], slot.AnonymousBase.Bytes())
+	if slot.mode & common.TxSlotKind == common.InputSlot && slot.AnonymousValue.asset != nil {
+		bytes = append(bytes, slot.AnonymousValue.asset.Bytes()...)
+	}
 	return bytes
 }
 
@@ -124,9 +139,25 @@ func (slot *AnonymousSlot) SetBytes(b []byte) (*AnonymousSlot, error) {
 	if mode & common.TxSlotKind == common.OutputSlot {
 		start = end
 		end = start + common.AnonymousZKsLength
+		if bLen < end {return nil, errors.NewWrongInputLength(bLen)}
 		slot.AnonymousZK = new(AnonymousZK)
 		err = slot.AnonymousZK.SetBytes(b[start:end])
 		if err != nil {return nil, err}
+
+		slot.memo, slot.AnonymousValue.asset, slot.audit, slot.AnonymousBase.spend, _, err = setExtensionBytes(b[end:], common.MemoCipherLength)
+		if err != nil {return nil, err}
+		if slot.AnonymousValue.asset != nil && !slot.AnonymousValue.Solvable() {return nil, errors.NewCannotSolveError()}
+	} else {
+		start = end
+		end = start + common.SpendBaseLength
+		if bLen < end {return nil, errors.NewWrongInputLength(bLen)}
+		slot.AnonymousBase.spend, err = setSpendBytes(b[start:end])
+		if err != nil {return nil, err}
+		if bLen == end {return slot, nil}
+		slot.AnonymousValue.asset = new(AssetTag)
+		assetLength, err := slot.AnonymousValue.asset.SetBytes(b[end:])
+		if err != nil {return nil, err}
+		if end + assetLength != bLen {return nil, errors.NewWrongInputLength(bLen)}
 	}
 
 	return slot, nil
@@ -147,8 +178,28 @@ func (slot *AnonymousSlot) SetValue(v, r *big.Int) {
 
 func (slot *AnonymousSlot) SetSelfValue(value *AnonymousValue) {slot.AnonymousValue = value}
 
+// SetMemo encrypts memo to the owner of the output slot with the blinding factor r of the value
+func (slot *AnonymousSlot) SetMemo(memo []byte, r *big.Int) error {
+	if slot.mode & common.TxSlotKind != common.OutputSlot {return errors.NewWrongSlotModeError(common.OutputSlot, slot.mode & common.TxSlotKind)}
+	if !slot.AnonymousValue.Solvable() {return errors.NewCannotSolveError()}
+	shared := new(crypto.Commitment).SetIntByGenerator(slot.AnonymousBase.h, r)
+	cipherText, err := sealMemo(shared, slot.AnonymousBase, slot.AnonymousValue, memo)
+	if err != nil {return err}
+	slot.memo = cipherText
+	return nil
+}
 
-type AnonymousBase struct {g, h *crypto.Generator}
+// Memo decrypts the memo of the slot, returns nil if the slot has no memo
+func (slot *AnonymousSlot) Memo(prv *PrivateKey) ([]byte, error) {
+	if slot.memo == nil {return nil, nil}
+	if !slot.AnonymousValue.Solvable() {return nil, errors.NewCannotSolveError()}
+	shared := new(crypto.Commitment).Mul(slot.AnonymousValue.d, prv.Int)
+	return openMemo(shared, slot.AnonymousBase, slot.AnonymousValue, slot.memo)
+}
+
+
+// AnonymousBase is the ElGamal public key h = sk g, spend = x g authorizes the spends, a base without it can not be spent
+type AnonymousBase struct {g, h, spend *crypto.Generator}
 
 func (base *AnonymousBase) BaseMode() uint8 {return common.Anonymous}
 
@@ -177,26 +228,36 @@ func (base *AnonymousBase) SetBytes(b []byte) error {
 	return nil
 }
 
+// PublicKey returns the ElGamal public key of the base
+func (base *AnonymousBase) PublicKey() *elgamal.PublicKey {return elgamal.NewPublicKey(base.g, base.h)}
+
 func (base *AnonymousBase) SetValue(v, r *big.Int, solvable bool) *AnonymousValue {
-	c := new(crypto.Commitment).FixedSet(base.g, base.h, v, r)
+	ct := base.PublicKey().EncryptWith(v, r)
 	if solvable {
-		d := new(crypto.Commitment).SetIntByGenerator(base.g, r)
-		return &AnonymousValue{c,d}
+		return &AnonymousValue{ct.C(), ct.D(), nil}
 	} else {
-		return &AnonymousValue{c,nil}
+		return &AnonymousValue{ct.C(), nil, nil}
 	}
 }
 
+// SetAssetValue commits v against the asset tag, the value is always solvable so the owner can open the tag
+func (base *AnonymousBase) SetAssetValue(v, r *big.Int, tag *AssetTag) *AnonymousValue {
+	ct := base.PublicKey().EncryptBy(tag.t, v, r)
+	return &AnonymousValue{ct.C(), ct.D(), tag}
+}
+
 func (base *AnonymousBase) Proof(v, r *big.Int, value *AnonymousValue) (*AnonymousZK, error) {
 	if !value.Solvable() {return nil, errors.NewCannotSolveError()}
 
 	formatZK := new(zkproofs.FormatZK).Init()
+	gv := value.generator(base.g)
 	formatZK.SetPrivate(v, r, base.g, base.h, value.c, value.d)
+	formatZK.SetValueGenerator(gv)
 	err := formatZK.Proof()
 	if err != nil{return nil, err}
 
 	rangeZK := new(zkproofs.RangeZK).Init()
-	_, err = rangeZK.SetPrivate(value.c, base.g, base.h, zkproofs.RangeG, zkproofs.RangeH, uint8(common.RangeProofShortBits), v, r)
+	_, err = rangeZK.SetPrivate(value.c, gv, base.h, zkproofs.RangeG, zkproofs.RangeH, uint8(common.RangeProofShortBits), v, r)
 	if err != nil {return nil, err}
 	err = rangeZK.Proof()
 	if err != nil {return nil, err}
@@ -207,14 +268,22 @@ func (base *AnonymousBase) Proof(v, r *big.Int, value *AnonymousValue) (*Anonymo
 }
 
 func (base *AnonymousBase) Check(value *AnonymousValue, zk *AnonymousZK) bool {
+	if zk == nil {return false}
+	gv := value.generator(base.g)
 	zk.formatZK.SetPublic(base.g, base.h, value.c, value.d)
-	_, err := zk.rangeZK.SetPublic(value.c, base.g, base.h, zkproofs.RangeG, zkproofs.RangeH, uint8(common.RangeProofShortBits))
+	zk.formatZK.SetValueGenerator(gv)
+	_, err := zk.rangeZK.SetPublic(value.c, gv, base.h, zkproofs.RangeG, zkproofs.RangeH, uint8(common.RangeProofShortBits))
 	if err != nil {return false}
+	if value.asset != nil && !value.asset.check(value.c) {return false}
 
 	return zk.formatZK.Check() && zk.rangeZK.Check()
 }
 
-type AnonymousValue struct {c, d *crypto.Commitment}
+// AnonymousValue commits against the value generator, which is the blinded asset tag if asset is set
+type AnonymousValue struct {
+	c, d *crypto.Commitment
+	asset *AssetTag
+}
 
 func (value *AnonymousValue) ValueMode() uint8 {return common.Anonymous}
 
@@ -222,33 +291,30 @@ func (value *AnonymousValue) Solvable() bool {return value.d != nil}
 
 func (value *AnonymousValue) Solve(prv *PrivateKey) (*big.Int, error) {
 	if !value.Solvable() {return nil, errors.NewCannotSolveError()}
+	if value.asset != nil {return prv.SolveBy(value.asset.t, value.c, value.d)}
 	return prv.Solve(value.c, value.d)
 }
 
-func (value *AnonymousValue) Bytes() []byte {
-	var bytes []byte
-	if value.Solvable() {
-		pointBytes := common.Bn256PointBits / common.ByteBits
-		bytes = make([]byte, common.AnonymousSolvableValueLength)
-		copy(bytes[:pointBytes], value.c.Bytes())
-		copy(bytes[pointBytes:], value.d.Bytes())
-	} else {
-		bytes = make([]byte, common.AnonymousNonSolvableValueLength)
-		copy(bytes, value.c.Bytes())
-	}
-	return bytes
+// Asset returns the asset tag of the value, nil for the native asset committed against G
+func (value *AnonymousValue) Asset() *AssetTag {return value.asset}
+
+// generator returns the value generator, g if the value has no asset tag
+func (value *AnonymousValue) generator(g *crypto.Generator) *crypto.Generator {
+	if value.asset != nil {return value.asset.t}
+	return g
 }
 
+// Ciphertext returns the value as an ElGamal ciphertext
+func (value *AnonymousValue) Ciphertext() *elgamal.Ciphertext {return elgamal.NewCiphertext(value.c, value.d)}
+
+func (value *AnonymousValue) Bytes() []byte {return value.Ciphertext().Bytes()}
+
 func (value *AnonymousValue) SetBytes(b []byte) (*AnonymousValue, error) {
 	bLen := len(b)
 	if bLen != common.AnonymousSolvableValueLength && bLen != common.AnonymousNonSolvableValueLength {return nil, errors.NewWrongInputLength(bLen)}
-	if bLen == common.AnonymousSolvableValueLength {
-		pointBytes := common.Bn256PointBits / common.ByteBits
-		value.c = new(crypto.Commitment).SetBytes(b[:pointBytes])
-		value.d = new(crypto.Commitment).SetBytes(b[pointBytes:])
-	} else {
-		value.c = new(crypto.Commitment).SetBytes(b)
-	}
+	ct, err := new(elgamal.Ciphertext).SetBytes(b)
+	if err != nil {return nil, err}
+	value.c, value.d = ct.C(), ct.D()
 	return value, nil
 }
 
@@ -24,7 +24,7 @@ func TestAnonymous(t *testing.T) {
 	rl, _ := crypto.RandomZq(1)
 	r := rl[0]
 
-	slot0, err := targetBase.NewAnonymousOutputSlot(value, r, true, common.NoneContractSlot, nil)
+	slot0, err := targetBase.NewAnonymousOutputSlot(value, r, true, common.NoneContractSlot, nil, nil)
 	errors.Handle(err)
 
 	if slot0.CheckZKs() {
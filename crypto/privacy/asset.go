@@ -0,0 +1,366 @@
+package privacy
+
+import (
+	"bytes"
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/crypto/elgamal"
+	"github.com/Acoustical/maskash/crypto/zkproofs"
+	"github.com/Acoustical/maskash/errors"
+	"golang.org/x/crypto/bn256"
+	"math/big"
+	"sync"
+)
+
+// AssetID identifies an asset, the zero AssetID is the native asset
+type AssetID [common.AssetIDLength]byte
+
+var NativeAsset AssetID
+
+var assetDomain = crypto.HashBytes("maskash asset")
+var assetBlindingDomain = crypto.HashBytes("maskash asset blinding")
+var assetFactorDomain = crypto.HashBytes("maskash asset blinding factor")
+
+// assetBlinding is the generator j which blinds the asset generators in the tags
+var assetBlinding = crypto.HashToGenerator(assetBlindingDomain)
+
+var assetGenerators sync.Map
+
+// NewAssetID derives the AssetID of an asset name
+func NewAssetID(name string) AssetID {return AssetID(crypto.Hash_(crypto.HashBytes(name)))}
+
+func (id AssetID) Bytes() []byte {return id[:]}
+
+// Generator returns the asset generator of unknown discrete log
+func (id AssetID) Generator() *crypto.Generator {
+	if g, ok := assetGenerators.Load(id); ok {return g.(*crypto.Generator)}
+	g := crypto.HashToGenerator(assetDomain, id)
+	assetGenerators.Store(id, g)
+	return g
+}
+
+// assetFactor derives the blinding factor s of a tag from the shared point rh = sk d
+func assetFactor(shared *crypto.Commitment) *big.Int {
+	s := crypto.Hash_(shared, assetFactorDomain).BigInt()
+	return s.Mod(s, bn256.Order)
+}
+
+// AssetTag is the blinded asset generator t = a + s j of a value with the set of assets it is proven to belong to
+type AssetTag struct {
+	t *crypto.Generator
+	assets []AssetID
+	zk *zkproofs.AssetSetZK
+}
+
+// newAssetTag blinds the generator of asset with the factor derived from shared
+func newAssetTag(shared *crypto.Commitment, asset AssetID, assets []AssetID) (*AssetTag, int, *big.Int, error) {
+	n := len(assets)
+	if n == 0 || n > common.MaxAssetSetSize {return nil, 0, nil, errors.NewWrongInputLength(n)}
+	index := -1
+	for i, id := range assets {
+		if id == asset {index = i}
+	}
+	if index < 0 {return nil, 0, nil, errors.NewAssetNotAllowedError(asset.Bytes())}
+
+	s := assetFactor(shared)
+	t := new(crypto.Generator).Mul(assetBlinding, s)
+	t.G1 = new(bn256.G1).Add(t.G1, asset.Generator().G1)
+	return &AssetTag{t: t, assets: assets}, index, s, nil
+}
+
+// prove proves the tag belongs to its asset set, the proof is bound to the value commitment c
+func (tag *AssetTag) prove(index int, s *big.Int, c *crypto.Commitment) error {
+	tag.zk = new(zkproofs.AssetSetZK).Init()
+	_, err := tag.zk.SetPrivate(index, s, tag.t, assetBlinding, tag.generators(), crypto.Hash_(c).BigInt())
+	if err != nil {return err}
+	return tag.zk.Proof()
+}
+
+func (tag *AssetTag) check(c *crypto.Commitment) bool {
+	if tag.zk == nil {return false}
+	tag.zk.SetPublic(tag.t, assetBlinding, tag.generators(), crypto.Hash_(c).BigInt())
+	return tag.zk.Check()
+}
+
+func (tag *AssetTag) generators() []*crypto.Generator {
+	g := make([]*crypto.Generator, len(tag.assets))
+	for i, id := range tag.assets {
+		g[i] = id.Generator()
+	}
+	return g
+}
+
+// open finds the asset of the tag with the blinding factor derived from shared
+func (tag *AssetTag) open(shared *crypto.Commitment) (AssetID, *big.Int, error) {
+	s := assetFactor(shared)
+	a := new(crypto.Commitment).SetIntByGenerator(assetBlinding, s).Neg().AddGenerator(tag.t)
+	for _, id := range tag.assets {
+		if bytes.Equal(a.Bytes(), id.Generator().Bytes()) {return id, s, nil}
+	}
+	return NativeAsset, nil, errors.NewCannotSolveError()
+}
+
+// AssetTagOf returns the asset tag of a Secret or Anonymous slot, nil if the slot is native
+func AssetTagOf(slot Slot) *AssetTag {
+	switch s := slot.(type) {
+	case *SecretSlot:
+		return s.SecretValue.asset
+	case *AnonymousSlot:
+		return s.AnonymousValue.asset
+	default:
+		return nil
+	}
+}
+
+// Assets returns the asset set the tag is proven to belong to
+func (tag *AssetTag) Assets() []AssetID {return tag.assets}
+
+// CheckAllowed checks every asset of the tag set is allowed
+func (tag *AssetTag) CheckAllowed(allowed []AssetID) error {
+	for _, id := range tag.assets {
+		found := false
+		for _, a := range allowed {
+			if a == id {found = true; break}
+		}
+		if !found {return errors.NewAssetNotAllowedError(id.Bytes())}
+	}
+	return nil
+}
+
+// Bytes returns t | n | n asset IDs | asset set proof
+func (tag *AssetTag) Bytes() []byte {
+	n := len(tag.assets)
+	bytes := make([]byte, 0, common.AssetTagLength + common.AssetSetSizeLength + n * (common.AssetIDLength + common.AssetSetProofUnitLength))
+	bytes = append(bytes, tag.t.Bytes()...)
+	bytes = append(bytes, uint8(n))
+	for _, id := range tag.assets {
+		bytes = append(bytes, id[:]...)
+	}
+	return append(bytes, tag.zk.Bytes()...)
+}
+
+// SetBytes parses the tag from the head of b and returns the parsed length
+func (tag *AssetTag) SetBytes(b []byte) (int, error) {
+	bLen := len(b)
+	headLength := common.AssetTagLength + common.AssetSetSizeLength
+	if bLen < headLength {return 0, errors.NewWrongInputLength(bLen)}
+	n := int(b[common.AssetTagLength])
+	end := headLength + n * (common.AssetIDLength + common.AssetSetProofUnitLength)
+	if n == 0 || bLen < end {return 0, errors.NewWrongInputLength(bLen)}
+
+	t, err := crypto.SetBytes(b[:common.AssetTagLength])
+	if err != nil {return 0, err}
+	tag.t = &crypto.Generator{G1: t}
+
+	tag.assets = make([]AssetID, n)
+	start := headLength
+	for i := range tag.assets {
+		copy(tag.assets[i][:], b[start:start+common.AssetIDLength])
+		start += common.AssetIDLength
+	}
+	tag.zk = new(zkproofs.AssetSetZK).Init()
+	err = tag.zk.SetBytes(b[start:end])
+	if err != nil {return 0, err}
+	return end, nil
+}
+
+// NewSecretAssetOutputSlot commits value of asset against a blinded tag proven to be in assets, audited by auditor unless it is nil
+func (base *SecretBase) NewSecretAssetOutputSlot(value, r *big.Int, asset AssetID, assets []AssetID, contractMode uint8, c ContractSlot, auditor *elgamal.PublicKey) (*SecretSlot, error) {
+	shared := new(crypto.Commitment).SetIntByGenerator(base.h, r)
+	tag, index, s, err := newAssetTag(shared, asset, assets)
+	if err != nil {return nil, err}
+
+	slot := new(SecretSlot).Init()
+	_ = slot.SetMode(common.Secret | common.OutputSlot | common.Solvable | contractMode)
+	slot.SetBase(base)
+	slot.SecretValue = base.SetAssetValue(value, r, tag)
+	err = tag.prove(index, s, slot.SecretValue.c)
+	if err != nil {return nil, err}
+	slot.SecretZK, err = base.Proof(value, r, slot.SecretValue)
+	if err != nil {return nil, err}
+	err = slot.setAudit(auditor, value, r)
+	if err != nil {return nil, err}
+
+	if contractMode != common.NoneContractSlot {
+		if c == nil {return nil, errors.NewNonContractSlotError()}
+		slot.ContractSlot = c
+	}
+	return slot, nil
+}
+
+// NewAnonymousAssetOutputSlot commits value of asset against a blinded tag proven to be in assets, audited by auditor unless it is nil
+func (base *AnonymousBase) NewAnonymousAssetOutputSlot(value, r *big.Int, asset AssetID, assets []AssetID, contractMode uint8, c ContractSlot, auditor *elgamal.PublicKey) (*AnonymousSlot, error) {
+	shared := new(crypto.Commitment).SetIntByGenerator(base.h, r)
+	tag, index, s, err := newAssetTag(shared, asset, assets)
+	if err != nil {return nil, err}
+
+	slot := new(AnonymousSlot).Init()
+	_ = slot.SetMode(common.Anonymous | common.OutputSlot | common.Solvable | contractMode)
+	slot.SetBase(base)
+	slot.AnonymousValue = base.SetAssetValue(value, r, tag)
+	err = tag.prove(index, s, slot.AnonymousValue.c)
+	if err != nil {return nil, err}
+	slot.AnonymousZK, err = base.Proof(value, r, slot.AnonymousValue)
+	if err != nil {return nil, err}
+	err = slot.setAudit(auditor, value, r)
+	if err != nil {return nil, err}
+
+	if contractMode != common.NoneContractSlot {
+		if c == nil {return nil, errors.NewNonContractSlotError()}
+		slot.ContractSlot = c
+	}
+	return slot, nil
+}
+
+// assetParts returns the commitments, the base (g, h) and the tag of an asset tagged slot
+func assetParts(slot Slot) (c, d *crypto.Commitment, g, h *crypto.Generator, tag *AssetTag, err error) {
+	switch s := slot.(type) {
+	case *SecretSlot:
+		c, d, h, tag = s.SecretValue.c, s.SecretValue.d, s.SecretBase.h, s.SecretValue.asset
+		g = new(crypto.Generator).Init(big.NewInt(1))
+	case *AnonymousSlot:
+		c, d, g, h, tag = s.AnonymousValue.c, s.AnonymousValue.d, s.AnonymousBase.g, s.AnonymousBase.h, s.AnonymousValue.asset
+	default:
+		return nil, nil, nil, nil, nil, errors.NewWrongSlotModeError(common.Secret, slot.SlotMode())
+	}
+	if tag == nil || d == nil {return nil, nil, nil, nil, nil, errors.NewCannotSolveError()}
+	return
+}
+
+// OpenAsset returns the asset and the amount of an asset tagged slot owned by prv
+func (prv *PrivateKey) OpenAsset(slot Slot) (AssetID, *big.Int, error) {
+	id, v, _, err := prv.openAsset(slot)
+	return id, v, err
+}
+
+func (prv *PrivateKey) openAsset(slot Slot) (AssetID, *big.Int, *big.Int, error) {
+	c, d, _, _, tag, err := assetParts(slot)
+	if err != nil {return NativeAsset, nil, nil, err}
+	id, s, err := tag.open(new(crypto.Commitment).Mul(d, prv.Int))
+	if err != nil {return NativeAsset, nil, nil, err}
+	v, err := prv.SolveBy(tag.t, c, d)
+	if err != nil {return NativeAsset, nil, nil, err}
+	return id, v, s, nil
+}
+
+// AssetInput is an asset tagged input slot with the key of its owner
+type AssetInput struct {
+	Slot Slot
+	Key *PrivateKey
+}
+
+// AssetOutput is an asset tagged output slot with its amount and blinding factor
+type AssetOutput struct {
+	Slot Slot
+	Value, R *big.Int
+}
+
+// AssetBalance proves the asset tagged inputs and outputs conserve the amount of every asset,
+// sum(c_in) - sum(c_out) has no component on any asset generator, so it is a combination of j, d_in and h_out.
+// The same sk proves h_in = sk g_in and the same r proves d_out = r g_out, so no amount hides in the blinding
+type AssetBalance struct {
+	Inputs, Outputs []Slot
+	zk *zkproofs.LinearSystemZK
+}
+
+func NewAssetBalance(inputs []*AssetInput, outputs []*AssetOutput) (*AssetBalance, error) {
+	P := bn256.Order
+	balance := &AssetBalance{Inputs: make([]Slot, len(inputs)), Outputs: make([]Slot, len(outputs))}
+	x := make([]*big.Int, 1, 1+len(inputs)+len(outputs))
+	x[0] = big.NewInt(0)
+	amounts := make(map[AssetID]*big.Int)
+
+	for i, input := range inputs {
+		id, v, s, err := input.Key.openAsset(input.Slot)
+		if err != nil {return nil, err}
+		balance.Inputs[i] = input.Slot
+		x[0].Add(x[0], new(big.Int).Mul(v, s))
+		x = append(x, input.Key.Int)
+		if amounts[id] == nil {amounts[id] = big.NewInt(0)}
+		amounts[id].Add(amounts[id], v)
+	}
+	for i, output := range outputs {
+		_, _, _, h, tag, err := assetParts(output.Slot)
+		if err != nil {return nil, err}
+		id, s, err := tag.open(new(crypto.Commitment).SetIntByGenerator(h, output.R))
+		if err != nil {return nil, err}
+		balance.Outputs[i] = output.Slot
+		x[0].Sub(x[0], new(big.Int).Mul(output.Value, s))
+		x = append(x, new(big.Int).Neg(output.R))
+		if amounts[id] == nil {amounts[id] = big.NewInt(0)}
+		amounts[id].Sub(amounts[id], output.Value)
+	}
+	for _, amount := range amounts {
+		if amount.Sign() != 0 {return nil, errors.NewUnbalancedError()}
+	}
+	for i := range x {
+		x[i].Mod(x[i], P)
+	}
+
+	y, g, err := balance.statement()
+	if err != nil {return nil, err}
+	balance.zk = new(zkproofs.LinearSystemZK).Init()
+	_, err = balance.zk.SetPrivate(zeros(len(x)), big.NewInt(0), x, y, g, nil)
+	if err != nil {return nil, err}
+	err = balance.zk.Proof()
+	if err != nil {return nil, err}
+	return balance, nil
+}
+
+// statement returns the rows sum(c_in) - sum(c_out) over j, d_in..., h_out..., h_in = sk g_in for every input and
+// -d_out = -r g_out for every output
+func (balance *AssetBalance) statement() ([]*crypto.Commitment, [][]*crypto.Generator, error) {
+	n := 1+len(balance.Inputs)+len(balance.Outputs)
+	sum := new(crypto.Commitment).SetInt(big.NewInt(0))
+	y := []*crypto.Commitment{sum}
+	g := [][]*crypto.Generator{make([]*crypto.Generator, 1, n)}
+	g[0][0] = assetBlinding
+	for i, input := range balance.Inputs {
+		c, d, gi, h, _, err := assetParts(input)
+		if err != nil {return nil, nil, err}
+		sum.AddBy(c)
+		g[0] = append(g[0], &crypto.Generator{G1: d.G1})
+		y = append(y, &crypto.Commitment{G1: h.G1})
+		g = append(g, unitRow(n, 1+i, gi))
+	}
+	for i, output := range balance.Outputs {
+		c, d, gi, h, _, err := assetParts(output)
+		if err != nil {return nil, nil, err}
+		sum.AddBy(new(crypto.Commitment).Mul(c, big.NewInt(1)).Neg())
+		g[0] = append(g[0], h)
+		y = append(y, &crypto.Commitment{G1: new(bn256.G1).Neg(d.G1)})
+		g = append(g, unitRow(n, 1+len(balance.Inputs)+i, gi))
+	}
+	return y, g, nil
+}
+
+// unitRow returns a row of n generators with only g at i
+func unitRow(n, i int, g *crypto.Generator) []*crypto.Generator {
+	row := make([]*crypto.Generator, n)
+	row[i] = g
+	return row
+}
+
+func zeros(n int) []*big.Int {
+	a := make([]*big.Int, n)
+	for i := range a {
+		a[i] = big.NewInt(0)
+	}
+	return a
+}
+
+// Bytes returns the proof, the slots are encoded by the transaction carrying the balance
+func (balance *AssetBalance) Bytes() []byte {
+	if balance.zk == nil {return nil}
+	return balance.zk.Bytes()
+}
+
+// Check verifies the conservation of every asset, the slots themselves are checked by CheckZKs
+func (balance *AssetBalance) Check() bool {
+	if balance.zk == nil {return false}
+	y, g, err := balance.statement()
+	if err != nil {return false}
+	_, err = balance.zk.SetPublic(zeros(len(g[0])), big.NewInt(0), y, g, nil)
+	if err != nil {return false}
+	return balance.zk.Check()
+}
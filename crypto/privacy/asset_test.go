@@ -0,0 +1,100 @@
+package privacy
+
+import (
+	"fmt"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/errors"
+	"math/big"
+	"testing"
+)
+
+func TestAssetSlot(t *testing.T) {
+	gold, silver := NewAssetID("gold"), NewAssetID("silver")
+	assets := []AssetID{NativeAsset, gold, silver}
+	prv := NewRandomPrivateKey()
+	rl, _ := crypto.RandomZq(2)
+
+	slot0, err := prv.GenSecretBase().NewSecretAssetOutputSlot(big.NewInt(1919), rl[0], gold, assets, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+	err = slot0.SetMemo([]byte("gold bar"), rl[0])
+	errors.Handle(err)
+	slot1, err := new(SecretSlot).Init().SetBytes(slot0.Bytes())
+	errors.Handle(err)
+	if !slot1.CheckZKs() {
+		t.Errorf("secret asset slot check failed")
+	}
+	id, v, err := prv.OpenAsset(slot1)
+	errors.Handle(err)
+	fmt.Printf("Secret asset %x amount %d\n\n", id, v)
+	if id != gold || v.Int64() != 1919 {
+		t.Errorf("secret asset slot opened to %x %d", id, v)
+	}
+	if memo, _ := slot1.Memo(prv); memo == nil {
+		t.Errorf("secret asset slot memo lost")
+	}
+
+	input, err := new(SecretSlot).Init().SetBytes(NewSecretInputSlot(slot1).Bytes())
+	errors.Handle(err)
+	if v, err = input.Solve(prv); err != nil || v.Int64() != 1919 {
+		t.Errorf("secret asset input solved to %d", v)
+	}
+
+	slot2, err := prv.GenAnonymousBase().NewAnonymousAssetOutputSlot(big.NewInt(810), rl[1], silver, assets, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+	slot3, err := new(AnonymousSlot).Init().SetBytes(slot2.Bytes())
+	errors.Handle(err)
+	if !slot3.CheckZKs() {
+		t.Errorf("anonymous asset slot check failed")
+	}
+	if v, err = prv.SolveAnonymous(slot3.AnonymousBase, slot3.AnonymousValue); err != nil || v.Int64() != 810 {
+		t.Errorf("anonymous asset slot solved to %d", v)
+	}
+
+	if slot3.Asset().CheckAllowed([]AssetID{NativeAsset, gold}) == nil {
+		t.Errorf("asset set accepted with a disallowed asset")
+	}
+	if _, err = prv.GenSecretBase().NewSecretAssetOutputSlot(big.NewInt(1), rl[0], NewAssetID("copper"), assets, common.NoneContractSlot, nil, nil); err == nil {
+		t.Errorf("tag created for an asset out of the set")
+	}
+
+	slot1.SecretValue.asset, slot3.AnonymousValue.asset = slot3.AnonymousValue.asset, slot1.SecretValue.asset
+	if slot1.CheckZKs() || slot3.CheckZKs() {
+		t.Errorf("swapped asset tags accepted")
+	}
+}
+
+func TestAssetBalance(t *testing.T) {
+	gold, silver := NewAssetID("gold"), NewAssetID("silver")
+	assets := []AssetID{gold, silver}
+	alice, bob := NewRandomPrivateKey(), NewRandomPrivateKey()
+	rl, _ := crypto.RandomZq(6)
+
+	newOutput := func(prv *PrivateKey, v int64, asset AssetID, r *big.Int) *AssetOutput {
+		slot, err := prv.GenSecretBase().NewSecretAssetOutputSlot(big.NewInt(v), r, asset, assets, common.NoneContractSlot, nil, nil)
+		errors.Handle(err)
+		return &AssetOutput{slot, big.NewInt(v), r}
+	}
+	in0, in1 := newOutput(alice, 100, gold, rl[0]), newOutput(alice, 30, silver, rl[1])
+	inputs := []*AssetInput{
+		{NewSecretInputSlot(in0.Slot.(*SecretSlot)), alice},
+		{NewSecretInputSlot(in1.Slot.(*SecretSlot)), alice},
+	}
+
+	outputs := []*AssetOutput{newOutput(bob, 60, gold, rl[2]), newOutput(alice, 40, gold, rl[3]), newOutput(bob, 30, silver, rl[4])}
+	balance, err := NewAssetBalance(inputs, outputs)
+	errors.Handle(err)
+	if !balance.Check() {
+		t.Errorf("asset balance check failed")
+	}
+
+	// 10 silver pretends to be 10 gold
+	forged := []*AssetOutput{newOutput(bob, 70, gold, rl[2]), newOutput(alice, 40, gold, rl[3]), newOutput(bob, 20, silver, rl[4])}
+	if _, err = NewAssetBalance(inputs, forged); err == nil {
+		t.Errorf("unbalanced assets proven")
+	}
+	balance.Outputs = []Slot{forged[0].Slot, forged[1].Slot, forged[2].Slot}
+	if balance.Check() {
+		t.Errorf("asset balance accepted with swapped outputs")
+	}
+}
@@ -0,0 +1,128 @@
+package privacy
+
+import (
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/crypto/elgamal"
+	"github.com/Acoustical/maskash/crypto/zkproofs"
+	"github.com/Acoustical/maskash/errors"
+	"math/big"
+)
+
+// AuditTag encrypts the amount of an output to the auditor with a proof that it equals the amount of the value
+type AuditTag struct {
+	ct *elgamal.Ciphertext
+	zk *zkproofs.AuditZK
+}
+
+// newAuditTag encrypts v to key, (c, d) encrypts v against gv under (g, h) with the blinding factor r
+func newAuditTag(key *elgamal.PublicKey, v, r *big.Int, gv, g, h *crypto.Generator, c, d *crypto.Commitment) (*AuditTag, error) {
+	ct, ra, err := key.Encrypt(v)
+	if err != nil {return nil, err}
+	tag := &AuditTag{ct: ct}
+	tag.zk = new(zkproofs.AuditZK).Init()
+	tag.zk.SetPrivate(v, r, ra, gv, g, h, c, d, key.G(), key.H(), ct.C(), ct.D(), nil)
+	err = tag.zk.Proof()
+	if err != nil {return nil, err}
+	return tag, nil
+}
+
+func (tag *AuditTag) check(key *elgamal.PublicKey, gv, g, h *crypto.Generator, c, d *crypto.Commitment) bool {
+	tag.zk.SetPublic(gv, g, h, c, d, key.G(), key.H(), tag.ct.C(), tag.ct.D(), nil)
+	return tag.zk.Check()
+}
+
+// checkAudit accepts a slot without tag if auditor is nil and a slot with a valid tag otherwise
+func checkAudit(auditor *elgamal.PublicKey, tag *AuditTag, gv, g, h *crypto.Generator, c, d *crypto.Commitment) bool {
+	if auditor == nil {return tag == nil}
+	return tag != nil && d != nil && tag.check(auditor, gv, g, h, c, d)
+}
+
+// auditSlot returns the tag for the output (c, d) if auditor is not nil, a non-solvable value can not be audited
+func auditSlot(auditor *elgamal.PublicKey, v, r *big.Int, gv, g, h *crypto.Generator, c, d *crypto.Commitment) (*AuditTag, error) {
+	if auditor == nil {return nil, nil}
+	if d == nil {return nil, errors.NewUnauditableError()}
+	return newAuditTag(auditor, v, r, gv, g, h, c, d)
+}
+
+// CheckAudit checks the audit tag of an output slot against auditor, the auditor public key of the deployment.
+// Secret and Anonymous outputs must carry a valid tag if auditor is not nil and no tag otherwise
+func CheckAudit(slot Slot, auditor *elgamal.PublicKey) bool {
+	switch s := slot.(type) {
+	case *SecretSlot:
+		return s.checkAudit(auditor)
+	case *AnonymousSlot:
+		return s.checkAudit(auditor)
+	default:
+		return true
+	}
+}
+
+// Ciphertext returns the amount encrypted to the auditor
+func (tag *AuditTag) Ciphertext() *elgamal.Ciphertext {return tag.ct}
+
+func (tag *AuditTag) Bytes() []byte {
+	bytes := make([]byte, common.AuditTagLength)
+	copy(bytes[:common.ElGamalCiphertextLength], tag.ct.Bytes())
+	copy(bytes[common.ElGamalCiphertextLength:], tag.zk.Bytes())
+	return bytes
+}
+
+// SetBytes parses the tag from the head of b, returns the parsed length
+func (tag *AuditTag) SetBytes(b []byte) (int, error) {
+	bLen := len(b)
+	if bLen < common.AuditTagLength {return 0, errors.NewWrongInputLength(bLen)}
+	ct, err := new(elgamal.Ciphertext).SetBytes(b[:common.ElGamalCiphertextLength])
+	if err != nil {return 0, err}
+	if !ct.Solvable() {return 0, errors.NewCannotSolveError()}
+	tag.ct = ct
+	tag.zk = new(zkproofs.AuditZK).Init()
+	err = tag.zk.SetBytes(b[common.ElGamalCiphertextLength:common.AuditTagLength])
+	if err != nil {return 0, err}
+	return common.AuditTagLength, nil
+}
+
+// AuditTagOf returns the audit tag of a Secret or Anonymous output slot, nil if it has none
+func AuditTagOf(slot Slot) *AuditTag {
+	switch s := slot.(type) {
+	case *SecretSlot:
+		return s.audit
+	case *AnonymousSlot:
+		return s.audit
+	default:
+		return nil
+	}
+}
+
+// Audit decrypts the amount of an audited output slot with the auditor private key
+func Audit(key *elgamal.PrivateKey, slot Slot) (*big.Int, error) {
+	tag := AuditTagOf(slot)
+	if tag == nil {return nil, errors.NewCannotSolveError()}
+	return key.Decrypt(tag.ct, common.RangeProofShortBits)
+}
+
+// setAudit attaches the audit tag to auditor of the output slot encrypting v with the blinding factor r
+func (slot *SecretSlot) setAudit(auditor *elgamal.PublicKey, v, r *big.Int) (err error) {
+	g := new(crypto.Generator).Init(big.NewInt(1))
+	value := slot.SecretValue
+	slot.audit, err = auditSlot(auditor, v, r, value.generator(g), g, slot.SecretBase.h, value.c, value.d)
+	return
+}
+
+func (slot *SecretSlot) checkAudit(auditor *elgamal.PublicKey) bool {
+	g := new(crypto.Generator).Init(big.NewInt(1))
+	value := slot.SecretValue
+	return checkAudit(auditor, slot.audit, value.generator(g), g, slot.SecretBase.h, value.c, value.d)
+}
+
+// setAudit attaches the audit tag to auditor of the output slot encrypting v with the blinding factor r
+func (slot *AnonymousSlot) setAudit(auditor *elgamal.PublicKey, v, r *big.Int) (err error) {
+	base, value := slot.AnonymousBase, slot.AnonymousValue
+	slot.audit, err = auditSlot(auditor, v, r, value.generator(base.g), base.g, base.h, value.c, value.d)
+	return
+}
+
+func (slot *AnonymousSlot) checkAudit(auditor *elgamal.PublicKey) bool {
+	base, value := slot.AnonymousBase, slot.AnonymousValue
+	return checkAudit(auditor, slot.audit, value.generator(base.g), base.g, base.h, value.c, value.d)
+}
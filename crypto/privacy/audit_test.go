@@ -0,0 +1,72 @@
+package privacy
+
+import (
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/crypto/elgamal"
+	"github.com/Acoustical/maskash/errors"
+	"math/big"
+	"testing"
+)
+
+func TestAudit(t *testing.T) {
+	key, err := elgamal.KeyGen(new(crypto.Generator).Init(big.NewInt(1)))
+	errors.Handle(err)
+	rl, _ := crypto.RandomZq(3)
+
+	prv := NewRandomPrivateKey()
+	unaudited, err := prv.GenSecretBase().NewSecretOutputSlot(big.NewInt(1), rl[0], true, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+
+	slot0, err := prv.GenSecretBase().NewSecretOutputSlot(big.NewInt(1919), rl[1], true, common.NoneContractSlot, nil, key.PublicKey)
+	errors.Handle(err)
+	slot1, err := new(SecretSlot).Init().SetBytes(slot0.Bytes())
+	errors.Handle(err)
+	if !slot1.CheckZKs() || !CheckAudit(slot1, key.PublicKey) {
+		t.Errorf("audited secret slot check failed")
+	}
+	if v, err := Audit(key, slot1); err != nil || v.Int64() != 1919 {
+		t.Errorf("audited secret slot decrypted to %d", v)
+	}
+
+	slot2, err := prv.GenAnonymousBase().NewAnonymousAssetOutputSlot(big.NewInt(810), rl[2], NativeAsset, []AssetID{NativeAsset}, common.NoneContractSlot, nil, key.PublicKey)
+	errors.Handle(err)
+	slot3, err := new(AnonymousSlot).Init().SetBytes(slot2.Bytes())
+	errors.Handle(err)
+	if !slot3.CheckZKs() || !CheckAudit(slot3, key.PublicKey) {
+		t.Errorf("audited anonymous slot check failed")
+	}
+	if v, err := Audit(key, slot3); err != nil || v.Int64() != 810 {
+		t.Errorf("audited anonymous slot decrypted to %d", v)
+	}
+
+	if CheckAudit(unaudited, key.PublicKey) {
+		t.Errorf("slot without audit tag accepted")
+	}
+	if CheckAudit(slot1, nil) {
+		t.Errorf("audit tag accepted without auditor")
+	}
+	slot1.audit = slot3.audit
+	if CheckAudit(slot1, key.PublicKey) {
+		t.Errorf("slot with a foreign audit tag accepted")
+	}
+
+	_, err = prv.GenSecretBase().NewSecretOutputSlot(big.NewInt(1), rl[0], false, common.NoneContractSlot, nil, key.PublicKey)
+	if _, ok := err.(*errors.UnauditableError); !ok {
+		t.Errorf("non-solvable secret slot created under an auditor")
+	}
+	_, err = prv.GenAnonymousBase().NewAnonymousOutputSlot(big.NewInt(1), rl[0], false, common.NoneContractSlot, nil, key.PublicKey)
+	if _, ok := err.(*errors.UnauditableError); !ok {
+		t.Errorf("non-solvable anonymous slot created under an auditor")
+	}
+
+	pointBytes := common.Bn256PointBits / common.ByteBits
+	tagBytes := slot0.audit.Bytes()
+	for {
+		tagBytes[pointBytes-1]++
+		if point, _ := crypto.SetBytes(tagBytes[:pointBytes]); point == nil {break}
+	}
+	if _, err = new(AuditTag).SetBytes(tagBytes); err == nil {
+		t.Errorf("audit tag decoded from a point off the curve")
+	}
+}
@@ -0,0 +1,132 @@
+package privacy
+
+import (
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/crypto/zkproofs"
+	"github.com/Acoustical/maskash/errors"
+	"golang.org/x/crypto/bn256"
+	"math/big"
+)
+
+// Input is a Secret or Anonymous input slot with the key of its owner
+type Input struct {
+	Slot Slot
+	Key *PrivateKey
+}
+
+// Output is a Secret or Anonymous output slot with its amount and blinding factor
+type Output struct {
+	Slot Slot
+	Value, R *big.Int
+}
+
+// Balance proves the native Secret and Anonymous inputs pay the outputs and the public fee, every input opens as
+// c_in = v_in gv_in + sk d_in with h_in = sk g_in, every output as c_out = v_out gv_out + r h_out with d_out = r g_out,
+// and sum(v_in) - sum(v_out) = fee
+type Balance struct {
+	Inputs, Outputs []Slot
+	Fee *big.Int
+	zk *zkproofs.LinearSystemZK
+}
+
+func NewBalance(inputs []*Input, outputs []*Output, fee *big.Int) (*Balance, error) {
+	P := bn256.Order
+	if fee == nil {fee = big.NewInt(0)}
+	if fee.Sign() < 0 {return nil, errors.NewUnbalancedError()}
+	balance := &Balance{Inputs: make([]Slot, len(inputs)), Outputs: make([]Slot, len(outputs)), Fee: fee}
+	x := make([]*big.Int, 0, 2*(len(inputs)+len(outputs)))
+	sum := new(big.Int).Neg(fee)
+
+	for i, input := range inputs {
+		_, _, gv, c, d, err := balanceParts(input.Slot)
+		if err != nil {return nil, err}
+		v, err := input.Key.SolveBy(gv, c, d)
+		if err != nil {return nil, err}
+		balance.Inputs[i] = input.Slot
+		x = append(x, v, input.Key.Int)
+		sum.Add(sum, v)
+	}
+	for i, output := range outputs {
+		balance.Outputs[i] = output.Slot
+		x = append(x, new(big.Int).Neg(output.Value), new(big.Int).Neg(output.R))
+		sum.Sub(sum, output.Value)
+	}
+	if sum.Sign() != 0 {return nil, errors.NewUnbalancedError()}
+	for i := range x {
+		x[i].Mod(x[i], P)
+	}
+
+	a, y, g, err := balance.statement()
+	if err != nil {return nil, err}
+	balance.zk = new(zkproofs.LinearSystemZK).Init()
+	_, err = balance.zk.SetPrivate(a, fee, x, y, g, nil)
+	if err != nil {return nil, err}
+	err = balance.zk.Proof()
+	if err != nil {return nil, err}
+	return balance, nil
+}
+
+// balanceParts returns the parts of a native solvable Secret or Anonymous slot
+func balanceParts(slot Slot) (g, h, gv *crypto.Generator, c, d *crypto.Commitment, err error) {
+	g, h, gv, c, d, err = decryptionParts(slot.Base(), slot.Value())
+	if err != nil {return nil, nil, nil, nil, nil, err}
+	switch s := slot.(type) {
+	case *SecretSlot:
+		if s.SecretValue.asset != nil {err = errors.NewAssetTaggedError()}
+	case *AnonymousSlot:
+		if s.AnonymousValue.asset != nil {err = errors.NewAssetTaggedError()}
+	}
+	if err == nil && d == nil {err = errors.NewCannotSolveError()}
+	return
+}
+
+// statement returns the constraint a on the amounts and two rows for each slot over the witnesses v_in, sk..., -v_out, -r...:
+// c_in = v_in gv_in + sk d_in and h_in = sk g_in for an input, -c_out = -v_out gv_out - r h_out and -d_out = -r g_out for an output
+func (balance *Balance) statement() ([]*big.Int, []*crypto.Commitment, [][]*crypto.Generator, error) {
+	n := 2*(len(balance.Inputs)+len(balance.Outputs))
+	a := make([]*big.Int, 0, n)
+	y := make([]*crypto.Commitment, 0, n)
+	g := make([][]*crypto.Generator, 0, n)
+	for i, input := range balance.Inputs {
+		gi, h, gv, c, d, err := balanceParts(input)
+		if err != nil {return nil, nil, nil, err}
+		row := make([]*crypto.Generator, n)
+		row[2*i], row[2*i+1] = gv, &crypto.Generator{G1: d.G1}
+		a = append(a, big.NewInt(1), big.NewInt(0))
+		y = append(y, c, &crypto.Commitment{G1: h.G1})
+		g = append(g, row, unitRow(n, 2*i+1, gi))
+	}
+	for i, output := range balance.Outputs {
+		gi, h, gv, c, d, err := balanceParts(output)
+		if err != nil {return nil, nil, nil, err}
+		j := 2*(len(balance.Inputs)+i)
+		row := make([]*crypto.Generator, n)
+		row[j], row[j+1] = gv, h
+		a = append(a, big.NewInt(1), big.NewInt(0))
+		y = append(y, &crypto.Commitment{G1: new(bn256.G1).Neg(c.G1)}, &crypto.Commitment{G1: new(bn256.G1).Neg(d.G1)})
+		g = append(g, row, unitRow(n, j+1, gi))
+	}
+	return a, y, g, nil
+}
+
+// Bytes returns fee (32) | proof, the slots are encoded by the transaction carrying the balance
+func (balance *Balance) Bytes() []byte {
+	zqBytes := common.Bn256ZqBits / common.ByteBits
+	bytes := make([]byte, zqBytes)
+	fee := new(big.Int).Mod(balance.Fee, bn256.Order).Bytes()
+	copy(bytes[zqBytes-len(fee):], fee)
+	if balance.zk != nil {bytes = append(bytes, balance.zk.Bytes()...)}
+	return bytes
+}
+
+// Check verifies the amounts are conserved, the slots themselves are checked by CheckZKs
+func (balance *Balance) Check() bool {
+	if balance.zk == nil || balance.Fee == nil || balance.Fee.Sign() < 0 {return false}
+	a, y, g, err := balance.statement()
+	if err != nil {return false}
+	fee := new(big.Int).Mod(balance.Fee, bn256.Order)
+	_, err = balance.zk.SetPublic(a, fee, y, g, nil)
+	if err != nil {return false}
+	return balance.zk.Check()
+}
@@ -0,0 +1,51 @@
+package privacy
+
+import (
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/errors"
+	"golang.org/x/crypto/bn256"
+	"math/big"
+	"testing"
+)
+
+func TestBalance(t *testing.T) {
+	alice, bob := NewRandomPrivateKey(), NewRandomPrivateKey()
+	rl, _ := crypto.RandomZq(4)
+	in0, err := alice.GenSecretBase().NewSecretOutputSlot(big.NewInt(1000), rl[0], true, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+	in1, err := alice.GenAnonymousBase().NewAnonymousOutputSlot(big.NewInt(500), rl[1], true, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+	out0, err := bob.GenAnonymousBase().NewAnonymousOutputSlot(big.NewInt(1200), rl[2], true, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+	out1, err := alice.GenSecretBase().NewSecretOutputSlot(big.NewInt(290), rl[3], true, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+
+	inputs := []*Input{{NewSecretInputSlot(in0), alice}, {NewAnonymousInputSlot(in1), alice}}
+	outputs := []*Output{{out0, big.NewInt(1200), rl[2]}, {out1, big.NewInt(290), rl[3]}}
+	balance, err := NewBalance(inputs, outputs, big.NewInt(10))
+	errors.Handle(err)
+	if !balance.Check() {
+		t.Errorf("balance check failed")
+	}
+
+	balance.Fee = big.NewInt(11)
+	if balance.Check() {
+		t.Errorf("balance accepted a changed fee")
+	}
+	if _, err = NewBalance(inputs, outputs, big.NewInt(0)); err == nil {
+		t.Errorf("unbalanced transfer proved")
+	}
+
+	// a change of 300 opened as 290 with r' = r + 10 / sk against h = sk G mints 10
+	change, err := alice.GenSecretBase().NewSecretOutputSlot(big.NewInt(300), rl[3], true, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+	forged := new(big.Int).ModInverse(alice.Int, bn256.Order)
+	forged.Mul(forged, big.NewInt(10)).Add(forged, rl[3]).Mod(forged, bn256.Order)
+	outputs = []*Output{{out0, big.NewInt(1200), rl[2]}, {change, big.NewInt(290), forged}}
+	balance, err = NewBalance(inputs, outputs, big.NewInt(10))
+	errors.Handle(err)
+	if balance.Check() {
+		t.Errorf("balance accepted a change output opened to a smaller amount")
+	}
+}
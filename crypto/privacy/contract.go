@@ -1,6 +1,7 @@
 package privacy
 
 import (
+	"encoding/binary"
 	"github.com/Acoustical/maskash/common"
 	"github.com/Acoustical/maskash/crypto"
 	"github.com/Acoustical/maskash/errors"
@@ -13,32 +14,74 @@ type ContractSlot interface {
 	crypto.HashVariable
 }
 
-type ContractCreateSlot struct {binaryCode *big.Int}
+// NewContractCreateSlot carries the bytecode of a contract, leading zero bytes are not kept. A nil salt deploys the
+// contract at the address of the creator nonce, a salt at the address of the creator, the salt and the code
+func NewContractCreateSlot(code []byte, salt *big.Int) (*ContractCreateSlot, error) {
+	if salt != nil {salt = new(big.Int).Mod(salt, saltModulus)}
+	slot := &ContractCreateSlot{new(big.Int).SetBytes(code), salt}
+	err := checkContractLength(slot.Bytes())
+	if err != nil {return nil, err}
+	return slot, nil
+}
 
-func (slot *ContractCreateSlot) ContractSlotMode() uint8 {return common.ContractCreation}
+var saltModulus = new(big.Int).Lsh(big.NewInt(1), uint(common.Bn256ZqBits))
 
-func (slot *ContractCreateSlot) Bytes() []byte {
-	binary := slot.binaryCode.Bytes()
-	bLen := len(binary)
-	bytes := make([]byte, 2 + bLen)
-	bLenUint16 := uint16(bLen)
+type ContractCreateSlot struct {
+	binaryCode *big.Int
+	salt *big.Int
+}
 
-	bytes[0] = uint8(bLenUint16 >> 8)
-	bytes[1] = uint8(bLenUint16 & 0b11111111)
+func (slot *ContractCreateSlot) ContractSlotMode() uint8 {return common.ContractCreation}
 
-	copy(bytes[2:], binary)
-	return bytes
+// Bytes returns 2 bytes length | salt flag | salt | code, the salt is left out when the flag is 0
+func (slot *ContractCreateSlot) Bytes() []byte {
+	zqBytes := common.Bn256ZqBits / common.ByteBits
+	bytes := []byte{0, 0, 0}
+	if slot.salt != nil {
+		bytes[2] = 1
+		salt := make([]byte, zqBytes)
+		saltBytes := slot.salt.Bytes()
+		copy(salt[zqBytes-len(saltBytes):], saltBytes)
+		bytes = append(bytes, salt...)
+	}
+	bytes = append(bytes, slot.binaryCode.Bytes()...)
+	return contractLength(bytes)
 }
 
 func (slot *ContractCreateSlot) SetBytes(b []byte) error {
+	zqBytes := common.Bn256ZqBits / common.ByteBits
 	bLen := len(b)
-	if bLen < 2 {return errors.NewWrongInputLength(bLen)}
-	var length = uint16(b[0]) << 8 + uint16(b[1])
-	if length != uint16(bLen - 2) {return errors.NewWrongInputLength(bLen)}
-	slot.binaryCode = new(big.Int).SetBytes(b[2:])
+	if bLen < 3 || contractBodyLength(b) != bLen - 2 {return errors.NewWrongInputLength(bLen)}
+	start := 3
+	var salt *big.Int
+	switch b[2] {
+	case 0:
+	case 1:
+		if bLen < start + zqBytes {return errors.NewWrongInputLength(bLen)}
+		salt = new(big.Int).SetBytes(b[start:start+zqBytes])
+		start += zqBytes
+	default:
+		return errors.NewWrongInputLength(bLen)
+	}
+	slot.binaryCode, slot.salt = new(big.Int).SetBytes(b[start:]), salt
 	return nil
 }
 
+// Code returns the bytecode of the contract
+func (slot *ContractCreateSlot) Code() []byte {return slot.binaryCode.Bytes()}
+
+// Salt returns the salt of the contract address, nil if the address follows the creator nonce
+func (slot *ContractCreateSlot) Salt() *big.Int {return slot.salt}
+
+// NewContractCallSlot calls the function of a contract with the arguments args
+func NewContractCallSlot(function *big.Int, args ...Value) (*ContractCallSlot, error) {
+	if len(args) > common.MaxContractCount {return nil, errors.NewWrongInputLength(len(args))}
+	slot := &ContractCallSlot{function: &PlaintextValue{nil, function}, values: args}
+	err := checkContractLength(slot.Bytes())
+	if err != nil {return nil, err}
+	return slot, nil
+}
+
 type ContractCallSlot struct {
 	function Value
 	bases []Base
@@ -48,13 +91,215 @@ type ContractCallSlot struct {
 
 func (slot *ContractCallSlot) ContractSlotMode() uint8 {return common.ContractCall}
 
+// Function returns the function selector of the call
+func (slot *ContractCallSlot) Function() *big.Int {
+	v, _ := slot.function.Solve(nil)
+	return v
+}
+
+// Args returns the arguments of the call
+func (slot *ContractCallSlot) Args() []Value {return slot.values}
+
+// AddProof attaches the proof that a value under base is not negative, the proofs cover the confidential
+// arguments first and then the values the call computes, in order. The slot is kept when the proof does not fit
+func (slot *ContractCallSlot) AddProof(base Base, proof *NonNegativeProof) error {
+	if len(slot.zks) >= common.MaxContractCount {return errors.NewWrongInputLength(len(slot.zks) + 1)}
+	err := checkContractLength(append(slot.Bytes(), make([]byte, 1 + len(base.Bytes()) + len(proof.Bytes()))...))
+	if err != nil {return err}
+	slot.bases = append(slot.bases, base)
+	slot.zks = append(slot.zks, proof)
+	return nil
+}
+
+// Proofs returns the attached bases and non negative proofs
+func (slot *ContractCallSlot) Proofs() ([]Base, []*NonNegativeProof) {
+	proofs := make([]*NonNegativeProof, len(slot.zks))
+	for i, zk := range slot.zks {
+		proofs[i] = zk.(*NonNegativeProof)
+	}
+	return slot.bases, proofs
+}
+
+func (slot *ContractCallSlot) Bytes() []byte {
+	bytes := make([]byte, 2 + common.Bn256ZqBits / common.ByteBits)
+	copy(bytes[2:], slot.function.Bytes())
+	bytes = append(bytes, valuesBytes(slot.values)...)
+	bytes = append(bytes, uint8(len(slot.zks)))
+	for i, zk := range slot.zks {
+		bytes = append(bytes, slot.bases[i].BaseMode())
+		bytes = append(bytes, slot.bases[i].Bytes()...)
+		bytes = append(bytes, zk.Bytes()...)
+	}
+	return contractLength(bytes)
+}
+
+func (slot *ContractCallSlot) SetBytes(b []byte) error {
+	zqBytes := common.Bn256ZqBits / common.ByteBits
+	bLen := len(b)
+	if bLen < 2 + zqBytes || contractBodyLength(b) != bLen - 2 {return errors.NewWrongInputLength(bLen)}
+	function, err := new(PlaintextValue).SetBytes(b[2:2+zqBytes])
+	if err != nil {return err}
+	values, n, err := setValuesBytes(b[2+zqBytes:])
+	if err != nil {return err}
+	start := 2 + zqBytes + n
+	if bLen < start + 1 {return errors.NewWrongInputLength(bLen)}
+	bases := make([]Base, int(b[start]))
+	zks := make([]ZKs, len(bases))
+	start++
+	for i := range bases {
+		if bLen < start + 1 {return errors.NewWrongInputLength(bLen)}
+		baseLength := common.SecretBaseLength
+		if b[start] == common.Anonymous {baseLength = common.AnonymousBaseLength}
+		end := start + 1 + baseLength + common.NonNegativeProofLength
+		if bLen < end {return errors.NewWrongInputLength(bLen)}
+		switch b[start] {
+		case common.Secret:
+			base := new(SecretBase)
+			err = base.SetBytes(b[start+1:start+1+baseLength])
+			bases[i] = base
+		case common.Anonymous:
+			base := new(AnonymousBase)
+			err = base.SetBytes(b[start+1:start+1+baseLength])
+			bases[i] = base
+		default:
+			return errors.NewWrongSlotModeError(common.Secret, b[start])
+		}
+		if err != nil {return err}
+		zks[i], err = new(NonNegativeProof).SetBytes(b[start+1+baseLength:end])
+		if err != nil {return err}
+		start = end
+	}
+	if start != bLen {return errors.NewWrongInputLength(bLen)}
+	slot.function, slot.values, slot.bases, slot.zks = function, values, bases, zks
+	return nil
+}
+
+// NewContractReceiptSlot records the result of the call with hash call, the gas it used and the values it emitted
+func NewContractReceiptSlot(call crypto.Hash, status uint8, gasUsed uint64, values []Value) (*ContractReceiptSlot, error) {
+	if len(values) > common.MaxContractCount {return nil, errors.NewWrongInputLength(len(values))}
+	slot := &ContractReceiptSlot{prvHash: &PlaintextValue{nil, call.BigInt()}, status: status, gasUsed: gasUsed, values: values}
+	err := checkContractLength(slot.Bytes())
+	if err != nil {return nil, err}
+	return slot, nil
+}
+
 type ContractReceiptSlot struct {
 	prvHash Value
 	bases []Base
 	values []Value
 	zks []ZKs
+	status uint8
+	gasUsed uint64
 }
 
 func (slot *ContractReceiptSlot) ContractSlotMode() uint8 {return common.ContractReceipt}
 
+// CallHash returns the hash of the call the receipt records
+func (slot *ContractReceiptSlot) CallHash() crypto.Hash {
+	var hash crypto.Hash
+	copy(hash[:], slot.prvHash.Bytes())
+	return hash
+}
+
+// Status returns the status of the call, zero for success
+func (slot *ContractReceiptSlot) Status() uint8 {return slot.status}
+
+func (slot *ContractReceiptSlot) GasUsed() uint64 {return slot.gasUsed}
+
+// Values returns the values emitted by the call
+func (slot *ContractReceiptSlot) Values() []Value {return slot.values}
+
+func (slot *ContractReceiptSlot) Bytes() []byte {
+	zqBytes := common.Bn256ZqBits / common.ByteBits
+	bytes := make([]byte, 2 + zqBytes + 9)
+	copy(bytes[2:], slot.prvHash.Bytes())
+	bytes[2+zqBytes] = slot.status
+	binary.BigEndian.PutUint64(bytes[3+zqBytes:], slot.gasUsed)
+	bytes = append(bytes, valuesBytes(slot.values)...)
+	return contractLength(bytes)
+}
+
+func (slot *ContractReceiptSlot) SetBytes(b []byte) error {
+	zqBytes := common.Bn256ZqBits / common.ByteBits
+	bLen := len(b)
+	if bLen < 2 + zqBytes + 9 || contractBodyLength(b) != bLen - 2 {return errors.NewWrongInputLength(bLen)}
+	prvHash, err := new(PlaintextValue).SetBytes(b[2:2+zqBytes])
+	if err != nil {return err}
+	values, n, err := setValuesBytes(b[2+zqBytes+9:])
+	if err != nil {return err}
+	if 2 + zqBytes + 9 + n != bLen {return errors.NewWrongInputLength(bLen)}
+	slot.prvHash, slot.values = prvHash, values
+	slot.status = b[2+zqBytes]
+	slot.gasUsed = binary.BigEndian.Uint64(b[3+zqBytes:])
+	return nil
+}
+
+// newContractSlot returns an empty contract slot of the contract mode
+func newContractSlot(mode uint8) ContractSlot {
+	switch mode & common.ContractSlotMode {
+	case common.ContractCreation:
+		return new(ContractCreateSlot)
+	case common.ContractCall:
+		return new(ContractCallSlot)
+	case common.ContractReceipt:
+		return new(ContractReceiptSlot)
+	default:
+		return nil
+	}
+}
+
+// contractLength writes the length of the body after the 2 bytes length prefix of bytes
+func contractLength(bytes []byte) []byte {
+	binary.BigEndian.PutUint16(bytes[:2], uint16(len(bytes) - 2))
+	return bytes
+}
+
+func contractBodyLength(b []byte) int {return int(binary.BigEndian.Uint16(b[:2]))}
+
+// checkContractLength returns an error if the body of the encoded contract slot bytes overflows its length prefix
+func checkContractLength(bytes []byte) error {
+	if len(bytes) - common.ContractLengthLength > common.MaxContractBodyLength {return errors.NewWrongInputLength(len(bytes))}
+	return nil
+}
+
+// valuesBytes encodes the count of values and every value as mode, 2 bytes length and bytes
+func valuesBytes(values []Value) []byte {
+	bytes := []byte{uint8(len(values))}
+	for _, value := range values {
+		vBytes := value.Bytes()
+		bytes = append(bytes, value.ValueMode(), uint8(len(vBytes) >> 8), uint8(len(vBytes)))
+		bytes = append(bytes, vBytes...)
+	}
+	return bytes
+}
+
+// setValuesBytes decodes the values at the start of b and returns their length
+func setValuesBytes(b []byte) ([]Value, int, error) {
+	bLen := len(b)
+	if bLen < 1 {return nil, 0, errors.NewWrongInputLength(bLen)}
+	values := make([]Value, int(b[0]))
+	start := 1
+	for i := range values {
+		if bLen < start + 3 {return nil, 0, errors.NewWrongInputLength(bLen)}
+		mode := b[start]
+		end := start + 3 + int(binary.BigEndian.Uint16(b[start+1:start+3]))
+		if bLen < end {return nil, 0, errors.NewWrongInputLength(bLen)}
+		vBytes := b[start+3:end]
+		var err error
+		switch mode {
+		case common.Plaintext:
+			values[i], err = new(PlaintextValue).SetBytes(vBytes)
+		case common.Secret:
+			values[i], err = new(SecretValue).SetBytes(vBytes)
+		case common.Anonymous:
+			values[i], err = new(AnonymousValue).SetBytes(vBytes)
+		default:
+			return nil, 0, errors.NewWrongSlotModeError(common.Secret, mode)
+		}
+		if err != nil {return nil, 0, err}
+		start = end
+	}
+	return values, start, nil
+}
+
 
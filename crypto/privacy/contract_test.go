@@ -0,0 +1,119 @@
+package privacy
+
+import (
+	"bytes"
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/errors"
+	"math/big"
+	"testing"
+)
+
+func TestContractSlots(t *testing.T) {
+	prv := NewRandomPrivateKey()
+	base := prv.GenPlaintextBase()
+	rl, _ := crypto.RandomZq(1)
+	secret := prv.GenSecretBase().SetValue(big.NewInt(1919), rl[0], true)
+
+	code := []byte{0x01, 0x01, 0x2a, 0x50}
+	create, err := NewContractCreateSlot(code, nil)
+	errors.Handle(err)
+	slot0, err := base.NewPlaintextOutputSlot(big.NewInt(0), common.ContractCreation, create)
+	errors.Handle(err)
+	slot1, err := SetSlotBytes(slot0.Bytes())
+	errors.Handle(err)
+	if create, ok := slot1.(*PlaintextSlot).ContractSlot.(*ContractCreateSlot); !ok || !bytes.Equal(create.Code(), code) || create.Salt() != nil {
+		t.Errorf("contract creation slot mismatch")
+	}
+	create, err = NewContractCreateSlot(code, big.NewInt(1919))
+	errors.Handle(err)
+	salted, err := base.NewPlaintextOutputSlot(big.NewInt(0), common.ContractCreation, create)
+	errors.Handle(err)
+	slot1, err = SetSlotBytes(salted.Bytes())
+	errors.Handle(err)
+	if create, ok := slot1.(*PlaintextSlot).ContractSlot.(*ContractCreateSlot); !ok || !bytes.Equal(create.Code(), code) || create.Salt() == nil || create.Salt().Int64() != 1919 {
+		t.Errorf("salted contract creation slot mismatch")
+	}
+
+	call, err := NewContractCallSlot(big.NewInt(7), base.SetValue(nil, big.NewInt(114514)), secret)
+	errors.Handle(err)
+	proof, err := ProveNonNegative(prv, prv.GenSecretBase(), secret)
+	errors.Handle(err)
+	errors.Handle(call.AddProof(prv.GenSecretBase(), proof))
+	slot2, err := base.NewPlaintextOutputSlot(big.NewInt(10), common.ContractCall, call)
+	errors.Handle(err)
+	slot3, err := SetSlotBytes(slot2.Bytes())
+	errors.Handle(err)
+	call1, ok := slot3.(*PlaintextSlot).ContractSlot.(*ContractCallSlot)
+	if !ok || call1.Function().Int64() != 7 || len(call1.Args()) != 2 || !bytes.Equal(call1.Args()[1].Bytes(), secret.Bytes()) || !bytes.Equal(slot3.Bytes(), slot2.Bytes()) {
+		t.Errorf("contract call slot mismatch")
+	}
+	if v, _ := call1.Args()[0].Solve(nil); v.Int64() != 114514 {
+		t.Errorf("contract call argument %d, want 114514", v)
+	}
+	if bases, proofs := call1.Proofs(); len(proofs) != 1 || !VerifyNonNegative(bases[0], secret, proofs[0]) {
+		t.Errorf("contract call proof mismatch")
+	}
+
+	hash := crypto.Hash_(slot2)
+	receipt, err := NewContractReceiptSlot(hash, 1, 250, []Value{secret})
+	errors.Handle(err)
+	slot4, err := base.NewPlaintextOutputSlot(big.NewInt(0), common.ContractReceipt, receipt)
+	errors.Handle(err)
+	slot5, err := SetSlotBytes(slot4.Bytes())
+	errors.Handle(err)
+	receipt1, ok := slot5.(*PlaintextSlot).ContractSlot.(*ContractReceiptSlot)
+	if !ok || receipt1.CallHash() != hash || receipt1.Status() != 1 || receipt1.GasUsed() != 250 || len(receipt1.Values()) != 1 {
+		t.Errorf("contract receipt slot mismatch")
+	}
+
+	b := slot2.Bytes()
+	if _, err = SetSlotBytes(b[:len(b)-1]); err == nil {
+		t.Errorf("truncated contract call slot accepted")
+	}
+}
+
+func TestContractSlotLimits(t *testing.T) {
+	prv := NewRandomPrivateKey()
+	base := prv.GenPlaintextBase()
+	rl, _ := crypto.RandomZq(1)
+	secret := prv.GenSecretBase().SetValue(big.NewInt(1919), rl[0], true)
+
+	// the body of a creation is the salt flag and the code
+	code := bytes.Repeat([]byte{0x01}, common.MaxContractBodyLength - 1)
+	create, err := NewContractCreateSlot(code, nil)
+	errors.Handle(err)
+	slot, err := base.NewPlaintextOutputSlot(big.NewInt(0), common.ContractCreation, create)
+	errors.Handle(err)
+	if slot1, err := SetSlotBytes(slot.Bytes()); err != nil || !bytes.Equal(slot1.(*PlaintextSlot).ContractSlot.(*ContractCreateSlot).Code(), code) {
+		t.Errorf("contract creation of the maximal length mismatch")
+	}
+	if _, err = NewContractCreateSlot(append(code, 0x01), nil); err == nil {
+		t.Errorf("contract creation over the maximal length accepted")
+	}
+	if _, err = NewContractCreateSlot(code, big.NewInt(1)); err == nil {
+		t.Errorf("salted contract creation over the maximal length accepted")
+	}
+	if _, err = NewContractCallSlot(big.NewInt(1), make([]Value, common.MaxContractCount + 1)...); err == nil {
+		t.Errorf("contract call over the maximal argument count accepted")
+	}
+
+	// proofs are attached until the body is full, a refused proof leaves the slot unchanged
+	call, err := NewContractCallSlot(big.NewInt(7), secret)
+	errors.Handle(err)
+	proof, err := ProveNonNegative(prv, prv.GenSecretBase(), secret)
+	errors.Handle(err)
+	n := 0
+	for call.AddProof(prv.GenSecretBase(), proof) == nil {
+		n++
+	}
+	b := call.Bytes()
+	if n == 0 || len(b) - 2 > common.MaxContractBodyLength || len(b) - 2 + 1 + common.SecretBaseLength + common.NonNegativeProofLength <= common.MaxContractBodyLength {
+		t.Errorf("contract call took %d proofs with %d bytes", n, len(b))
+	}
+	call1 := new(ContractCallSlot)
+	errors.Handle(call1.SetBytes(b))
+	if _, proofs := call1.Proofs(); len(proofs) != n || !bytes.Equal(call1.Bytes(), b) {
+		t.Errorf("full contract call slot mismatch")
+	}
+}
@@ -0,0 +1,81 @@
+package privacy
+
+import (
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/crypto/elgamal"
+	"github.com/Acoustical/maskash/crypto/zkproofs"
+	"github.com/Acoustical/maskash/errors"
+	"math/big"
+)
+
+// Conversion carries the value of a SecretSlot input to an AnonymousSlot output
+type Conversion struct {
+	Input *SecretSlot
+	Output *AnonymousSlot
+	zk *zkproofs.ConversionZK
+}
+
+// NewConversion spends the solvable SecretSlot output owned by prv to a solvable AnonymousSlot of base audited by auditor
+func (prv *PrivateKey) NewConversion(outputSlot *SecretSlot, base *AnonymousBase, auditor *elgamal.PublicKey) (*Conversion, error) {
+	value, err := outputSlot.Solve(prv)
+	if err != nil {return nil, err}
+	rl, err := crypto.RandomZq(1)
+	if err != nil {return nil, err}
+	r := rl[0]
+
+	conversion := new(Conversion)
+	conversion.Input = NewSecretInputSlot(outputSlot)
+	conversion.Output, err = base.NewAnonymousOutputSlot(value, r, true, common.NoneContractSlot, nil, auditor)
+	if err != nil {return nil, err}
+
+	// c1 = vG + sk d1, h1 = sk G and c2 = v g2 + r h2
+	conversion.zk = new(zkproofs.ConversionZK).Init()
+	g1, h1, d1, c1, g2, h2, c2 := conversion.statement()
+	conversion.zk.SetPrivate(value, prv.Int, r, g1, h1, d1, c1, g2, h2, c2, conversion.context())
+	err = conversion.zk.Proof()
+	if err != nil {return nil, err}
+	return conversion, nil
+}
+
+func (conversion *Conversion) statement() (g1, h1, d1 *crypto.Generator, c1 *crypto.Commitment, g2, h2 *crypto.Generator, c2 *crypto.Commitment) {
+	input, output := conversion.Input, conversion.Output
+	g1 = new(crypto.Generator).Init(big.NewInt(1))
+	d1 = &crypto.Generator{G1: input.SecretValue.d.G1}
+	return g1, input.SecretBase.h, d1, input.SecretValue.c, output.AnonymousBase.g, output.AnonymousBase.h, output.AnonymousValue.c
+}
+
+// context binds the proof to both slots of the conversion
+func (conversion *Conversion) context() *big.Int {return crypto.Hash_(conversion.Input, conversion.Output).BigInt()}
+
+// Check verifies the AnonymousSlot and that both slots commit to the same value
+func (conversion *Conversion) Check() bool {
+	input, output := conversion.Input, conversion.Output
+	if input.SlotMode() & (common.PrivacyMode | common.TxSlotKind | common.Solvability) != common.Secret | common.InputSlot | common.Solvable {return false}
+	if output.SlotMode() & (common.PrivacyMode | common.TxSlotKind) != common.Anonymous | common.OutputSlot {return false}
+	if !output.CheckZKs() {return false}
+
+	g1, h1, d1, c1, g2, h2, c2 := conversion.statement()
+	conversion.zk.SetPublic(g1, h1, d1, c1, g2, h2, c2, conversion.context())
+	return conversion.zk.Check()
+}
+
+func (conversion *Conversion) Bytes() []byte {
+	return concatBytes(conversion.Input.Bytes(), conversion.Output.Bytes(), conversion.zk.Bytes())
+}
+
+func (conversion *Conversion) SetBytes(b []byte) (*Conversion, error) {
+	bLen := len(b)
+	if bLen < common.SecretInputSolvableSlotLength + common.ConversionProofLength {return nil, errors.NewWrongInputLength(bLen)}
+	zkStart := bLen - common.ConversionProofLength
+
+	var err error
+	conversion.Input, err = new(SecretSlot).Init().SetBytes(b[:common.SecretInputSolvableSlotLength])
+	if err != nil {return nil, err}
+	conversion.Output, err = new(AnonymousSlot).Init().SetBytes(b[common.SecretInputSolvableSlotLength:zkStart])
+	if err != nil {return nil, err}
+	conversion.zk = new(zkproofs.ConversionZK).Init()
+	err = conversion.zk.SetBytes(b[zkStart:])
+	if err != nil {return nil, err}
+	return conversion, nil
+}
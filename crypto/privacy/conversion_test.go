@@ -0,0 +1,44 @@
+package privacy
+
+import (
+	"fmt"
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/errors"
+	"math/big"
+	"testing"
+)
+
+func TestConversion(t *testing.T) {
+	prv := NewRandomPrivateKey()
+	receiver := NewRandomPrivateKey()
+	value := big.NewInt(114514)
+
+	rl, _ := crypto.RandomZq(1)
+	secretSlot, err := prv.GenSecretBase().NewSecretOutputSlot(value, rl[0], true, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+
+	conversion, err := prv.NewConversion(secretSlot, receiver.GenAnonymousBase(), nil)
+	errors.Handle(err)
+	conversionBytes := conversion.Bytes()
+	fmt.Printf("Conversion\n%x\n\n", conversionBytes)
+
+	conversion0, err := new(Conversion).SetBytes(conversionBytes)
+	errors.Handle(err)
+	if !conversion0.Check() {
+		t.Errorf("Conversion check failed")
+	}
+
+	v, err := receiver.SolveAnonymous(conversion0.Output.AnonymousBase, conversion0.Output.AnonymousValue)
+	errors.Handle(err)
+	if v.Cmp(value) != 0 {
+		t.Errorf("Conversion carried a wrong value")
+	}
+
+	other, err := receiver.GenAnonymousBase().NewAnonymousOutputSlot(big.NewInt(114515), rl[0], true, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+	conversion0.Output = other
+	if conversion0.Check() {
+		t.Errorf("Conversion accepted with another output")
+	}
+}
@@ -0,0 +1,92 @@
+package privacy
+
+import (
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/crypto/zkproofs"
+	"github.com/Acoustical/maskash/errors"
+	"math/big"
+)
+
+// DecryptionProof reveals the amount v of a solvable value with a proof that c - v gv = sk d for the sk of h = sk g,
+// so an auditor learns the amount of one slot without the private key
+type DecryptionProof struct {
+	Amount *big.Int
+	zk *zkproofs.EqualityZK
+}
+
+// ProveDecryption decrypts the SecretValue or AnonymousValue of base owned by prv and proves the decryption
+func ProveDecryption(prv *PrivateKey, base Base, value Value) (*DecryptionProof, error) {
+	g, h, gv, c, d, err := decryptionParts(base, value)
+	if err != nil {return nil, err}
+	if !new(crypto.Commitment).SetIntByGenerator(g, prv.Int).Cmp(&crypto.Commitment{G1: h.G1}) {return nil, errors.NewCannotSolveError()}
+	v, err := prv.SolveBy(gv, c, d)
+	if err != nil {return nil, err}
+
+	proof := &DecryptionProof{Amount: v}
+	g1, y1, g2, y2 := decryptionStatement(g, h, gv, c, d, v)
+	proof.zk = new(zkproofs.EqualityZK).Init()
+	proof.zk.SetPrivate(prv.Int, g1, y1, g2, y2, decryptionContext(base, value, v))
+	err = proof.zk.Proof()
+	if err != nil {return nil, err}
+	return proof, nil
+}
+
+// VerifyDecryption checks the revealed amount is the decryption of value under base with public data only
+func VerifyDecryption(base Base, value Value, proof *DecryptionProof) bool {
+	if proof == nil || proof.zk == nil || proof.Amount == nil || proof.Amount.Sign() < 0 {return false}
+	g, h, gv, c, d, err := decryptionParts(base, value)
+	if err != nil {return false}
+	g1, y1, g2, y2 := decryptionStatement(g, h, gv, c, d, proof.Amount)
+	proof.zk.SetPublic(g1, y1, g2, y2, decryptionContext(base, value, proof.Amount))
+	return proof.zk.Check()
+}
+
+// decryptionParts returns the base (g, h), the value generator gv and the ciphertext (c, d)
+func decryptionParts(base Base, value Value) (g, h, gv *crypto.Generator, c, d *crypto.Commitment, err error) {
+	switch b := base.(type) {
+	case *SecretBase:
+		v, ok := value.(*SecretValue)
+		if !ok {return nil, nil, nil, nil, nil, errors.NewWrongSlotModeError(common.Secret, value.ValueMode())}
+		g = new(crypto.Generator).Init(big.NewInt(1))
+		h, gv, c, d = b.h, v.generator(g), v.c, v.d
+	case *AnonymousBase:
+		v, ok := value.(*AnonymousValue)
+		if !ok {return nil, nil, nil, nil, nil, errors.NewWrongSlotModeError(common.Anonymous, value.ValueMode())}
+		g, h, gv, c, d = b.g, b.h, v.generator(b.g), v.c, v.d
+	default:
+		return nil, nil, nil, nil, nil, errors.NewWrongSlotModeError(common.Secret, base.BaseMode())
+	}
+	if d == nil {return nil, nil, nil, nil, nil, errors.NewCannotSolveError()}
+	return
+}
+
+// decryptionStatement returns h = sk g and c - v gv = sk d
+func decryptionStatement(g, h, gv *crypto.Generator, c, d *crypto.Commitment, v *big.Int) (*crypto.Generator, *crypto.Commitment, *crypto.Generator, *crypto.Commitment) {
+	y2 := new(crypto.Commitment).SetIntByGenerator(gv, v).Neg().AddBy(c)
+	return g, &crypto.Commitment{G1: h.G1}, &crypto.Generator{G1: d.G1}, y2
+}
+
+func decryptionContext(base Base, value Value, v *big.Int) *big.Int {
+	return crypto.Hash_(base, value, v).BigInt()
+}
+
+func (proof *DecryptionProof) Bytes() []byte {
+	zqBytes := common.Bn256ZqBits / common.ByteBits
+	bytes := make([]byte, common.DecryptionProofLength)
+	vBytes := proof.Amount.Bytes()
+	copy(bytes[zqBytes-len(vBytes):zqBytes], vBytes)
+	copy(bytes[zqBytes:], proof.zk.Bytes())
+	return bytes
+}
+
+func (proof *DecryptionProof) SetBytes(b []byte) (*DecryptionProof, error) {
+	bLen := len(b)
+	if bLen != common.DecryptionProofLength {return nil, errors.NewWrongInputLength(bLen)}
+	zqBytes := common.Bn256ZqBits / common.ByteBits
+	proof.Amount = new(big.Int).SetBytes(b[:zqBytes])
+	proof.zk = new(zkproofs.EqualityZK).Init()
+	err := proof.zk.SetBytes(b[zqBytes:])
+	if err != nil {return nil, err}
+	return proof, nil
+}
@@ -0,0 +1,52 @@
+package privacy
+
+import (
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/errors"
+	"math/big"
+	"testing"
+)
+
+func TestSecretDecryptionProof(t *testing.T) {
+	prv := NewRandomPrivateKey()
+	base := prv.GenSecretBase()
+	rl, _ := crypto.RandomZq(1)
+	slot, err := base.NewSecretOutputSlot(big.NewInt(1919), rl[0], true, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+
+	proof, err := ProveDecryption(prv, base, slot.SecretValue)
+	errors.Handle(err)
+	proof1, err := new(DecryptionProof).SetBytes(proof.Bytes())
+	errors.Handle(err)
+	if proof1.Amount.Int64() != 1919 || !VerifyDecryption(base, slot.SecretValue, proof1) {
+		t.Errorf("secret decryption proof check failed")
+	}
+
+	proof1.Amount = big.NewInt(1920)
+	if VerifyDecryption(base, slot.SecretValue, proof1) {
+		t.Errorf("secret decryption proof accepted a wrong amount")
+	}
+	if _, err := ProveDecryption(NewRandomPrivateKey(), base, slot.SecretValue); err == nil {
+		t.Errorf("secret decryption proved by a foreign key")
+	}
+}
+
+func TestAnonymousDecryptionProof(t *testing.T) {
+	prv := NewRandomPrivateKey()
+	base := prv.GenAnonymousBase()
+	rl, _ := crypto.RandomZq(1)
+	slot, err := base.NewAnonymousOutputSlot(big.NewInt(810), rl[0], true, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+
+	proof, err := ProveDecryption(prv, base, slot.AnonymousValue)
+	errors.Handle(err)
+	proof1, err := new(DecryptionProof).SetBytes(proof.Bytes())
+	errors.Handle(err)
+	if proof1.Amount.Int64() != 810 || !VerifyDecryption(base, slot.AnonymousValue, proof1) {
+		t.Errorf("anonymous decryption proof check failed")
+	}
+	if VerifyDecryption(prv.GenAnonymousBase(), slot.AnonymousValue, proof1) {
+		t.Errorf("anonymous decryption proof accepted under another base")
+	}
+}
@@ -0,0 +1,71 @@
+package privacy
+
+import (
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/errors"
+)
+
+// The extension flag of an output slot marks the optional sections following the ZKs
+const ExtensionMemo uint8 = 0b00000001
+const ExtensionAsset uint8 = 0b00000010
+const ExtensionAudit uint8 = 0b00000100
+const ExtensionSpend uint8 = 0b00001000
+
+// extensionBytes returns the extension flag followed by the memo, the asset tag, the audit tag and the spend base
+func extensionBytes(memo []byte, asset *AssetTag, audit *AuditTag, spend *crypto.Generator) []byte {
+	var flag uint8
+	if len(memo) > 0 {flag |= ExtensionMemo}
+	if asset != nil {flag |= ExtensionAsset}
+	if audit != nil {flag |= ExtensionAudit}
+	if spend != nil {flag |= ExtensionSpend}
+	bytes := append([]byte{flag}, memo...)
+	if asset != nil {bytes = append(bytes, asset.Bytes()...)}
+	if audit != nil {bytes = append(bytes, audit.Bytes()...)}
+	if spend != nil {bytes = append(bytes, spend.Bytes()...)}
+	return bytes
+}
+
+// setExtensionBytes parses the extension flag, the memo of memoLength, the asset tag, the audit tag and the spend base
+// from b, returns them with the parsed length
+func setExtensionBytes(b []byte, memoLength int) ([]byte, *AssetTag, *AuditTag, *crypto.Generator, int, error) {
+	bLen := len(b)
+	if bLen < common.ExtensionFlagLength {return nil, nil, nil, nil, 0, errors.NewWrongInputLength(bLen)}
+	flag := b[0]
+	if flag & ^(ExtensionMemo | ExtensionAsset | ExtensionAudit | ExtensionSpend) != 0 {return nil, nil, nil, nil, 0, errors.NewWrongInputLength(bLen)}
+	end := common.ExtensionFlagLength
+
+	var memo []byte
+	if flag & ExtensionMemo != 0 {
+		if bLen < end + memoLength {return nil, nil, nil, nil, 0, errors.NewWrongInputLength(bLen)}
+		memo = make([]byte, memoLength)
+		copy(memo, b[end:end+memoLength])
+		end += memoLength
+	}
+
+	var asset *AssetTag
+	if flag & ExtensionAsset != 0 {
+		asset = new(AssetTag)
+		assetLength, err := asset.SetBytes(b[end:])
+		if err != nil {return nil, nil, nil, nil, 0, err}
+		end += assetLength
+	}
+
+	var audit *AuditTag
+	if flag & ExtensionAudit != 0 {
+		audit = new(AuditTag)
+		auditLength, err := audit.SetBytes(b[end:])
+		if err != nil {return nil, nil, nil, nil, 0, err}
+		end += auditLength
+	}
+
+	var spend *crypto.Generator
+	if flag & ExtensionSpend != 0 {
+		if bLen < end + common.SpendBaseLength {return nil, nil, nil, nil, 0, errors.NewWrongInputLength(bLen)}
+		var err error
+		spend, err = setSpendBytes(b[end:end+common.SpendBaseLength])
+		if err != nil {return nil, nil, nil, nil, 0, err}
+		end += common.SpendBaseLength
+	}
+	return memo, asset, audit, spend, end, nil
+}
@@ -0,0 +1,204 @@
+package privacy
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/errors"
+	"golang.org/x/crypto/bn256"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var masterKeyDomain = []byte("maskash seed")
+
+// ExtendedPrivateKey is a BIP32 style hierarchical deterministic PrivateKey over bn256
+type ExtendedPrivateKey struct {
+	*PrivateKey
+	chainCode [common.ChainCodeLength]byte
+	depth uint8
+	index uint32
+}
+
+// NewMasterKey derives the master ExtendedPrivateKey from a seed
+func NewMasterKey(seed []byte) (*ExtendedPrivateKey, error) {
+	k, chainCode := hdHash(masterKeyDomain, seed)
+	if k.Sign() == 0 {return nil, errors.NewInvalidChildKeyError(0)}
+	return &ExtendedPrivateKey{&PrivateKey{k}, chainCode, 0, 0}, nil
+}
+
+// Child derives the child key of index, index not less than HardenedKeyStart derives a hardened child
+func (key *ExtendedPrivateKey) Child(index uint32) (*ExtendedPrivateKey, error) {
+	var data []byte
+	if index >= common.HardenedKeyStart {
+		zqBytes := common.Bn256ZqBits / common.ByteBits
+		data = make([]byte, 1+zqBytes+4)
+		kBytes := key.Int.Bytes()
+		copy(data[1+zqBytes-len(kBytes):1+zqBytes], kBytes)
+	} else {
+		data = append(key.GenSecretBase().Bytes(), make([]byte, 4)...)
+	}
+	binary.BigEndian.PutUint32(data[len(data)-4:], index)
+
+	il, chainCode := hdHash(key.chainCode[:], data)
+	k := il.Add(il, key.Int)
+	k.Mod(k, bn256.Order)
+	if k.Sign() == 0 {return nil, errors.NewInvalidChildKeyError(index)}
+
+	return &ExtendedPrivateKey{&PrivateKey{k}, chainCode, key.depth + 1, index}, nil
+}
+
+// Derive derives the key of path such as "m/44'/0'/1", the path is relative if it does not start with "m" and only
+// the master key takes a path from "m"
+func (key *ExtendedPrivateKey) Derive(path string) (*ExtendedPrivateKey, error) {
+	if key.depth != 0 && masterPath(path) {return nil, errors.NewInvalidDerivationPathError(path)}
+	indexes, err := ParseDerivationPath(path)
+	if err != nil {return nil, err}
+	child := key
+	for _, index := range indexes {
+		child, err = child.Child(index)
+		if err != nil {return nil, err}
+	}
+	return child, nil
+}
+
+// ExtendedBase returns the extended base which derives the non-hardened children without the private key
+func (key *ExtendedPrivateKey) ExtendedBase() *ExtendedBase {
+	return &ExtendedBase{key.GenSecretBase(), key.chainCode, key.depth, key.index}
+}
+
+// AccountKey uses the key as the spend key of an AccountKey
+func (key *ExtendedPrivateKey) AccountKey() *AccountKey {return NewAccountKey(key.PrivateKey)}
+
+func (key *ExtendedPrivateKey) Depth() uint8 {return key.depth}
+
+func (key *ExtendedPrivateKey) Index() uint32 {return key.index}
+
+func (key *ExtendedPrivateKey) Bytes() []byte {
+	zqBytes := common.Bn256ZqBits / common.ByteBits
+	bytes := make([]byte, common.ExtendedPrivateKeyLength)
+	start := putExtendedHeader(bytes, key.depth, key.index, key.chainCode)
+	kBytes := key.Int.Bytes()
+	copy(bytes[start+zqBytes-len(kBytes):], kBytes)
+	return bytes
+}
+
+func (key *ExtendedPrivateKey) SetBytes(b []byte) (*ExtendedPrivateKey, error) {
+	bLen := len(b)
+	if bLen != common.ExtendedPrivateKeyLength {return nil, errors.NewWrongInputLength(bLen)}
+	start := getExtendedHeader(b, &key.depth, &key.index, &key.chainCode)
+	key.PrivateKey = &PrivateKey{new(big.Int).SetBytes(b[start:])}
+	return key, nil
+}
+
+// ExtendedBase is the public part of an ExtendedPrivateKey
+type ExtendedBase struct {
+	*SecretBase
+	chainCode [common.ChainCodeLength]byte
+	depth uint8
+	index uint32
+}
+
+// Child derives the non-hardened child base of index
+func (base *ExtendedBase) Child(index uint32) (*ExtendedBase, error) {
+	if index >= common.HardenedKeyStart {return nil, errors.NewHardenedDerivationError(index)}
+	data := append(base.SecretBase.Bytes(), make([]byte, 4)...)
+	binary.BigEndian.PutUint32(data[len(data)-4:], index)
+
+	il, chainCode := hdHash(base.chainCode[:], data)
+	h := new(crypto.Generator).Init(il)
+	h.G1 = new(bn256.G1).Add(h.G1, base.h.G1)
+
+	return &ExtendedBase{&SecretBase{h, h}, chainCode, base.depth + 1, index}, nil
+}
+
+// Derive derives the base of a relative non-hardened path such as "0/1", a path from "m" is taken at depth 0 only
+func (base *ExtendedBase) Derive(path string) (*ExtendedBase, error) {
+	if base.depth != 0 && masterPath(path) {return nil, errors.NewInvalidDerivationPathError(path)}
+	indexes, err := ParseDerivationPath(path)
+	if err != nil {return nil, err}
+	child := base
+	for _, index := range indexes {
+		child, err = child.Child(index)
+		if err != nil {return nil, err}
+	}
+	return child, nil
+}
+
+func (base *ExtendedBase) Depth() uint8 {return base.depth}
+
+func (base *ExtendedBase) Index() uint32 {return base.index}
+
+func (base *ExtendedBase) Bytes() []byte {
+	bytes := make([]byte, common.ExtendedBaseLength)
+	start := putExtendedHeader(bytes, base.depth, base.index, base.chainCode)
+	copy(bytes[start:], base.SecretBase.Bytes())
+	return bytes
+}
+
+func (base *ExtendedBase) SetBytes(b []byte) (*ExtendedBase, error) {
+	bLen := len(b)
+	if bLen != common.ExtendedBaseLength {return nil, errors.NewWrongInputLength(bLen)}
+	start := getExtendedHeader(b, &base.depth, &base.index, &base.chainCode)
+	base.SecretBase = new(SecretBase)
+	err := base.SecretBase.SetBytes(b[start:])
+	if err != nil {return nil, err}
+	return base, nil
+}
+
+// ParseDerivationPath parses a path such as "m/44'/0'/1", hardened indexes are marked by ' or h and every segment
+// after "m" is an index
+func ParseDerivationPath(path string) ([]uint32, error) {
+	parts := strings.Split(path, "/")
+	if masterPath(path) {parts = parts[1:]}
+	indexes := make([]uint32, 0, len(parts))
+	for _, part := range parts {
+		if part == "" {return nil, errors.NewInvalidDerivationPathError(path)}
+		var offset uint32
+		if strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h") {
+			offset = common.HardenedKeyStart
+			part = part[:len(part)-1]
+		}
+		index, err := strconv.ParseUint(part, 10, 32)
+		if err != nil || uint32(index) >= common.HardenedKeyStart {return nil, errors.NewInvalidDerivationPathError(path)}
+		indexes = append(indexes, uint32(index)+offset)
+	}
+	return indexes, nil
+}
+
+// masterPath returns whether path starts from the master key "m"
+func masterPath(path string) bool {return path == "m" || strings.HasPrefix(path, "m/")}
+
+// hdHash returns a scalar and a chain code, the bn256 order is much less than 2^256
+// so the scalar is reduced from a whole HMAC-SHA512 instead of rejecting the left half
+func hdHash(key, data []byte) (*big.Int, [common.ChainCodeLength]byte) {
+	mac := hmac.New(sha512.New, key)
+	mac.Write([]byte{0})
+	mac.Write(data)
+	k := new(big.Int).SetBytes(mac.Sum(nil))
+	k.Mod(k, bn256.Order)
+
+	mac.Reset()
+	mac.Write([]byte{1})
+	mac.Write(data)
+	var chainCode [common.ChainCodeLength]byte
+	copy(chainCode[:], mac.Sum(nil))
+	return k, chainCode
+}
+
+func putExtendedHeader(b []byte, depth uint8, index uint32, chainCode [common.ChainCodeLength]byte) int {
+	b[0] = depth
+	binary.BigEndian.PutUint32(b[1:5], index)
+	copy(b[5:5+common.ChainCodeLength], chainCode[:])
+	return 5 + common.ChainCodeLength
+}
+
+func getExtendedHeader(b []byte, depth *uint8, index *uint32, chainCode *[common.ChainCodeLength]byte) int {
+	*depth = b[0]
+	*index = binary.BigEndian.Uint32(b[1:5])
+	copy(chainCode[:], b[5:5+common.ChainCodeLength])
+	return 5 + common.ChainCodeLength
+}
@@ -0,0 +1,64 @@
+package privacy
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/Acoustical/maskash/errors"
+	"testing"
+)
+
+func TestHDKey(t *testing.T) {
+	seed := []byte("maskash hierarchical deterministic seed")
+	master, err := NewMasterKey(seed)
+	errors.Handle(err)
+	fmt.Printf("Master Key\n%x\n\n", master.Bytes())
+
+	account, err := master.Derive("m/44'/0'")
+	errors.Handle(err)
+	accountBase := account.ExtendedBase()
+	fmt.Printf("Account Extended Base\n%x\n\n", accountBase.Bytes())
+
+	child, err := account.Derive("0/7")
+	errors.Handle(err)
+	childBase, err := accountBase.Derive("0/7")
+	errors.Handle(err)
+
+	if !bytes.Equal(child.GenSecretBase().Bytes(), childBase.SecretBase.Bytes()) {
+		t.Errorf("non-hardened child base mismatch")
+	}
+
+	_, err = accountBase.Derive("0'")
+	if err == nil {
+		t.Errorf("hardened child derived from extended base")
+	}
+	for _, path := range []string{"m/0", "m"} {
+		if _, err = account.Derive(path); err == nil {
+			t.Errorf("master path %q derived from a child key", path)
+		}
+		if _, err = accountBase.Derive(path); err == nil {
+			t.Errorf("master path %q derived from a child base", path)
+		}
+	}
+	for _, path := range []string{"", "m//1", "1/", "/1", "m/"} {
+		if _, err = ParseDerivationPath(path); err == nil {
+			t.Errorf("path %q with an empty segment parsed", path)
+		}
+	}
+	if indexes, err := ParseDerivationPath("m"); err != nil || len(indexes) != 0 {
+		t.Errorf("master path parsed to %v", indexes)
+	}
+
+	master0, err := new(ExtendedPrivateKey).SetBytes(master.Bytes())
+	errors.Handle(err)
+	account0, err := master0.Derive("m/44h/0h")
+	errors.Handle(err)
+	if !bytes.Equal(account0.Bytes(), account.Bytes()) {
+		t.Errorf("hardened child derivation mismatch")
+	}
+
+	base0, err := new(ExtendedBase).SetBytes(accountBase.Bytes())
+	errors.Handle(err)
+	if !bytes.Equal(base0.Bytes(), accountBase.Bytes()) {
+		t.Errorf("extended base encoding mismatch")
+	}
+}
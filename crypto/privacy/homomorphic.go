@@ -0,0 +1,189 @@
+package privacy
+
+import (
+	"bytes"
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/crypto/elgamal"
+	"github.com/Acoustical/maskash/crypto/zkproofs"
+	"github.com/Acoustical/maskash/errors"
+	"golang.org/x/crypto/bn256"
+)
+
+// Sub sets value to a - b, both values must be solvable and committed against G
+func (value *SecretValue) Sub(a, b *SecretValue) (*SecretValue, error) {
+	if !a.Solvable() || !b.Solvable() {return nil, errors.NewCannotSolveError()}
+	if a.asset != nil || b.asset != nil {return nil, errors.NewCannotSolveError()}
+	ct := new(elgamal.Ciphertext).Sub(a.Ciphertext(), b.Ciphertext())
+	value.c, value.d, value.asset = ct.C(), ct.D(), nil
+	return value, nil
+}
+
+// Add sets value to a + b, both values must be solvable and committed against G
+func (value *AnonymousValue) Add(a, b *AnonymousValue) (*AnonymousValue, error) {
+	if !a.Solvable() || !b.Solvable() {return nil, errors.NewCannotSolveError()}
+	if a.asset != nil || b.asset != nil {return nil, errors.NewCannotSolveError()}
+	ct := new(elgamal.Ciphertext).Add(a.Ciphertext(), b.Ciphertext())
+	value.c, value.d, value.asset = ct.C(), ct.D(), nil
+	return value, nil
+}
+
+// Sub sets value to a - b, both values must be solvable and committed against G
+func (value *AnonymousValue) Sub(a, b *AnonymousValue) (*AnonymousValue, error) {
+	if !a.Solvable() || !b.Solvable() {return nil, errors.NewCannotSolveError()}
+	if a.asset != nil || b.asset != nil {return nil, errors.NewCannotSolveError()}
+	ct := new(elgamal.Ciphertext).Sub(a.Ciphertext(), b.Ciphertext())
+	value.c, value.d, value.asset = ct.C(), ct.D(), nil
+	return value, nil
+}
+
+// AddValues returns a + b for two SecretValues or two AnonymousValues, the sum decrypts only if both are
+// encrypted to the same base
+func AddValues(a, b Value) (Value, error) {
+	switch x := a.(type) {
+	case *SecretValue:
+		y, ok := b.(*SecretValue)
+		if !ok {return nil, errors.NewWrongSlotModeError(common.Secret, b.ValueMode())}
+		return new(SecretValue).Add(x, y)
+	case *AnonymousValue:
+		y, ok := b.(*AnonymousValue)
+		if !ok {return nil, errors.NewWrongSlotModeError(common.Anonymous, b.ValueMode())}
+		return new(AnonymousValue).Add(x, y)
+	default:
+		return nil, errors.NewWrongSlotModeError(common.Secret, a.ValueMode())
+	}
+}
+
+// SubValues returns a - b for two SecretValues or two AnonymousValues
+func SubValues(a, b Value) (Value, error) {
+	switch x := a.(type) {
+	case *SecretValue:
+		y, ok := b.(*SecretValue)
+		if !ok {return nil, errors.NewWrongSlotModeError(common.Secret, b.ValueMode())}
+		return new(SecretValue).Sub(x, y)
+	case *AnonymousValue:
+		y, ok := b.(*AnonymousValue)
+		if !ok {return nil, errors.NewWrongSlotModeError(common.Anonymous, b.ValueMode())}
+		return new(AnonymousValue).Sub(x, y)
+	default:
+		return nil, errors.NewWrongSlotModeError(common.Secret, a.ValueMode())
+	}
+}
+
+// NonNegativeProof shows a value computed homomorphically is in the short range without revealing it: the owner
+// encrypts the amount again with a fresh r, proves the fresh value with a format and range proof and proves
+// c - c' = sk (d - d'), both values encrypt the same amount under the base
+type NonNegativeProof struct {
+	mode uint8
+	value Value
+	zk ZKs
+	eq *zkproofs.EqualityZK
+}
+
+// ProveNonNegative decrypts the value of base owned by prv and proves the amount is in the short range
+func ProveNonNegative(prv *PrivateKey, base Base, value Value) (*NonNegativeProof, error) {
+	g, h, gv, c, d, err := decryptionParts(base, value)
+	if err != nil {return nil, err}
+	if !new(crypto.Commitment).SetIntByGenerator(g, prv.Int).Cmp(&crypto.Commitment{G1: h.G1}) {return nil, errors.NewCannotSolveError()}
+	if !bytes.Equal(gv.Bytes(), g.Bytes()) {return nil, errors.NewAssetTaggedError()}
+	v, err := prv.SolveBy(gv, c, d)
+	if err != nil {return nil, err}
+	rl, err := crypto.RandomZq(1)
+	if err != nil {return nil, err}
+
+	proof := &NonNegativeProof{mode: base.BaseMode()}
+	switch b := base.(type) {
+	case *SecretBase:
+		fresh := b.SetValue(v, rl[0], true)
+		proof.value = fresh
+		proof.zk, err = b.Proof(v, rl[0], fresh)
+	case *AnonymousBase:
+		fresh := b.SetValue(v, rl[0], true)
+		proof.value = fresh
+		proof.zk, err = b.Proof(v, rl[0], fresh)
+	}
+	if err != nil {return nil, err}
+
+	g1, y1, g2, y2, err := proof.statement(base, value)
+	if err != nil {return nil, err}
+	proof.eq = new(zkproofs.EqualityZK).Init()
+	proof.eq.SetPrivate(prv.Int, g1, y1, g2, y2, crypto.Hash_(base, value, proof.value).BigInt())
+	err = proof.eq.Proof()
+	if err != nil {return nil, err}
+	return proof, nil
+}
+
+// VerifyNonNegative checks the value of base is in the short range with public data only
+func VerifyNonNegative(base Base, value Value, proof *NonNegativeProof) bool {
+	if proof == nil || proof.eq == nil || proof.mode != base.BaseMode() {return false}
+	switch b := base.(type) {
+	case *SecretBase:
+		fresh, ok1 := proof.value.(*SecretValue)
+		zk, ok2 := proof.zk.(*SecretZK)
+		if !ok1 || !ok2 || fresh.asset != nil || !b.Check(fresh, zk) {return false}
+	case *AnonymousBase:
+		fresh, ok1 := proof.value.(*AnonymousValue)
+		zk, ok2 := proof.zk.(*AnonymousZK)
+		if !ok1 || !ok2 || fresh.asset != nil || !b.Check(fresh, zk) {return false}
+	default:
+		return false
+	}
+	g1, y1, g2, y2, err := proof.statement(base, value)
+	if err != nil {return false}
+	proof.eq.SetPublic(g1, y1, g2, y2, crypto.Hash_(base, value, proof.value).BigInt())
+	return proof.eq.Check()
+}
+
+// statement returns h = sk g and c - c' = sk (d - d') for the value and the fresh value
+func (proof *NonNegativeProof) statement(base Base, value Value) (*crypto.Generator, *crypto.Commitment, *crypto.Generator, *crypto.Commitment, error) {
+	g, h, gv, c, d, err := decryptionParts(base, value)
+	if err != nil {return nil, nil, nil, nil, err}
+	if !bytes.Equal(gv.Bytes(), g.Bytes()) {return nil, nil, nil, nil, errors.NewAssetTaggedError()}
+	_, _, _, c1, d1, err := decryptionParts(base, proof.value)
+	if err != nil {return nil, nil, nil, nil, err}
+	y2 := (&crypto.Commitment{G1: new(bn256.G1).Neg(c1.G1)}).AddBy(c)
+	g2 := (&crypto.Commitment{G1: new(bn256.G1).Neg(d1.G1)}).AddBy(d)
+	return g, &crypto.Commitment{G1: h.G1}, &crypto.Generator{G1: g2.G1}, y2, nil
+}
+
+func (proof *NonNegativeProof) ZKMode() uint8 {return proof.mode}
+
+// Bytes returns mode | fresh value | format and range proof | equality proof
+func (proof *NonNegativeProof) Bytes() []byte {
+	b := make([]byte, 0, common.NonNegativeProofLength)
+	b = append(b, proof.mode)
+	b = append(b, proof.value.Bytes()...)
+	b = append(b, proof.zk.Bytes()...)
+	b = append(b, proof.eq.Bytes()...)
+	return b
+}
+
+func (proof *NonNegativeProof) SetBytes(b []byte) (*NonNegativeProof, error) {
+	bLen := len(b)
+	if bLen != common.NonNegativeProofLength {return nil, errors.NewWrongInputLength(bLen)}
+	valueEnd := 1 + common.SecretSolvableValueLength
+	zkEnd := valueEnd + common.SecretZKsLength
+	var err error
+	proof.mode = b[0]
+	switch proof.mode {
+	case common.Secret:
+		proof.value, err = new(SecretValue).SetBytes(b[1:valueEnd])
+		if err != nil {return nil, err}
+		zk := new(SecretZK)
+		err = zk.SetBytes(b[valueEnd:zkEnd])
+		proof.zk = zk
+	case common.Anonymous:
+		proof.value, err = new(AnonymousValue).SetBytes(b[1:valueEnd])
+		if err != nil {return nil, err}
+		zk := new(AnonymousZK)
+		err = zk.SetBytes(b[valueEnd:zkEnd])
+		proof.zk = zk
+	default:
+		return nil, errors.NewWrongSlotModeError(common.Secret, proof.mode)
+	}
+	if err != nil {return nil, err}
+	proof.eq = new(zkproofs.EqualityZK).Init()
+	err = proof.eq.SetBytes(b[zkEnd:])
+	if err != nil {return nil, err}
+	return proof, nil
+}
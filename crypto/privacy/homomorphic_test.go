@@ -0,0 +1,55 @@
+package privacy
+
+import (
+	"bytes"
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/errors"
+	"math/big"
+	"testing"
+)
+
+func TestNonNegative(t *testing.T) {
+	prv := NewRandomPrivateKey()
+	rl, _ := crypto.RandomZq(4)
+
+	secret := prv.GenSecretBase()
+	balance := secret.SetValue(big.NewInt(1919), rl[0], true)
+	amount := secret.SetValue(big.NewInt(810), rl[1], true)
+	rest, err := SubValues(balance, amount)
+	errors.Handle(err)
+	proof, err := ProveNonNegative(prv, secret, rest)
+	errors.Handle(err)
+	proof1, err := new(NonNegativeProof).SetBytes(proof.Bytes())
+	errors.Handle(err)
+	if len(proof.Bytes()) != common.NonNegativeProofLength || !bytes.Equal(proof1.Bytes(), proof.Bytes()) || !VerifyNonNegative(secret, rest, proof1) {
+		t.Errorf("secret non negative proof check failed")
+	}
+	if v, _ := rest.Solve(prv); v.Int64() != 1109 {
+		t.Errorf("secret difference %d, want 1109", v)
+	}
+	sum, err := AddValues(rest, amount)
+	errors.Handle(err)
+	if VerifyNonNegative(secret, sum, proof) {
+		t.Errorf("secret non negative proof accepted for another value")
+	}
+	negative, err := SubValues(amount, balance)
+	errors.Handle(err)
+	if _, err = ProveNonNegative(prv, secret, negative); err == nil {
+		t.Errorf("secret negative value proved non negative")
+	}
+
+	anonymous := prv.GenAnonymousBase()
+	balance1 := anonymous.SetValue(big.NewInt(114514), rl[2], true)
+	amount1 := anonymous.SetValue(big.NewInt(4), rl[3], true)
+	rest1, err := SubValues(balance1, amount1)
+	errors.Handle(err)
+	proof2, err := ProveNonNegative(prv, anonymous, rest1)
+	errors.Handle(err)
+	if !VerifyNonNegative(anonymous, rest1, proof2) || VerifyNonNegative(secret, rest, proof2) {
+		t.Errorf("anonymous non negative proof check failed")
+	}
+	if _, err = SubValues(balance, amount1); err == nil {
+		t.Errorf("secret and anonymous values subtracted")
+	}
+}
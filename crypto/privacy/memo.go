@@ -0,0 +1,48 @@
+package privacy
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/errors"
+)
+
+var memoKeyDomain = crypto.HashBytes("maskash memo key")
+
+// padMemo pads memo with zeros to the fixed MemoLength
+func padMemo(memo []byte) ([]byte, error) {
+	mLen := len(memo)
+	if mLen > common.MemoLength {return nil, errors.NewWrongInputLength(mLen)}
+	padded := make([]byte, common.MemoLength)
+	copy(padded, memo)
+	return padded, nil
+}
+
+// memoAEAD keys the memo cipher with the shared point rh = sk d which is unique for each output
+func memoAEAD(shared *crypto.Commitment) (cipher.AEAD, error) {
+	key := crypto.Hash_(shared, memoKeyDomain)
+	block, err := aes.NewCipher(key[:])
+	if err != nil {return nil, err}
+	return cipher.NewGCM(block)
+}
+
+// sealMemo encrypts memo under the shared point, the base and the value are bound as additional data
+func sealMemo(shared *crypto.Commitment, base Base, value Value, memo []byte) ([]byte, error) {
+	padded, err := padMemo(memo)
+	if err != nil {return nil, err}
+	aead, err := memoAEAD(shared)
+	if err != nil {return nil, err}
+	nonce := make([]byte, aead.NonceSize())
+	return aead.Seal(nil, nonce, padded, append(base.Bytes(), value.Bytes()...)), nil
+}
+
+// openMemo decrypts the memo sealed by sealMemo
+func openMemo(shared *crypto.Commitment, base Base, value Value, cipherText []byte) ([]byte, error) {
+	aead, err := memoAEAD(shared)
+	if err != nil {return nil, err}
+	nonce := make([]byte, aead.NonceSize())
+	memo, err := aead.Open(nil, nonce, cipherText, append(base.Bytes(), value.Bytes()...))
+	if err != nil {return nil, errors.NewCannotOpenMemoError()}
+	return memo, nil
+}
@@ -0,0 +1,65 @@
+package privacy
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/errors"
+	"math/big"
+	"testing"
+)
+
+func TestMemo(t *testing.T) {
+	prv := NewRandomPrivateKey()
+	memo := []byte("invoice #114514")
+	rl, _ := crypto.RandomZq(3)
+
+	slot0, err := prv.GenSecretBase().NewSecretOutputSlot(big.NewInt(1919), rl[0], true, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+	err = slot0.SetMemo(memo, rl[0])
+	errors.Handle(err)
+
+	slot1, err := new(SecretSlot).Init().SetBytes(slot0.Bytes())
+	errors.Handle(err)
+	memo1, err := slot1.Memo(prv)
+	errors.Handle(err)
+	fmt.Printf("Secret memo\n%s\n\n", memo1)
+	if !bytes.Equal(memo1[:len(memo)], memo) || !slot1.CheckZKs() {
+		t.Errorf("secret memo mismatch")
+	}
+	if _, err = slot1.Memo(NewRandomPrivateKey()); err == nil {
+		t.Errorf("secret memo opened by another key")
+	}
+
+	slot2, err := prv.GenAnonymousBase().NewAnonymousOutputSlot(big.NewInt(810), rl[1], true, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+	err = slot2.SetMemo(memo, rl[1])
+	errors.Handle(err)
+	slot3, err := new(AnonymousSlot).Init().SetBytes(slot2.Bytes())
+	errors.Handle(err)
+	memo3, err := slot3.Memo(prv)
+	errors.Handle(err)
+	fmt.Printf("Anonymous memo\n%s\n\n", memo3)
+	if !bytes.Equal(memo3[:len(memo)], memo) {
+		t.Errorf("anonymous memo mismatch")
+	}
+
+	slot4, err := prv.GenSecretBase().NewSecretOutputSlot(big.NewInt(1919), rl[2], true, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+	slot4.memo = slot0.memo
+	if _, err = slot4.Memo(prv); err == nil {
+		t.Errorf("swapped memo opened")
+	}
+
+	slot5, err := prv.GenPlaintextBase().NewPlaintextOutputSlot(big.NewInt(114514), common.NoneContractSlot, nil)
+	errors.Handle(err)
+	err = slot5.SetMemo(memo)
+	errors.Handle(err)
+	slot6, err := new(PlaintextSlot).SetBytes(slot5.Bytes())
+	errors.Handle(err)
+	fmt.Printf("Plaintext memo\n%s\n\n", slot6.Memo())
+	if !bytes.Equal(slot6.Memo()[:len(memo)], memo) {
+		t.Errorf("plaintext memo mismatch")
+	}
+}
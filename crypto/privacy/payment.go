@@ -0,0 +1,73 @@
+package privacy
+
+import (
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/crypto/zkproofs"
+	"github.com/Acoustical/maskash/errors"
+	"math/big"
+)
+
+// PaymentProof proves an output slot paid the amount v to its base with d = r g and c - v gv = r h,
+// the sender proves with the blinding factor r of the output, neither the recipient key nor r is revealed
+type PaymentProof struct {
+	Amount *big.Int
+	zk *zkproofs.EqualityZK
+}
+
+// NewPaymentProof proves the Secret or Anonymous output slot created with the blinding factor r pays v
+func NewPaymentProof(slot Slot, v, r *big.Int) (*PaymentProof, error) {
+	if slot.SlotMode() & common.TxSlotKind != common.OutputSlot {return nil, errors.NewWrongSlotModeError(common.OutputSlot, slot.SlotMode() & common.TxSlotKind)}
+	g, h, gv, c, d, err := decryptionParts(slot.Base(), slot.Value())
+	if err != nil {return nil, err}
+	if !new(crypto.Commitment).SetIntByGenerator(g, r).Cmp(d) {return nil, errors.NewCannotSolveError()}
+
+	proof := &PaymentProof{Amount: v}
+	g1, y1, g2, y2 := paymentStatement(g, h, gv, c, d, v)
+	proof.zk = new(zkproofs.EqualityZK).Init()
+	proof.zk.SetPrivate(r, g1, y1, g2, y2, paymentContext(slot, v))
+	err = proof.zk.Proof()
+	if err != nil {return nil, err}
+	return proof, nil
+}
+
+// Check checks the proof against the output slot with public data only
+func (proof *PaymentProof) Check(slot Slot) bool {
+	if proof.zk == nil || proof.Amount == nil || proof.Amount.Sign() < 0 {return false}
+	if slot.SlotMode() & common.TxSlotKind != common.OutputSlot {return false}
+	g, h, gv, c, d, err := decryptionParts(slot.Base(), slot.Value())
+	if err != nil {return false}
+	g1, y1, g2, y2 := paymentStatement(g, h, gv, c, d, proof.Amount)
+	proof.zk.SetPublic(g1, y1, g2, y2, paymentContext(slot, proof.Amount))
+	return proof.zk.Check()
+}
+
+// paymentStatement returns d = r g and c - v gv = r h
+func paymentStatement(g, h, gv *crypto.Generator, c, d *crypto.Commitment, v *big.Int) (*crypto.Generator, *crypto.Commitment, *crypto.Generator, *crypto.Commitment) {
+	y2 := new(crypto.Commitment).SetIntByGenerator(gv, v).Neg().AddBy(c)
+	return g, d, h, y2
+}
+
+func paymentContext(slot Slot, v *big.Int) *big.Int {
+	return crypto.Hash_(slot.Base(), slot.Value(), v).BigInt()
+}
+
+func (proof *PaymentProof) Bytes() []byte {
+	zqBytes := common.Bn256ZqBits / common.ByteBits
+	bytes := make([]byte, common.PaymentProofLength)
+	vBytes := proof.Amount.Bytes()
+	copy(bytes[zqBytes-len(vBytes):zqBytes], vBytes)
+	copy(bytes[zqBytes:], proof.zk.Bytes())
+	return bytes
+}
+
+func (proof *PaymentProof) SetBytes(b []byte) (*PaymentProof, error) {
+	bLen := len(b)
+	if bLen != common.PaymentProofLength {return nil, errors.NewWrongInputLength(bLen)}
+	zqBytes := common.Bn256ZqBits / common.ByteBits
+	proof.Amount = new(big.Int).SetBytes(b[:zqBytes])
+	proof.zk = new(zkproofs.EqualityZK).Init()
+	err := proof.zk.SetBytes(b[zqBytes:])
+	if err != nil {return nil, err}
+	return proof, nil
+}
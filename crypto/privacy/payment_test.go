@@ -0,0 +1,42 @@
+package privacy
+
+import (
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/errors"
+	"math/big"
+	"testing"
+)
+
+func TestPaymentProof(t *testing.T) {
+	rl, _ := crypto.RandomZq(2)
+	secret, err := NewRandomPrivateKey().GenSecretBase().NewSecretOutputSlot(big.NewInt(1919), rl[0], true, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+	anonymous, err := NewRandomPrivateKey().GenAnonymousBase().NewAnonymousOutputSlot(big.NewInt(810), rl[1], true, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+
+	proof, err := NewPaymentProof(secret, big.NewInt(1919), rl[0])
+	errors.Handle(err)
+	proof1, err := new(PaymentProof).SetBytes(proof.Bytes())
+	errors.Handle(err)
+	if !proof1.Check(secret) {
+		t.Errorf("secret payment proof check failed")
+	}
+	if proof1.Check(anonymous) {
+		t.Errorf("secret payment proof accepted for another slot")
+	}
+
+	proof, err = NewPaymentProof(anonymous, big.NewInt(810), rl[1])
+	errors.Handle(err)
+	if !proof.Check(anonymous) {
+		t.Errorf("anonymous payment proof check failed")
+	}
+	proof.Amount = big.NewInt(811)
+	if proof.Check(anonymous) {
+		t.Errorf("anonymous payment proof accepted a wrong amount")
+	}
+
+	if _, err = NewPaymentProof(anonymous, big.NewInt(810), rl[0]); err == nil {
+		t.Errorf("payment proved with a wrong blinding factor")
+	}
+}
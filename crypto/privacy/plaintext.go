@@ -39,6 +39,7 @@ type PlaintextSlot struct {
 	*PlaintextValue
 	*PlaintextZK
 	ContractSlot
+	memo []byte
 }
 
 func (slot *PlaintextSlot) Init() *PlaintextSlot {
@@ -89,7 +90,8 @@ func (slot *PlaintextSlot) Bytes() []byte {
 			contractBytes = slot.ContractSlot.Bytes()
 			contractLength = len(contractBytes)
 		}
-		totalLength = common.PlaintextOutputSlotLength + contractLength
+		memo := extensionBytes(slot.memo, nil, nil, nil)
+		totalLength = common.PlaintextOutputSlotLength - common.ExtensionFlagLength + len(memo) + contractLength
 		bytes = make([]byte, totalLength)
 
 		baseBytes := slot.PlaintextBase.Bytes()
@@ -104,6 +106,10 @@ func (slot *PlaintextSlot) Bytes() []byte {
 		end = start + common.PlaintextOutputValueLength
 		copy(bytes[start:end], valueBytes)
 
+		start = end
+		end = start + len(memo)
+		copy(bytes[start:end], memo)
+
 		if contractLength > 0 {
 			start = end
 			end = start + contractLength
@@ -142,18 +148,26 @@ func (slot *PlaintextSlot) SetBytes(b []byte) (*PlaintextSlot, error) {
 		err = slot.PlaintextZK.SetBytes(b[start:end])
 		if err != nil {return nil, err}
 	} else {
+		if bLen < common.PlaintextOutputSlotLength {return nil, errors.NewWrongInputLength(bLen)}
+		memoStart := common.PlaintextOutputSlotLength - common.ExtensionFlagLength
+		memo, asset, audit, spend, memoLength, err := setExtensionBytes(b[memoStart:], common.MemoLength)
+		if err != nil {return nil, err}
+		if asset != nil || audit != nil || spend != nil {return nil, errors.NewWrongInputLength(bLen)}
+		contractStart := memoStart + memoLength
+
 		var contractLength int
 		if slot.mode & common.ContractSlotMode != common.NoneContractSlot {
-			contractLength = int(b[common.PlaintextOutputSlotLength]) << 8 + int(b[common.PlaintextOutputSlotLength+1]) + 2
+			if bLen < contractStart + 2 {return nil, errors.NewWrongInputLength(bLen)}
+			contractLength = int(b[contractStart]) << 8 + int(b[contractStart+1]) + 2
 		}
-		if bLen != common.PlaintextOutputSlotLength + contractLength {return nil, errors.NewWrongInputLength(bLen)}
+		if bLen != contractStart + contractLength {return nil, errors.NewWrongInputLength(bLen)}
 
 		slot.PlaintextBase = new(PlaintextBase)
 		slot.PlaintextValue = new(PlaintextValue)
 
 		start := 1
 		end := start+common.PlaintextBaseLength
-		err := slot.PlaintextBase.SetBytes(b[start:end])
+		err = slot.PlaintextBase.SetBytes(b[start:end])
 		if err != nil {return nil, err}
 
 		start = end
@@ -161,9 +175,11 @@ func (slot *PlaintextSlot) SetBytes(b []byte) (*PlaintextSlot, error) {
 		_, err = slot.PlaintextValue.SetBytes(b[start:end])
 		if err != nil {return nil, err}
 
+		slot.memo = memo
 		if contractLength > 0 {
-			start = end
+			start = contractStart
 			end = start + contractLength
+			slot.ContractSlot = newContractSlot(mode)
 			err = slot.ContractSlot.SetBytes(b[start:end])
 			if err != nil {return nil, err}
 		}
@@ -181,8 +197,45 @@ func (slot *PlaintextSlot) SetBase(base *PlaintextBase) {slot.PlaintextBase = ba
 
 func (slot *PlaintextSlot) SetValue(nonce, value *big.Int) {slot.PlaintextValue = slot.PlaintextBase.SetValue(nonce, value)}
 
+// SetMemo sets the cleartext memo of the output slot
+func (slot *PlaintextSlot) SetMemo(memo []byte) error {
+	if slot.mode & common.TxSlotKind != common.OutputSlot {return errors.NewWrongSlotModeError(common.OutputSlot, slot.mode & common.TxSlotKind)}
+	padded, err := padMemo(memo)
+	if err != nil {return err}
+	slot.memo = padded
+	return nil
+}
+
+// Sign replaces the signature of the input slot over Hash_(base, value) by a signature over the message e,
+// such as the hash of the whole transaction
+func (slot *PlaintextSlot) Sign(prv *PrivateKey, e *big.Int) error {
+	if slot.mode & common.TxSlotKind != common.InputSlot {return errors.NewWrongSlotModeError(common.InputSlot, slot.mode & common.TxSlotKind)}
+	h := new(crypto.Generator).Init(prv.Int)
+	sig := new(zkproofs.Signature).Init()
+	sig.SetPrivate(prv.Int, slot.PlaintextBase.addr, h, e)
+	err := sig.Proof()
+	if err != nil {return err}
+	slot.PlaintextZK = &PlaintextZK{sig}
+	return nil
+}
+
+// CheckSignature checks the signature of the input slot over the message e
+func (slot *PlaintextSlot) CheckSignature(e *big.Int) bool {
+	if slot.PlaintextZK == nil || slot.PlaintextZK.sig == nil {return false}
+	slot.PlaintextZK.sig.SetPublic(slot.PlaintextBase.addr, e)
+	return slot.PlaintextZK.sig.Check()
+}
+
+// Memo returns the memo of the slot, returns nil if the slot has no memo
+func (slot *PlaintextSlot) Memo() []byte {return slot.memo}
+
 type PlaintextBase struct {addr crypto.Address}
 
+// NewPlaintextBase returns the base of address, such as the address of a contract
+func NewPlaintextBase(address crypto.Address) *PlaintextBase {return &PlaintextBase{address}}
+
+func (base *PlaintextBase) Address() crypto.Address {return base.addr}
+
 func (base *PlaintextBase) BaseMode() uint8 {return common.Plaintext}
 
 func (base *PlaintextBase) Bytes() []byte {return base.addr[:]}
@@ -206,11 +259,12 @@ func (base *PlaintextBase) Proof(prv *PrivateKey, value *PlaintextValue) (*Plain
 	return &PlaintextZK{sig}, err
 }
 
+// Check checks the signature of zk over the base and the value, the public data is set on the signature itself
 func (base *PlaintextBase) Check(value *PlaintextValue, zk *PlaintextZK) bool {
+	if zk == nil || zk.sig == nil {return false}
 	e := crypto.Hash_(base, value).BigInt()
 
-	sig := new(zkproofs.Signature).Init()
-	sig.SetPublic(base.addr, e)
+	zk.sig.SetPublic(base.addr, e)
 	return zk.sig.Check()
 }
 
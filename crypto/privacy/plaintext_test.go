@@ -42,3 +42,24 @@ func TestPlaintext(t *testing.T) {
 		fmt.Printf("Solt0 ZK check failed!\n\n")
 	}
 }
+
+func TestPlaintextCheck(t *testing.T) {
+	prv, other := NewRandomPrivateKey(), NewRandomPrivateKey()
+	slot := prv.NewPlaintextInputSlot(big.NewInt(1), big.NewInt(114514))
+	decoded, err := new(PlaintextSlot).SetBytes(slot.Bytes())
+	errors.Handle(err)
+	if !decoded.CheckZKs() {
+		t.Errorf("decoded plaintext input rejected")
+	}
+
+	forged, err := new(PlaintextSlot).SetBytes(slot.Bytes())
+	errors.Handle(err)
+	forged.PlaintextZK = other.NewPlaintextInputSlot(big.NewInt(1), big.NewInt(114514)).PlaintextZK
+	if forged.CheckZKs() {
+		t.Errorf("plaintext input accepted with the signature of another key")
+	}
+	forged.PlaintextZK = nil
+	if forged.CheckZKs() {
+		t.Errorf("plaintext input accepted without a signature")
+	}
+}
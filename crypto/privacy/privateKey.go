@@ -1,9 +1,10 @@
 package privacy
 
 import (
+	"bytes"
 	"github.com/Acoustical/maskash/common"
 	"github.com/Acoustical/maskash/crypto"
-	"github.com/Acoustical/maskash/errors"
+	"github.com/Acoustical/maskash/crypto/elgamal"
 	"math/big"
 )
 
@@ -21,9 +22,10 @@ func NewHashPrivateKey(v... crypto.HashVariable) *PrivateKey {
 	return &PrivateKey{key}
 }
 
+// GenSecretBase returns the base of a single key, the key both solves the values and authorizes the spends
 func (prv PrivateKey) GenSecretBase() *SecretBase {
 	h := new(crypto.Generator).Init(prv.Int)
-	return &SecretBase{h}
+	return &SecretBase{h, h}
 }
 
 func (prv PrivateKey) GenPlaintextBase() *PlaintextBase {
@@ -45,22 +47,26 @@ func (base *SecretBase) GenAnonymousBase() *AnonymousBase {
 
 	g := new(crypto.Generator).Init(r)
 	h := new(crypto.Generator).Mul(base.h, r)
+	var spend *crypto.Generator
+	if base.spend != nil {spend = new(crypto.Generator).Mul(base.spend, r)}
 
-	return &AnonymousBase{g,h}
+	return &AnonymousBase{g, h, spend}
 }
 
+// ElGamal returns the ElGamal private key of prv with the generator g
+func (prv *PrivateKey) ElGamal(g *crypto.Generator) *elgamal.PrivateKey {return elgamal.NewPrivateKey(g, prv.Int)}
+
 func (prv *PrivateKey) Solve(c, d *crypto.Commitment) (*big.Int, error) {
-	gv := new(crypto.Commitment).Mul(d, prv.Int)
-	gv.Neg()
-	gv.AddBy(c)
+	g := new(crypto.Generator).Init(big.NewInt(1))
+	return prv.ElGamal(g).Decrypt(elgamal.NewCiphertext(c, d), common.RangeProofShortBits)
+}
+
+// SolveBy solves c = vg + sk d with the value generator g. Only the table of G is cached, the AnonymousBases and the
+// asset tags blind their generator for every slot so their tables are never used twice
+func (prv *PrivateKey) SolveBy(g *crypto.Generator, c, d *crypto.Commitment) (*big.Int, error) {
+	ct := elgamal.NewCiphertext(c, d)
+	if bytes.Equal(g.Marshal(), valueGenerator.Marshal()) {return prv.ElGamal(g).Decrypt(ct, common.RangeProofShortBits)}
+	return prv.ElGamal(g).DecryptOnce(ct, common.RangeProofShortBits)
+}
 
-	one := big.NewInt(1)
-	max := big.NewInt(int64(common.MaxShortValue))
-	for i := big.NewInt(0); i.Cmp(max) < 0; i.Add(i, one) {
-		gi := new(crypto.Commitment).SetInt(i)
-		if gi.Cmp(gv) {
-			return i, nil
-		}
-	}
-	return nil, errors.NewCannotFindValueError()
-}
\ No newline at end of file
+var valueGenerator = new(crypto.Generator).Init(big.NewInt(1))
@@ -0,0 +1,152 @@
+package privacy
+
+import (
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/crypto/elgamal"
+	"github.com/Acoustical/maskash/crypto/zkproofs"
+	"github.com/Acoustical/maskash/errors"
+	"math/big"
+)
+
+// SecretRerandomization adds an encryption of zero to a solvable SecretValue, the amount and the owner are unchanged
+type SecretRerandomization struct {
+	Base *SecretBase
+	Input, Output *SecretValue
+	zk *zkproofs.EqualityZK
+}
+
+// Rerandomize returns an unlinkable copy of the value of base with a proof it encrypts the same amount
+func (base *SecretBase) Rerandomize(value *SecretValue) (*SecretRerandomization, error) {
+	if !value.Solvable() {return nil, errors.NewCannotSolveError()}
+	if value.asset != nil {return nil, errors.NewAssetTaggedError()}
+	ct, t, err := base.PublicKey().Rerandomize(value.Ciphertext())
+	if err != nil {return nil, err}
+
+	rerandomization := &SecretRerandomization{Base: base, Input: value, Output: &SecretValue{ct.C(), ct.D(), nil}}
+	// c' - c = t h and d' - d = t G
+	g1, y1, g2, y2 := rerandomization.statement()
+	rerandomization.zk = new(zkproofs.EqualityZK).Init()
+	rerandomization.zk.SetPrivate(t, g1, y1, g2, y2, rerandomization.context())
+	err = rerandomization.zk.Proof()
+	if err != nil {return nil, err}
+	return rerandomization, nil
+}
+
+func (rerandomization *SecretRerandomization) statement() (*crypto.Generator, *crypto.Commitment, *crypto.Generator, *crypto.Commitment) {
+	diff := new(elgamal.Ciphertext).Sub(rerandomization.Output.Ciphertext(), rerandomization.Input.Ciphertext())
+	g := new(crypto.Generator).Init(big.NewInt(1))
+	return rerandomization.Base.h, diff.C(), g, diff.D()
+}
+
+func (rerandomization *SecretRerandomization) context() *big.Int {
+	return crypto.Hash_(rerandomization.Base, rerandomization.Input, rerandomization.Output).BigInt()
+}
+
+// Check verifies the output encrypts the amount of the input under the same base
+func (rerandomization *SecretRerandomization) Check() bool {
+	if rerandomization.zk == nil || !rerandomization.Input.Solvable() || !rerandomization.Output.Solvable() {return false}
+	g1, y1, g2, y2 := rerandomization.statement()
+	rerandomization.zk.SetPublic(g1, y1, g2, y2, rerandomization.context())
+	return rerandomization.zk.Check()
+}
+
+func (rerandomization *SecretRerandomization) Bytes() []byte {
+	return concatBytes(rerandomization.Base.Bytes(), rerandomization.Input.Bytes(), rerandomization.Output.Bytes(), rerandomization.zk.Bytes())
+}
+
+func (rerandomization *SecretRerandomization) SetBytes(b []byte) (*SecretRerandomization, error) {
+	bLen := len(b)
+	if bLen != common.SecretRerandomizationLength {return nil, errors.NewWrongInputLength(bLen)}
+	start, end := 0, common.SecretBaseLength
+	rerandomization.Base = new(SecretBase)
+	err := rerandomization.Base.SetBytes(b[start:end])
+	if err != nil {return nil, err}
+
+	start, end = end, end + common.SecretSolvableValueLength
+	rerandomization.Input, err = new(SecretValue).SetBytes(b[start:end])
+	if err != nil {return nil, err}
+	start, end = end, end + common.SecretSolvableValueLength
+	rerandomization.Output, err = new(SecretValue).SetBytes(b[start:end])
+	if err != nil {return nil, err}
+
+	rerandomization.zk = new(zkproofs.EqualityZK).Init()
+	err = rerandomization.zk.SetBytes(b[end:])
+	if err != nil {return nil, err}
+	return rerandomization, nil
+}
+
+// AnonymousRerandomization moves a solvable AnonymousValue to the base (k g, k h) and adds an encryption of zero,
+// the owner of the input base still owns the output base and the amount is unchanged
+type AnonymousRerandomization struct {
+	InputBase, OutputBase *AnonymousBase
+	Input, Output *AnonymousValue
+	zk *zkproofs.RerandomizationZK
+}
+
+// Rerandomize returns an unlinkable copy of the base and the value with a proof it encrypts the same amount
+func (base *AnonymousBase) Rerandomize(value *AnonymousValue) (*AnonymousRerandomization, error) {
+	if !value.Solvable() {return nil, errors.NewCannotSolveError()}
+	if value.asset != nil {return nil, errors.NewAssetTaggedError()}
+	kt, err := crypto.RandomZq(2)
+	if err != nil {return nil, err}
+	k, t := kt[0], kt[1]
+
+	outputBase := &AnonymousBase{g: new(crypto.Generator).Mul(base.g, k), h: new(crypto.Generator).Mul(base.h, k)}
+	if base.spend != nil {outputBase.spend = new(crypto.Generator).Mul(base.spend, k)}
+	scaled := new(elgamal.Ciphertext).ScalarMul(value.Ciphertext(), k)
+	ct, err := outputBase.PublicKey().RerandomizeWith(scaled, t)
+	if err != nil {return nil, err}
+
+	rerandomization := &AnonymousRerandomization{base, outputBase, value, &AnonymousValue{ct.C(), ct.D(), nil}, nil}
+	rerandomization.zk = new(zkproofs.RerandomizationZK).Init()
+	g1, h1, c1, d1, g2, h2, c2, d2, e := rerandomization.statement()
+	rerandomization.zk.SetPrivate(k, t, g1, h1, c1, d1, g2, h2, c2, d2, e)
+	err = rerandomization.zk.Proof()
+	if err != nil {return nil, err}
+	return rerandomization, nil
+}
+
+func (rerandomization *AnonymousRerandomization) statement() (*crypto.Generator, *crypto.Generator, *crypto.Commitment, *crypto.Commitment, *crypto.Generator, *crypto.Generator, *crypto.Commitment, *crypto.Commitment, *big.Int) {
+	in, out := rerandomization.InputBase, rerandomization.OutputBase
+	inValue, outValue := rerandomization.Input, rerandomization.Output
+	e := crypto.Hash_(in, out, inValue, outValue).BigInt()
+	return in.g, in.h, inValue.c, inValue.d, out.g, out.h, outValue.c, outValue.d, e
+}
+
+// Check verifies the output encrypts the amount of the input to the same owner
+func (rerandomization *AnonymousRerandomization) Check() bool {
+	if rerandomization.zk == nil || !rerandomization.Input.Solvable() || !rerandomization.Output.Solvable() {return false}
+	rerandomization.zk.SetPublic(rerandomization.statement())
+	return rerandomization.zk.Check()
+}
+
+func (rerandomization *AnonymousRerandomization) Bytes() []byte {
+	return concatBytes(rerandomization.InputBase.Bytes(), rerandomization.OutputBase.Bytes(),
+		rerandomization.Input.Bytes(), rerandomization.Output.Bytes(), rerandomization.zk.Bytes())
+}
+
+func (rerandomization *AnonymousRerandomization) SetBytes(b []byte) (*AnonymousRerandomization, error) {
+	bLen := len(b)
+	if bLen != common.AnonymousRerandomizationLength {return nil, errors.NewWrongInputLength(bLen)}
+	start, end := 0, common.AnonymousBaseLength
+	rerandomization.InputBase = new(AnonymousBase)
+	err := rerandomization.InputBase.SetBytes(b[start:end])
+	if err != nil {return nil, err}
+	start, end = end, end + common.AnonymousBaseLength
+	rerandomization.OutputBase = new(AnonymousBase)
+	err = rerandomization.OutputBase.SetBytes(b[start:end])
+	if err != nil {return nil, err}
+
+	start, end = end, end + common.AnonymousSolvableValueLength
+	rerandomization.Input, err = new(AnonymousValue).SetBytes(b[start:end])
+	if err != nil {return nil, err}
+	start, end = end, end + common.AnonymousSolvableValueLength
+	rerandomization.Output, err = new(AnonymousValue).SetBytes(b[start:end])
+	if err != nil {return nil, err}
+
+	rerandomization.zk = new(zkproofs.RerandomizationZK).Init()
+	err = rerandomization.zk.SetBytes(b[end:])
+	if err != nil {return nil, err}
+	return rerandomization, nil
+}
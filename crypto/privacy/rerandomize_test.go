@@ -0,0 +1,70 @@
+package privacy
+
+import (
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/errors"
+	"math/big"
+	"testing"
+)
+
+func TestSecretRerandomize(t *testing.T) {
+	prv := NewRandomPrivateKey()
+	base := prv.GenSecretBase()
+	rl, _ := crypto.RandomZq(1)
+	slot, err := base.NewSecretOutputSlot(big.NewInt(1919), rl[0], true, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+
+	rerandomization, err := base.Rerandomize(slot.SecretValue)
+	errors.Handle(err)
+	rerandomization1, err := new(SecretRerandomization).SetBytes(rerandomization.Bytes())
+	errors.Handle(err)
+	if !rerandomization1.Check() {
+		t.Errorf("secret rerandomization check failed")
+	}
+	if rerandomization1.Output.c.Cmp(slot.SecretValue.c) {
+		t.Errorf("secret rerandomization output linkable")
+	}
+	if v, err := rerandomization1.Output.Solve(prv); err != nil || v.Int64() != 1919 {
+		t.Errorf("secret rerandomization output solved to %d", v)
+	}
+
+	one := new(crypto.Commitment).SetInt(big.NewInt(1))
+	rerandomization1.Output.c = one.AddBy(rerandomization1.Output.c)
+	if rerandomization1.Check() {
+		t.Errorf("secret rerandomization accepted with a changed amount")
+	}
+}
+
+func TestAnonymousRerandomize(t *testing.T) {
+	prv := NewRandomPrivateKey()
+	base := prv.GenAnonymousBase()
+	rl, _ := crypto.RandomZq(1)
+	slot, err := base.NewAnonymousOutputSlot(big.NewInt(810), rl[0], true, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+
+	rerandomization, err := base.Rerandomize(slot.AnonymousValue)
+	errors.Handle(err)
+	rerandomization1, err := new(AnonymousRerandomization).SetBytes(rerandomization.Bytes())
+	errors.Handle(err)
+	if !rerandomization1.Check() {
+		t.Errorf("anonymous rerandomization check failed")
+	}
+	if !prv.Owns(rerandomization1.OutputBase) {
+		t.Errorf("anonymous rerandomization output base not owned")
+	}
+	if v, err := prv.SolveAnonymous(rerandomization1.OutputBase, rerandomization1.Output); err != nil || v.Int64() != 810 {
+		t.Errorf("anonymous rerandomization output solved to %d", v)
+	}
+
+	rerandomization1.OutputBase = prv.GenAnonymousBase()
+	if rerandomization1.Check() {
+		t.Errorf("anonymous rerandomization accepted with another base")
+	}
+
+	tagged, err := base.NewAnonymousAssetOutputSlot(big.NewInt(1), rl[0], NativeAsset, []AssetID{NativeAsset}, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+	if _, err = base.Rerandomize(tagged.AnonymousValue); err == nil {
+		t.Errorf("asset tagged value rerandomized")
+	}
+}
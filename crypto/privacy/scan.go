@@ -0,0 +1,107 @@
+package privacy
+
+import (
+	"bytes"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/errors"
+	"math/big"
+	"runtime"
+	"sync"
+)
+
+const DefaultScanBatchSize int = 256
+
+// OwnedAnonymousSlot is an AnonymousSlot recognized by the scanning PrivateKey
+type OwnedAnonymousSlot struct {
+	*AnonymousSlot
+	Index int
+	Amount *big.Int
+}
+
+// Owns returns whether the AnonymousBase (rG, rh) is derived from h = sk G
+func (prv *PrivateKey) Owns(base *AnonymousBase) bool {
+	if base.g == nil || base.h == nil || base.g.G1 == nil || base.h.G1 == nil {return false}
+	h := new(crypto.Generator).Mul(base.g, prv.Int)
+	return bytes.Equal(h.Marshal(), base.h.Marshal())
+}
+
+// SolveAnonymous solves the AnonymousValue under the value generator g of the AnonymousBase
+func (prv *PrivateKey) SolveAnonymous(base *AnonymousBase, value *AnonymousValue) (*big.Int, error) {
+	if !value.Solvable() {return nil, errors.NewCannotSolveError()}
+	return prv.SolveBy(value.generator(base.g), value.c, value.d)
+}
+
+// AnonymousScanner finds the AnonymousSlots owned by a PrivateKey
+type AnonymousScanner struct {
+	prv *PrivateKey
+	workers, batchSize int
+}
+
+// NewAnonymousScanner returns an AnonymousScanner, non-positive workers or batchSize use the defaults
+func (prv *PrivateKey) NewAnonymousScanner(workers, batchSize int) *AnonymousScanner {
+	if workers <= 0 {workers = runtime.NumCPU()}
+	if batchSize <= 0 {batchSize = DefaultScanBatchSize}
+	return &AnonymousScanner{prv, workers, batchSize}
+}
+
+// ScanBatch returns the owned slots in order, Amount is nil if the slot is non-solvable
+func (scanner *AnonymousScanner) ScanBatch(slots []*AnonymousSlot) []*OwnedAnonymousSlot {
+	return scanner.scan(slots, 0)
+}
+
+// Scan reads slots in batches and sends the owned ones, the channel is closed when slots is drained
+func (scanner *AnonymousScanner) Scan(slots <-chan *AnonymousSlot) <-chan *OwnedAnonymousSlot {
+	owned := make(chan *OwnedAnonymousSlot, scanner.batchSize)
+	go func() {
+		defer close(owned)
+		offset := 0
+		batch := make([]*AnonymousSlot, 0, scanner.batchSize)
+		flush := func() {
+			for _, slot := range scanner.scan(batch, offset) {
+				owned <- slot
+			}
+			offset += len(batch)
+			batch = batch[:0]
+		}
+		for slot := range slots {
+			batch = append(batch, slot)
+			if len(batch) == scanner.batchSize {flush()}
+		}
+		if len(batch) > 0 {flush()}
+	}()
+	return owned
+}
+
+func (scanner *AnonymousScanner) scan(slots []*AnonymousSlot, offset int) []*OwnedAnonymousSlot {
+	found := make([]*OwnedAnonymousSlot, len(slots))
+
+	var wg sync.WaitGroup
+	next := make(chan int, len(slots))
+	for i := range slots {
+		next <- i
+	}
+	close(next)
+
+	for w := 0; w < scanner.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				slot := slots[i]
+				if slot == nil || slot.AnonymousBase == nil || !scanner.prv.Owns(slot.AnonymousBase) {continue}
+				owned := &OwnedAnonymousSlot{AnonymousSlot: slot, Index: offset + i}
+				if slot.AnonymousValue != nil && slot.AnonymousValue.Solvable() {
+					owned.Amount, _ = scanner.prv.SolveAnonymous(slot.AnonymousBase, slot.AnonymousValue)
+				}
+				found[i] = owned
+			}
+		}()
+	}
+	wg.Wait()
+
+	result := make([]*OwnedAnonymousSlot, 0)
+	for _, owned := range found {
+		if owned != nil {result = append(result, owned)}
+	}
+	return result
+}
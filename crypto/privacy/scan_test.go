@@ -0,0 +1,70 @@
+package privacy
+
+import (
+	"fmt"
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestAnonymousScan(t *testing.T) {
+	prv := NewRandomPrivateKey()
+	other := NewRandomPrivateKey()
+
+	values := []int64{114514, 0, 1919, 810}
+	owners := []*PrivateKey{prv, other, prv, other}
+	stream := make(chan *AnonymousSlot, len(values))
+	for i, v := range values {
+		rl, _ := crypto.RandomZq(1)
+		slot, err := owners[i].GenAnonymousBase().NewAnonymousOutputSlot(big.NewInt(v), rl[0], true, common.NoneContractSlot, nil, nil)
+		errors.Handle(err)
+
+		received, err := new(AnonymousSlot).Init().SetBytes(slot.Bytes())
+		errors.Handle(err)
+		stream <- received
+	}
+	close(stream)
+
+	scanner := prv.NewAnonymousScanner(2, 3)
+	found := 0
+	for owned := range scanner.Scan(stream) {
+		fmt.Printf("Owned slot %d with value %d\n\n", owned.Index, owned.Amount)
+		if owners[owned.Index] != prv || owned.Amount == nil || owned.Amount.Int64() != values[owned.Index] {
+			t.Errorf("slot %d is wrongly recognized", owned.Index)
+		}
+		found++
+	}
+	if found != 2 {
+		t.Errorf("found %d owned slots, want 2", found)
+	}
+}
+
+// benchmarkScan scans n slots of which every other one is owned, so half of them are recognized and solved. Every
+// owned slot has its own value generator, repeated scans of the same slots cost as much as the first one
+func benchmarkScan(b *testing.B, n int) {
+	prv, other := NewRandomPrivateKey(), NewRandomPrivateKey()
+	slots := make([]*AnonymousSlot, n)
+	rl, _ := crypto.RandomZq(n)
+	for i := range slots {
+		owner := other
+		if i % 2 == 0 {owner = prv}
+		// the scanner reads the base and the value only, the proofs are left out
+		base := owner.GenAnonymousBase()
+		slots[i] = &AnonymousSlot{AnonymousBase: base, AnonymousValue: base.SetValue(big.NewInt(int64(i)), rl[i], true)}
+	}
+	scanner := prv.NewAnonymousScanner(0, 0)
+
+	b.ResetTimer()
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		if len(scanner.ScanBatch(slots)) != (n + 1) / 2 {b.Fatal("Anonymous scan missed an owned slot.")}
+	}
+	b.ReportMetric(float64(n * b.N) / time.Since(start).Seconds(), "slots/s")
+}
+
+func BenchmarkScan100(b *testing.B) {benchmarkScan(b, 100)}
+
+func BenchmarkScan1000(b *testing.B) {benchmarkScan(b, 1000)}
@@ -3,6 +3,7 @@ package privacy
 import (
 	"github.com/Acoustical/maskash/common"
 	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/crypto/elgamal"
 	"github.com/Acoustical/maskash/crypto/zkproofs"
 	"github.com/Acoustical/maskash/errors"
 	"math/big"
@@ -21,7 +22,8 @@ func NewSecretInputSlot(outputSlot *SecretSlot) *SecretSlot {
 	return slot
 }
 
-func (base *SecretBase) NewSecretOutputSlot(value, r *big.Int, solvable bool, contractMode uint8,  c ContractSlot) (*SecretSlot, error) {
+// NewSecretOutputSlot commits value with the blinding factor r, the slot carries an AuditTag to auditor unless it is nil
+func (base *SecretBase) NewSecretOutputSlot(value, r *big.Int, solvable bool, contractMode uint8,  c ContractSlot, auditor *elgamal.PublicKey) (*SecretSlot, error) {
 	slot := new(SecretSlot).Init()
 
 	mode := common.Secret | common.OutputSlot | contractMode
@@ -30,6 +32,8 @@ func (base *SecretBase) NewSecretOutputSlot(value, r *big.Int, solvable bool, co
 	slot.SetBase(base)
 	slot.SetValue(value, r)
 	slot.SecretZK, _ = slot.Proof(value, r, slot.SecretValue)
+	err := slot.setAudit(auditor, value, r)
+	if err != nil {return nil, err}
 
 	if contractMode != common.NoneContractSlot {
 		if c == nil {return nil, errors.NewNonContractSlotError()}
@@ -45,6 +49,8 @@ type SecretSlot struct {
 	*SecretValue
 	*SecretZK
 	ContractSlot
+	memo []byte
+	audit *AuditTag
 }
 
 func (slot *SecretSlot) Init() *SecretSlot {
@@ -73,6 +79,7 @@ func (slot *SecretSlot) Bytes() []byte {
 			bytes = make([]byte, common.SecretInputNonSolvableSlotLength)
 			copy(bytes[1+common.SecretBaseLength:1+common.SecretBaseLength+common.SecretNonSolvableValueLength], slot.SecretValue.Bytes())
 		}
+		if slot.SecretBase.spend != nil {copy(bytes[len(bytes)-common.SpendBaseLength:], slot.SecretBase.spend.Bytes())}
 	} else {
 		var contractLength int
 		var contractBytes []byte
@@ -80,24 +87,32 @@ func (slot *SecretSlot) Bytes() []byte {
 			contractBytes = slot.ContractSlot.Bytes()
 			contractLength = len(contractBytes)
 		}
+		memo := extensionBytes(slot.memo, slot.SecretValue.asset, slot.audit, slot.SecretBase.spend)
 		if slot.mode & common.Solvability == common.Solvable {
-			bytes = make([]byte, common.SecretOutputSolvableSlotLength+contractLength)
+			zkEnd := common.SecretOutputSolvableSlotLength - common.ExtensionFlagLength
+			bytes = make([]byte, zkEnd+len(memo)+contractLength)
 			copy(bytes[1+common.SecretBaseLength:1+common.SecretBaseLength+common.SecretSolvableValueLength], slot.SecretValue.Bytes())
-			copy(bytes[common.SecretOutputSolvableSlotLength-common.SecretZKsLength:common.SecretOutputSolvableSlotLength], slot.SecretZK.Bytes())
+			copy(bytes[zkEnd-common.SecretZKsLength:zkEnd], slot.SecretZK.Bytes())
+			copy(bytes[zkEnd:zkEnd+len(memo)], memo)
 			if contractLength > 0 {
-				copy(bytes[common.SecretOutputSolvableSlotLength:], contractBytes)
+				copy(bytes[zkEnd+len(memo):], contractBytes)
 			}
 		} else {
-			bytes = make([]byte, common.SecretOutputNonSolvableSlotLength+contractLength)
+			zkEnd := common.SecretOutputNonSolvableSlotLength - common.ExtensionFlagLength
+			bytes = make([]byte, zkEnd+len(memo)+contractLength)
 			copy(bytes[1+common.SecretBaseLength:1+common.SecretBaseLength+common.SecretNonSolvableValueLength], slot.SecretValue.Bytes())
-			copy(bytes[common.SecretOutputNonSolvableSlotLength - common.SecretZKsLength:common.SecretOutputNonSolvableSlotLength], slot.SecretZK.Bytes())
+			copy(bytes[zkEnd-common.SecretZKsLength:zkEnd], slot.SecretZK.Bytes())
+			copy(bytes[zkEnd:zkEnd+len(memo)], memo)
 			if contractLength > 0 {
-				copy(bytes[common.SecretOutputNonSolvableSlotLength:], contractBytes)
+				copy(bytes[zkEnd+len(memo):], contractBytes)
 			}
 		}
 	}
 	bytes[0] = slot.mode
 	copy(bytes[1:1+common.SecretBaseLength], slot.SecretBase.Bytes())
+	if slot.mode & common.TxSlotKind == common.InputSlot && slot.SecretValue.asset != nil {
+		bytes = append(bytes, slot.SecretValue.asset.Bytes()...)
+	}
 	return bytes
 }
 
@@ -124,9 +139,25 @@ func (slot *SecretSlot) SetBytes(b []byte) (*SecretSlot, error) {
 	if mode & common.TxSlotKind == common.OutputSlot {
 		start = end
 		end = start + common.SecretZKsLength
+		if bLen < end {return nil, errors.NewWrongInputLength(bLen)}
 		slot.SecretZK = new(SecretZK)
 		err = slot.SecretZK.SetBytes(b[start:end])
 		if err != nil {return nil, err}
+
+		slot.memo, slot.SecretValue.asset, slot.audit, slot.SecretBase.spend, _, err = setExtensionBytes(b[end:], common.MemoCipherLength)
+		if err != nil {return nil, err}
+		if slot.SecretValue.asset != nil && !slot.SecretValue.Solvable() {return nil, errors.NewCannotSolveError()}
+	} else {
+		start = end
+		end = start + common.SpendBaseLength
+		if bLen < end {return nil, errors.NewWrongInputLength(bLen)}
+		slot.SecretBase.spend, err = setSpendBytes(b[start:end])
+		if err != nil {return nil, err}
+		if bLen == end {return slot, nil}
+		slot.SecretValue.asset = new(AssetTag)
+		assetLength, err := slot.SecretValue.asset.SetBytes(b[end:])
+		if err != nil {return nil, err}
+		if end + assetLength != bLen {return nil, errors.NewWrongInputLength(bLen)}
 	}
 
 	return slot, nil
@@ -147,7 +178,27 @@ func (slot *SecretSlot) SetValue(v, r *big.Int) {
 
 func (slot *SecretSlot) SetSelfValue(value *SecretValue) {slot.SecretValue = value}
 
-type SecretBase struct {h *crypto.Generator}
+// SetMemo encrypts memo to the owner of the output slot with the blinding factor r of the value
+func (slot *SecretSlot) SetMemo(memo []byte, r *big.Int) error {
+	if slot.mode & common.TxSlotKind != common.OutputSlot {return errors.NewWrongSlotModeError(common.OutputSlot, slot.mode & common.TxSlotKind)}
+	if !slot.SecretValue.Solvable() {return errors.NewCannotSolveError()}
+	shared := new(crypto.Commitment).SetIntByGenerator(slot.SecretBase.h, r)
+	cipherText, err := sealMemo(shared, slot.SecretBase, slot.SecretValue, memo)
+	if err != nil {return err}
+	slot.memo = cipherText
+	return nil
+}
+
+// Memo decrypts the memo of the slot, returns nil if the slot has no memo
+func (slot *SecretSlot) Memo(prv *PrivateKey) ([]byte, error) {
+	if slot.memo == nil {return nil, nil}
+	if !slot.SecretValue.Solvable() {return nil, errors.NewCannotSolveError()}
+	shared := new(crypto.Commitment).Mul(slot.SecretValue.d, prv.Int)
+	return openMemo(shared, slot.SecretBase, slot.SecretValue, slot.memo)
+}
+
+// SecretBase is the ElGamal public key h = sk G, spend = x G authorizes the spends, a base without it can not be spent
+type SecretBase struct {h, spend *crypto.Generator}
 
 func (base *SecretBase) BaseMode() uint8 {return common.Secret}
 
@@ -160,28 +211,37 @@ func (base *SecretBase) SetBytes(b []byte) error {
 	return nil
 }
 
+// PublicKey returns the ElGamal public key of the base
+func (base *SecretBase) PublicKey() *elgamal.PublicKey {return elgamal.NewPublicKey(new(crypto.Generator).Init(big.NewInt(1)), base.h)}
+
 func (base *SecretBase) SetValue(v, r *big.Int, solvable bool) *SecretValue {
-	g := new(crypto.Generator).Init(big.NewInt(1))
-	c := new(crypto.Commitment).FixedSet(g, base.h, v, r)
+	ct := base.PublicKey().EncryptWith(v, r)
 	if solvable {
-		d := new(crypto.Commitment).SetIntByGenerator(g, r)
-		return &SecretValue{c,d}
+		return &SecretValue{ct.C(), ct.D(), nil}
 	} else {
-		return &SecretValue{c,nil}
+		return &SecretValue{ct.C(), nil, nil}
 	}
 }
 
+// SetAssetValue commits v against the asset tag, the value is always solvable so the owner can open the tag
+func (base *SecretBase) SetAssetValue(v, r *big.Int, tag *AssetTag) *SecretValue {
+	ct := base.PublicKey().EncryptBy(tag.t, v, r)
+	return &SecretValue{ct.C(), ct.D(), tag}
+}
+
 func (base *SecretBase) Proof(v, r *big.Int, value *SecretValue) (*SecretZK, error) {
 	if !value.Solvable() {return nil, errors.NewCannotSolveError()}
 	g := new(crypto.Generator).Init(big.NewInt(1))
 
 	formatZK := new(zkproofs.FormatZK).Init()
+	gv := value.generator(g)
 	formatZK.SetPrivate(v, r, g, base.h, value.c, value.d)
+	formatZK.SetValueGenerator(gv)
 	err := formatZK.Proof()
 	if err != nil{return nil, err}
 
 	rangeZK := new(zkproofs.RangeZK).Init()
-	_, err = rangeZK.SetPrivate(value.c, g, base.h, zkproofs.RangeG, zkproofs.RangeH, uint8(common.RangeProofShortBits), v, r)
+	_, err = rangeZK.SetPrivate(value.c, gv, base.h, zkproofs.RangeG, zkproofs.RangeH, uint8(common.RangeProofShortBits), v, r)
 	if err != nil {return nil, err}
 	err = rangeZK.Proof()
 	if err != nil {return nil, err}
@@ -194,14 +254,22 @@ func (base *SecretBase) Proof(v, r *big.Int, value *SecretValue) (*SecretZK, err
 func (base *SecretBase) Check(value *SecretValue, zk *SecretZK) bool {
 	g := new(crypto.Generator).Init(big.NewInt(1))
 
+	if zk == nil {return false}
+	gv := value.generator(g)
 	zk.formatZK.SetPublic(g, base.h, value.c, value.d)
-	_, err := zk.rangeZK.SetPublic(value.c, g, base.h, zkproofs.RangeG, zkproofs.RangeH, uint8(common.RangeProofShortBits))
+	zk.formatZK.SetValueGenerator(gv)
+	_, err := zk.rangeZK.SetPublic(value.c, gv, base.h, zkproofs.RangeG, zkproofs.RangeH, uint8(common.RangeProofShortBits))
 	if err != nil {return false}
+	if value.asset != nil && !value.asset.check(value.c) {return false}
 
 	return zk.formatZK.Check() && zk.rangeZK.Check()
 }
 
-type SecretValue struct {c, d *crypto.Commitment}
+// SecretValue commits against the value generator, which is the blinded asset tag if asset is set
+type SecretValue struct {
+	c, d *crypto.Commitment
+	asset *AssetTag
+}
 
 func (value *SecretValue) ValueMode() uint8 {return common.Secret}
 
@@ -209,33 +277,30 @@ func (value *SecretValue) Solvable() bool {return value.d != nil}
 
 func (value *SecretValue) Solve(prv *PrivateKey) (*big.Int, error) {
 	if !value.Solvable() {return nil, errors.NewCannotSolveError()}
+	if value.asset != nil {return prv.SolveBy(value.asset.t, value.c, value.d)}
 	return prv.Solve(value.c, value.d)
 }
 
-func (value *SecretValue) Bytes() []byte {
-	var bytes []byte
-	if value.Solvable() {
-		pointBytes := common.Bn256PointBits / common.ByteBits
-		bytes = make([]byte, common.SecretSolvableValueLength)
-		copy(bytes[:pointBytes], value.c.Bytes())
-		copy(bytes[pointBytes:], value.d.Bytes())
-	} else {
-		bytes = make([]byte, common.SecretNonSolvableValueLength)
-		copy(bytes, value.c.Bytes())
-	}
-	return bytes
+// Asset returns the asset tag of the value, nil for the native asset committed against G
+func (value *SecretValue) Asset() *AssetTag {return value.asset}
+
+// generator returns the value generator, g if the value has no asset tag
+func (value *SecretValue) generator(g *crypto.Generator) *crypto.Generator {
+	if value.asset != nil {return value.asset.t}
+	return g
 }
 
+// Ciphertext returns the value as an ElGamal ciphertext
+func (value *SecretValue) Ciphertext() *elgamal.Ciphertext {return elgamal.NewCiphertext(value.c, value.d)}
+
+func (value *SecretValue) Bytes() []byte {return value.Ciphertext().Bytes()}
+
 func (value *SecretValue) SetBytes(b []byte) (*SecretValue, error) {
 	bLen := len(b)
 	if bLen != common.SecretSolvableValueLength && bLen != common.SecretNonSolvableValueLength {return nil, errors.NewWrongInputLength(bLen)}
-	if bLen == common.SecretSolvableValueLength {
-		pointBytes := common.Bn256PointBits / common.ByteBits
-		value.c = new(crypto.Commitment).SetBytes(b[:pointBytes])
-		value.d = new(crypto.Commitment).SetBytes(b[pointBytes:])
-	} else {
-		value.c = new(crypto.Commitment).SetBytes(b)
-	}
+	ct, err := new(elgamal.Ciphertext).SetBytes(b)
+	if err != nil {return nil, err}
+	value.c, value.d = ct.C(), ct.D()
 	return value, nil
 }
 
@@ -0,0 +1,181 @@
+package privacy
+
+import (
+	"encoding/binary"
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/crypto/elgamal"
+	"github.com/Acoustical/maskash/crypto/zkproofs"
+	"github.com/Acoustical/maskash/errors"
+	"math/big"
+)
+
+// Shielding moves the public value of a PlaintextSlot input into a SecretSlot output
+type Shielding struct {
+	Input *PlaintextSlot
+	Output *SecretSlot
+	zk *zkproofs.EqualityZK
+}
+
+// NewShielding spends value from the address of prv into a solvable SecretSlot of base audited by auditor
+func (prv *PrivateKey) NewShielding(nonce, value *big.Int, base *SecretBase, auditor *elgamal.PublicKey) (*Shielding, error) {
+	if value.BitLen() > common.RangeProofShortBits {return nil, errors.NewOverRangeError(uint8(common.RangeProofShortBits), value)}
+	rl, err := crypto.RandomZq(1)
+	if err != nil {return nil, err}
+	r := rl[0]
+
+	shielding := new(Shielding)
+	shielding.Input = prv.NewPlaintextInputSlot(nonce, value)
+	shielding.Output, err = base.NewSecretOutputSlot(value, r, true, common.NoneContractSlot, nil, auditor)
+	if err != nil {return nil, err}
+
+	// c - vG = rh and d = rG
+	g1, y1, g2, y2 := shielding.statement()
+	shielding.zk = new(zkproofs.EqualityZK).Init()
+	shielding.zk.SetPrivate(r, g1, y1, g2, y2, shielding.context())
+	err = shielding.zk.Proof()
+	if err != nil {return nil, err}
+	return shielding, nil
+}
+
+func (shielding *Shielding) statement() (*crypto.Generator, *crypto.Commitment, *crypto.Generator, *crypto.Commitment) {
+	output := shielding.Output
+	g := new(crypto.Generator).Init(big.NewInt(1))
+	y1 := new(crypto.Commitment).SetInt(shielding.Input.PlaintextValue.v).Neg().AddBy(output.SecretValue.c)
+	return output.SecretBase.h, y1, g, output.SecretValue.d
+}
+
+// context binds the proof to both slots of the shielding
+func (shielding *Shielding) context() *big.Int {return crypto.Hash_(shielding.Input, shielding.Output).BigInt()}
+
+// Check verifies both slots and that the SecretSlot commits to the public value of the PlaintextSlot
+func (shielding *Shielding) Check() bool {
+	input, output := shielding.Input, shielding.Output
+	if input.SlotMode() & (common.PrivacyMode | common.TxSlotKind) != common.Plaintext | common.InputSlot {return false}
+	if output.SlotMode() & (common.PrivacyMode | common.TxSlotKind | common.Solvability) != common.Secret | common.OutputSlot | common.Solvable {return false}
+	if !input.CheckZKs() || !output.CheckZKs() {return false}
+
+	g1, y1, g2, y2 := shielding.statement()
+	shielding.zk.SetPublic(g1, y1, g2, y2, shielding.context())
+	return shielding.zk.Check()
+}
+
+func (shielding *Shielding) Bytes() []byte {
+	return concatBytes(shielding.Input.Bytes(), shielding.Output.Bytes(), shielding.zk.Bytes())
+}
+
+func (shielding *Shielding) SetBytes(b []byte) (*Shielding, error) {
+	bLen := len(b)
+	if bLen < common.PlaintextInputSlotLength + common.EqualityProofLength {return nil, errors.NewWrongInputLength(bLen)}
+	zkStart := bLen - common.EqualityProofLength
+
+	var err error
+	shielding.Input, err = new(PlaintextSlot).SetBytes(b[:common.PlaintextInputSlotLength])
+	if err != nil {return nil, err}
+	shielding.Output, err = new(SecretSlot).Init().SetBytes(b[common.PlaintextInputSlotLength:zkStart])
+	if err != nil {return nil, err}
+	shielding.zk = new(zkproofs.EqualityZK).Init()
+	err = shielding.zk.SetBytes(b[zkStart:])
+	if err != nil {return nil, err}
+	return shielding, nil
+}
+
+// Unshielding reveals the value of a SecretSlot input to a PlaintextSlot output on the chain ChainID, Spend
+// authorizes the input with its spend key. The caller checks the input is an unspent output, its OutputHash
+// binds the spend base
+type Unshielding struct {
+	ChainID uint64
+	Input *SecretSlot
+	Output *PlaintextSlot
+	zk *zkproofs.EqualityZK
+	Spend *SpendProof
+}
+
+// NewUnshielding spends the solvable SecretSlot output solved by prv to base on the chain chainID, spend is the spend
+// key of the output, prv itself for a single key
+func (prv *PrivateKey) NewUnshielding(outputSlot *SecretSlot, base *PlaintextBase, chainID uint64, spend *PrivateKey) (*Unshielding, error) {
+	value, err := outputSlot.Solve(prv)
+	if err != nil {return nil, err}
+
+	unshielding := &Unshielding{ChainID: chainID}
+	unshielding.Input = NewSecretInputSlot(outputSlot)
+	unshielding.Output, err = base.NewPlaintextOutputSlot(value, common.NoneContractSlot, nil)
+	if err != nil {return nil, err}
+
+	// h = sk G and c - vG = sk d
+	g1, y1, g2, y2 := unshielding.statement()
+	unshielding.zk = new(zkproofs.EqualityZK).Init()
+	unshielding.zk.SetPrivate(prv.Int, g1, y1, g2, y2, unshielding.context())
+	err = unshielding.zk.Proof()
+	if err != nil {return nil, err}
+	unshielding.Spend, err = NewSpendProof(spend, unshielding.Input, unshielding.context())
+	if err != nil {return nil, err}
+	return unshielding, nil
+}
+
+func (unshielding *Unshielding) statement() (*crypto.Generator, *crypto.Commitment, *crypto.Generator, *crypto.Commitment) {
+	input := unshielding.Input
+	g := new(crypto.Generator).Init(big.NewInt(1))
+	h := &crypto.Commitment{G1: input.SecretBase.h.G1}
+	d := &crypto.Generator{G1: input.SecretValue.d.G1}
+	y2 := new(crypto.Commitment).SetInt(unshielding.Output.PlaintextValue.v).Neg().AddBy(input.SecretValue.c)
+	return g, h, d, y2
+}
+
+// context binds the proofs to the chain and both slots of the unshielding
+func (unshielding *Unshielding) context() *big.Int {
+	return crypto.Hash_(unshieldingDomain, new(big.Int).SetUint64(unshielding.ChainID), unshielding.Input, unshielding.Output).BigInt()
+}
+
+var unshieldingDomain = crypto.HashBytes("maskash unshielding")
+
+// Check verifies that the SecretSlot commits to the public value of the PlaintextSlot and its spend is authorized
+func (unshielding *Unshielding) Check() bool {
+	input, output := unshielding.Input, unshielding.Output
+	if input.SlotMode() & (common.PrivacyMode | common.TxSlotKind | common.Solvability) != common.Secret | common.InputSlot | common.Solvable {return false}
+	if output.SlotMode() & (common.PrivacyMode | common.TxSlotKind) != common.Plaintext | common.OutputSlot {return false}
+
+	g1, y1, g2, y2 := unshielding.statement()
+	unshielding.zk.SetPublic(g1, y1, g2, y2, unshielding.context())
+	return unshielding.zk.Check() && unshielding.Spend.Check(input, unshielding.context())
+}
+
+// Bytes returns chain ID (8) | input | output | proof | spend proof
+func (unshielding *Unshielding) Bytes() []byte {
+	chainID := make([]byte, common.ChainIDLength)
+	binary.BigEndian.PutUint64(chainID, unshielding.ChainID)
+	return concatBytes(chainID, unshielding.Input.Bytes(), unshielding.Output.Bytes(), unshielding.zk.Bytes(), unshielding.Spend.Bytes())
+}
+
+func (unshielding *Unshielding) SetBytes(b []byte) (*Unshielding, error) {
+	bLen := len(b)
+	inputEnd := common.ChainIDLength + common.SecretInputSolvableSlotLength
+	if bLen < inputEnd + common.EqualityProofLength + common.SpendProofLength {return nil, errors.NewWrongInputLength(bLen)}
+	spendStart := bLen - common.SpendProofLength
+	zkStart := spendStart - common.EqualityProofLength
+
+	var err error
+	unshielding.ChainID = binary.BigEndian.Uint64(b[:common.ChainIDLength])
+	unshielding.Input, err = new(SecretSlot).Init().SetBytes(b[common.ChainIDLength:inputEnd])
+	if err != nil {return nil, err}
+	unshielding.Output, err = new(PlaintextSlot).SetBytes(b[inputEnd:zkStart])
+	if err != nil {return nil, err}
+	unshielding.zk = new(zkproofs.EqualityZK).Init()
+	err = unshielding.zk.SetBytes(b[zkStart:spendStart])
+	if err != nil {return nil, err}
+	unshielding.Spend, err = new(SpendProof).SetBytes(b[spendStart:])
+	if err != nil {return nil, err}
+	return unshielding, nil
+}
+
+func concatBytes(parts ...[]byte) []byte {
+	total := 0
+	for _, part := range parts {
+		total += len(part)
+	}
+	bytes := make([]byte, 0, total)
+	for _, part := range parts {
+		bytes = append(bytes, part...)
+	}
+	return bytes
+}
@@ -0,0 +1,65 @@
+package privacy
+
+import (
+	"fmt"
+	"github.com/Acoustical/maskash/errors"
+	"math/big"
+	"testing"
+)
+
+func TestShield(t *testing.T) {
+	prv := NewRandomPrivateKey()
+	receiver := NewRandomPrivateKey()
+
+	shielding, err := prv.NewShielding(big.NewInt(1), big.NewInt(114514), receiver.GenSecretBase(), nil)
+	errors.Handle(err)
+	shieldingBytes := shielding.Bytes()
+	fmt.Printf("Shielding\n%x\n\n", shieldingBytes)
+
+	shielding0, err := new(Shielding).SetBytes(shieldingBytes)
+	errors.Handle(err)
+	if !shielding0.Check() {
+		t.Errorf("Shielding check failed")
+	}
+
+	shielding0.Input.PlaintextValue.v = big.NewInt(114515)
+	if shielding0.Check() {
+		t.Errorf("Shielding accepted with another public value")
+	}
+
+	unshielding, err := receiver.NewUnshielding(shielding.Output, prv.GenPlaintextBase(), 1, receiver)
+	errors.Handle(err)
+	unshieldingBytes := unshielding.Bytes()
+	fmt.Printf("Unshielding\n%x\n\n", unshieldingBytes)
+
+	unshielding0, err := new(Unshielding).SetBytes(unshieldingBytes)
+	errors.Handle(err)
+	if !unshielding0.Check() {
+		t.Errorf("Unshielding check failed")
+	}
+	if unshielding0.Output.PlaintextValue.v.Cmp(big.NewInt(114514)) != 0 {
+		t.Errorf("Unshielding revealed a wrong value")
+	}
+
+	unshielding0.Output.PlaintextValue.v = big.NewInt(114514)
+	unshielding0.ChainID = 2
+	if unshielding0.Check() {
+		t.Errorf("Unshielding accepted on another chain")
+	}
+
+	unshielding0.Output.PlaintextValue.v = big.NewInt(114515)
+	unshielding0.ChainID = 1
+	if unshielding0.Check() {
+		t.Errorf("Unshielding accepted with another public value")
+	}
+
+	// the view key solves the output but only the spend key unshields it
+	owner := NewSeparateAccountKey(NewRandomPrivateKey(), NewRandomPrivateKey())
+	shielding, err = prv.NewShielding(big.NewInt(2), big.NewInt(1919), owner.GenSecretBase(), nil)
+	errors.Handle(err)
+	if _, err = owner.ViewKey().Key().NewUnshielding(shielding.Output, prv.GenPlaintextBase(), 1, owner.ViewKey().Key()); err == nil {
+		t.Errorf("Unshielding made without the spend key")
+	}
+	_, err = owner.ViewKey().Key().NewUnshielding(shielding.Output, prv.GenPlaintextBase(), 1, owner.SpendKey())
+	errors.Handle(err)
+}
@@ -0,0 +1,128 @@
+package privacy
+
+import (
+	"encoding/binary"
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/crypto/zkproofs"
+	"github.com/Acoustical/maskash/errors"
+)
+
+// AnonymousShuffle permutes solvable AnonymousValues with their bases, re-encrypts every value by its own secret s_j and
+// multiplies every (g, h, spend, c, d) by one secret k, each owner still owns one of the outputs with the same amount while the
+// link to the inputs is hidden even from the payers who know the randomness of the inputs
+type AnonymousShuffle struct {
+	InputBases, OutputBases []*AnonymousBase
+	Inputs, Outputs []*AnonymousValue
+	zk *zkproofs.ShuffleZK
+}
+
+// NewAnonymousShuffle shuffles the values of bases and proves the outputs are a permutation of the inputs
+func NewAnonymousShuffle(bases []*AnonymousBase, values []*AnonymousValue) (*AnonymousShuffle, error) {
+	n := len(bases)
+	if n != len(values) {return nil, errors.NewLengthNotMatchError(n, len(values))}
+	if n == 0 || n > common.MaxShuffleSize {return nil, errors.NewWrongInputLength(n)}
+	for _, value := range values {
+		if !value.Solvable() {return nil, errors.NewCannotSolveError()}
+		if value.asset != nil {return nil, errors.NewAssetTaggedError()}
+	}
+	for _, base := range bases {
+		if base.spend == nil {return nil, errors.NewUnauthorizedSpendError()}
+	}
+
+	shuffle := &AnonymousShuffle{InputBases: bases, Inputs: values}
+	x := shuffle.inputRows()
+	y, pi, k, s, err := zkproofs.Shuffle(x)
+	if err != nil {return nil, err}
+	shuffle.OutputBases, shuffle.Outputs = make([]*AnonymousBase, n), make([]*AnonymousValue, n)
+	for i, row := range y {
+		shuffle.OutputBases[i] = &AnonymousBase{&crypto.Generator{G1: row[0].G1}, &crypto.Generator{G1: row[1].G1}, &crypto.Generator{G1: row[2].G1}}
+		shuffle.Outputs[i] = &AnonymousValue{row[3], row[4], nil}
+	}
+
+	shuffle.zk = new(zkproofs.ShuffleZK).Init()
+	_, err = shuffle.zk.SetPrivate(pi, k, s, x, y, nil)
+	if err != nil {return nil, err}
+	err = shuffle.zk.Proof()
+	if err != nil {return nil, err}
+	return shuffle, nil
+}
+
+func anonymousRows(bases []*AnonymousBase, values []*AnonymousValue) [][]*crypto.Commitment {
+	rows := make([][]*crypto.Commitment, len(bases))
+	for i, base := range bases {
+		if base.spend == nil {return nil}
+		rows[i] = []*crypto.Commitment{{G1: base.g.G1}, {G1: base.h.G1}, {G1: base.spend.G1}, values[i].c, values[i].d}
+	}
+	return rows
+}
+
+func (shuffle *AnonymousShuffle) inputRows() [][]*crypto.Commitment {return anonymousRows(shuffle.InputBases, shuffle.Inputs)}
+
+func (shuffle *AnonymousShuffle) outputRows() [][]*crypto.Commitment {return anonymousRows(shuffle.OutputBases, shuffle.Outputs)}
+
+// Check verifies the outputs are the inputs permuted, re-encrypted and multiplied by one scalar
+func (shuffle *AnonymousShuffle) Check() bool {
+	if shuffle.zk == nil || len(shuffle.InputBases) != len(shuffle.Inputs) || len(shuffle.OutputBases) != len(shuffle.Outputs) {return false}
+	for i := range shuffle.Inputs {
+		if !shuffle.Inputs[i].Solvable() {return false}
+	}
+	for i := range shuffle.Outputs {
+		if !shuffle.Outputs[i].Solvable() {return false}
+	}
+	x, y := shuffle.inputRows(), shuffle.outputRows()
+	if x == nil || y == nil {return false}
+	_, err := shuffle.zk.SetPublic(x, y, nil)
+	if err != nil {return false}
+	return shuffle.zk.Check()
+}
+
+// Bytes returns n | n input bases, spend bases and values | n output bases, spend bases and values | proof
+func (shuffle *AnonymousShuffle) Bytes() []byte {
+	count := make([]byte, common.ShuffleCountLength)
+	binary.BigEndian.PutUint32(count, uint32(len(shuffle.Inputs)))
+	parts := [][]byte{count}
+	for i := range shuffle.Inputs {
+		parts = append(parts, shuffle.InputBases[i].Bytes(), shuffle.InputBases[i].spend.Bytes(), shuffle.Inputs[i].Bytes())
+	}
+	for i := range shuffle.Outputs {
+		parts = append(parts, shuffle.OutputBases[i].Bytes(), shuffle.OutputBases[i].spend.Bytes(), shuffle.Outputs[i].Bytes())
+	}
+	return concatBytes(append(parts, shuffle.zk.Bytes())...)
+}
+
+func (shuffle *AnonymousShuffle) SetBytes(b []byte) (*AnonymousShuffle, error) {
+	bLen := len(b)
+	if bLen < common.ShuffleCountLength {return nil, errors.NewWrongInputLength(bLen)}
+	n := int(binary.BigEndian.Uint32(b[:common.ShuffleCountLength]))
+	rowLength := common.AnonymousBaseLength + common.SpendBaseLength + common.AnonymousSolvableValueLength
+	if n == 0 || n > common.MaxShuffleSize || bLen < common.ShuffleCountLength + 2 * n * rowLength {return nil, errors.NewWrongInputLength(bLen)}
+
+	start := common.ShuffleCountLength
+	parse := func() ([]*AnonymousBase, []*AnonymousValue, error) {
+		bases, values := make([]*AnonymousBase, n), make([]*AnonymousValue, n)
+		for i := 0; i < n; i++ {
+			bases[i] = new(AnonymousBase)
+			err := bases[i].SetBytes(b[start:start+common.AnonymousBaseLength])
+			if err != nil {return nil, nil, err}
+			start += common.AnonymousBaseLength
+			bases[i].spend, err = setSpendBytes(b[start:start+common.SpendBaseLength])
+			if err != nil {return nil, nil, err}
+			start += common.SpendBaseLength
+			values[i], err = new(AnonymousValue).SetBytes(b[start:start+common.AnonymousSolvableValueLength])
+			if err != nil {return nil, nil, err}
+			start += common.AnonymousSolvableValueLength
+		}
+		return bases, values, nil
+	}
+	var err error
+	shuffle.InputBases, shuffle.Inputs, err = parse()
+	if err != nil {return nil, err}
+	shuffle.OutputBases, shuffle.Outputs, err = parse()
+	if err != nil {return nil, err}
+
+	shuffle.zk = new(zkproofs.ShuffleZK).Init()
+	err = shuffle.zk.SetBytes(b[start:])
+	if err != nil {return nil, err}
+	return shuffle, nil
+}
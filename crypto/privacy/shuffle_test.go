@@ -0,0 +1,58 @@
+package privacy
+
+import (
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/errors"
+	"math/big"
+	"testing"
+)
+
+func TestAnonymousShuffle(t *testing.T) {
+	n := 6
+	keys := make([]*PrivateKey, n)
+	bases := make([]*AnonymousBase, n)
+	values := make([]*AnonymousValue, n)
+	rl, _ := crypto.RandomZq(n)
+	for i := range keys {
+		keys[i] = NewRandomPrivateKey()
+		bases[i] = keys[i].GenAnonymousBase()
+		slot, err := bases[i].NewAnonymousOutputSlot(big.NewInt(int64(100+i)), rl[i], true, common.NoneContractSlot, nil, nil)
+		errors.Handle(err)
+		values[i] = slot.AnonymousValue
+	}
+
+	shuffle, err := NewAnonymousShuffle(bases, values)
+	errors.Handle(err)
+	shuffle1, err := new(AnonymousShuffle).SetBytes(shuffle.Bytes())
+	errors.Handle(err)
+	if !shuffle1.Check() {
+		t.Errorf("anonymous shuffle check failed")
+	}
+
+	for i, key := range keys {
+		found := false
+		for j, base := range shuffle1.OutputBases {
+			if !key.Owns(base) {continue}
+			v, err := key.SolveAnonymous(base, shuffle1.Outputs[j])
+			found = err == nil && v.Int64() == int64(100+i)
+		}
+		if !found {
+			t.Errorf("output of owner %d lost", i)
+		}
+	}
+
+	// the payer of an input knows its randomness, which must not point at its output
+	for i := range keys {
+		for j, base := range shuffle1.OutputBases {
+			if new(crypto.Commitment).SetIntByGenerator(base.g, rl[i]).Cmp(shuffle1.Outputs[j].d) {
+				t.Errorf("output %d linked to input %d by its randomness", j, i)
+			}
+		}
+	}
+
+	shuffle1.Outputs[0], shuffle1.Outputs[1] = shuffle1.Outputs[1], shuffle1.Outputs[0]
+	if shuffle1.Check() {
+		t.Errorf("anonymous shuffle accepted with swapped values")
+	}
+}
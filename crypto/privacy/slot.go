@@ -1,7 +1,9 @@
 package privacy
 
 import (
+	"github.com/Acoustical/maskash/common"
 	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/errors"
 	"math/big"
 )
 
@@ -29,4 +31,24 @@ type Value interface {
 type ZKs interface {
 	ZKMode() uint8
 	crypto.HashVariable
-}
\ No newline at end of file
+}
+// SetSlotBytes decodes a Plaintext, Secret or Anonymous slot by its mode byte
+func SetSlotBytes(b []byte) (Slot, error) {
+	if len(b) == 0 {return nil, errors.NewWrongInputLength(0)}
+	switch b[0] & common.PrivacyMode {
+	case common.Plaintext:
+		slot, err := new(PlaintextSlot).Init().SetBytes(b)
+		if err != nil {return nil, err}
+		return slot, nil
+	case common.Secret:
+		slot, err := new(SecretSlot).Init().SetBytes(b)
+		if err != nil {return nil, err}
+		return slot, nil
+	case common.Anonymous:
+		slot, err := new(AnonymousSlot).Init().SetBytes(b)
+		if err != nil {return nil, err}
+		return slot, nil
+	default:
+		return nil, errors.NewWrongSlotModeError(common.Secret, b[0] & common.PrivacyMode)
+	}
+}
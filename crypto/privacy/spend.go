@@ -0,0 +1,85 @@
+package privacy
+
+import (
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/crypto/zkproofs"
+	"github.com/Acoustical/maskash/errors"
+	"math/big"
+)
+
+// SpendProof proves the knowledge of x with spend = x g for the spend base of a Secret or Anonymous slot, a slot
+// without a spend base can not be spent. The proof is bound to the slot and the message e, so it authorizes
+// one transaction only
+type SpendProof struct {
+	zk *zkproofs.EqualityZK
+}
+
+// NewSpendProof proves prv is the spend key of the slot for the message e
+func NewSpendProof(prv *PrivateKey, slot Slot, e *big.Int) (*SpendProof, error) {
+	g, spend, err := spendParts(slot.Base())
+	if err != nil {return nil, err}
+	y := &crypto.Commitment{G1: spend.G1}
+	if !new(crypto.Commitment).SetIntByGenerator(g, prv.Int).Cmp(y) {return nil, errors.NewUnauthorizedSpendError()}
+
+	proof := &SpendProof{new(zkproofs.EqualityZK).Init()}
+	proof.zk.SetPrivate(prv.Int, g, y, g, y, spendContext(slot, spend, e))
+	err = proof.zk.Proof()
+	if err != nil {return nil, err}
+	return proof, nil
+}
+
+// Check checks the proof against the slot and the message e with public data only
+func (proof *SpendProof) Check(slot Slot, e *big.Int) bool {
+	if proof == nil || proof.zk == nil {return false}
+	g, spend, err := spendParts(slot.Base())
+	if err != nil {return false}
+	y := &crypto.Commitment{G1: spend.G1}
+	proof.zk.SetPublic(g, y, g, y, spendContext(slot, spend, e))
+	return proof.zk.Check()
+}
+
+// spendParts returns the generator g of a Secret or Anonymous base and its spend base, the view base h is never
+// taken for a missing spend base
+func spendParts(base Base) (*crypto.Generator, *crypto.Generator, error) {
+	switch b := base.(type) {
+	case *SecretBase:
+		if b.spend == nil {return nil, nil, errors.NewUnauthorizedSpendError()}
+		return new(crypto.Generator).Init(big.NewInt(1)), b.spend, nil
+	case *AnonymousBase:
+		if b.spend == nil {return nil, nil, errors.NewUnauthorizedSpendError()}
+		return b.g, b.spend, nil
+	default:
+		return nil, nil, errors.NewWrongSlotModeError(common.Secret, base.BaseMode())
+	}
+}
+
+// SpendBase returns the spend base of a Secret or Anonymous base, nil if it has none
+func SpendBase(base Base) *crypto.Generator {
+	_, spend, err := spendParts(base)
+	if err != nil {return nil}
+	return spend
+}
+
+func spendContext(slot Slot, spend *crypto.Generator, e *big.Int) *big.Int {
+	return crypto.Hash_(slot.Base(), spend, slot.Value(), e).BigInt()
+}
+
+// setSpendBytes parses a spend base
+func setSpendBytes(b []byte) (*crypto.Generator, error) {
+	point, err := crypto.SetBytes(b)
+	if err != nil {return nil, err}
+	if point == nil {return nil, errors.NewInvalidPointError()}
+	return &crypto.Generator{G1: point}, nil
+}
+
+func (proof *SpendProof) Bytes() []byte {return proof.zk.Bytes()}
+
+func (proof *SpendProof) SetBytes(b []byte) (*SpendProof, error) {
+	bLen := len(b)
+	if bLen != common.SpendProofLength {return nil, errors.NewWrongInputLength(bLen)}
+	proof.zk = new(zkproofs.EqualityZK).Init()
+	err := proof.zk.SetBytes(b)
+	if err != nil {return nil, err}
+	return proof, nil
+}
@@ -0,0 +1,161 @@
+package zkproofs
+
+import (
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/errors"
+	"golang.org/x/crypto/bn256"
+	"math/big"
+)
+
+// AssetSetZK proves the blinded tag t = a_k + s j for one of the asset generators a_k without revealing k
+type AssetSetZK struct {
+	*AssetSetProof
+	*AssetSetPrivate
+}
+
+func (zk *AssetSetZK) Init() *AssetSetZK {
+	zk.AssetSetProof = new(AssetSetProof)
+	zk.AssetSetPrivate = new(AssetSetPrivate)
+	zk.AssetSetPublic = new(AssetSetPublic)
+	return zk
+}
+
+func (zk *AssetSetZK) Proof() (err error) {
+	zk.AssetSetProof, err = new(AssetSetProof).ProofGen(zk.AssetSetPrivate)
+	return
+}
+
+func (zk *AssetSetZK) Check() bool {
+	return zk.AssetSetProof.ProofCheck(zk.AssetSetPublic)
+}
+
+type AssetSetPublic struct {
+	t, j *crypto.Generator
+	assets []*crypto.Generator
+	e *big.Int
+}
+
+func (public *AssetSetPublic) SetPublic(t, j *crypto.Generator, assets []*crypto.Generator, e *big.Int) *AssetSetPublic {
+	if e == nil {e = big.NewInt(0)}
+	public.t, public.j, public.assets, public.e = t, j, assets, e
+	return public
+}
+
+func (public *AssetSetPublic) public() {}
+
+// differences returns t - a_i for each asset generator
+func (public *AssetSetPublic) differences() []*crypto.Commitment {
+	y := make([]*crypto.Commitment, len(public.assets))
+	for i, a := range public.assets {
+		y[i] = new(crypto.Commitment).SetIntByGenerator(a, big.NewInt(1)).Neg().AddGenerator(public.t)
+	}
+	return y
+}
+
+type AssetSetPrivate struct {
+	*AssetSetPublic
+	index int
+	s *big.Int
+}
+
+func (private *AssetSetPrivate) SetPrivate(index int, s *big.Int, t, j *crypto.Generator, assets []*crypto.Generator, e *big.Int) (*AssetSetPrivate, error) {
+	if index < 0 || index >= len(assets) {return nil, errors.NewLengthNotMatchError(index, len(assets))}
+	private.index, private.s = index, s
+	private.AssetSetPublic.SetPublic(t, j, assets, e)
+	return private, nil
+}
+
+func (private *AssetSetPrivate) private() {}
+
+type AssetSetProof struct {
+	c, z []*big.Int
+}
+
+func (proof *AssetSetProof) ProofGen(private *AssetSetPrivate) (*AssetSetProof, error) {
+	P := bn256.Order
+	n := len(private.assets)
+	y := private.differences()
+
+	mix, err := crypto.RandomZq(2*n - 1)
+	if err != nil {return nil, err}
+
+	c := make([]*big.Int, n)
+	z := make([]*big.Int, n)
+	t := make([]*crypto.Commitment, n)
+	sum := big.NewInt(0)
+	line := 0
+	for i := 0; i < n; i++ {
+		if i == private.index {continue}
+		c[i], z[i] = mix[line], mix[line+1]
+		line += 2
+		t[i] = new(crypto.Commitment).SetIntByGenerator(private.j, z[i]).AddBy(new(crypto.Commitment).Mul(y[i], c[i]))	//z_i j + c_i y_i
+		sum.Add(sum, c[i])
+	}
+	a := mix[line]
+	t[private.index] = new(crypto.Commitment).SetIntByGenerator(private.j, a)	//a j
+
+	ck := proof.challenge(private.AssetSetPublic, t)
+	ck.Sub(ck, sum)	//c - sum(c_i)
+	ck.Mod(ck, P)
+	zk := new(big.Int).Mul(ck, private.s)	//c_k s
+	zk.Sub(a, zk)							//a - c_k s
+	zk.Mod(zk, P)
+	c[private.index], z[private.index] = ck, zk
+
+	proof.c, proof.z = c, z
+	return proof, nil
+}
+
+func (proof *AssetSetProof) ProofCheck(public *AssetSetPublic) bool {
+	n := len(public.assets)
+	if n == 0 || len(proof.c) != n || len(proof.z) != n {return false}
+	y := public.differences()
+
+	t := make([]*crypto.Commitment, n)
+	sum := big.NewInt(0)
+	for i := 0; i < n; i++ {
+		t[i] = new(crypto.Commitment).SetIntByGenerator(public.j, proof.z[i]).AddBy(new(crypto.Commitment).Mul(y[i], proof.c[i]))
+		sum.Add(sum, proof.c[i])
+	}
+	sum.Mod(sum, bn256.Order)
+	return sum.Cmp(proof.challenge(public, t)) == 0
+}
+
+func (proof *AssetSetProof) challenge(public *AssetSetPublic, t []*crypto.Commitment) *big.Int {
+	hashVar := make([]crypto.HashVariable, 0, 2*len(t)+3)
+	hashVar = append(hashVar, public.t, public.j)
+	for i := range t {
+		hashVar = append(hashVar, public.assets[i], t[i])
+	}
+	hashVar = append(hashVar, public.e)
+	c := crypto.Hash_(hashVar...).BigInt()
+	return c.Mod(c, bn256.Order)
+}
+
+// Bytes returns c_1..c_n followed by z_1..z_n
+func (proof *AssetSetProof) Bytes() []byte {
+	n := len(proof.c)
+	zqBytes := common.Bn256ZqBits / common.ByteBits
+	bytes := make([]byte, 2*n*zqBytes)
+	for i := 0; i < n; i++ {
+		cBytes := proof.c[i].Bytes()
+		zBytes := proof.z[i].Bytes()
+		copy(bytes[(i+1)*zqBytes-len(cBytes):(i+1)*zqBytes], cBytes)
+		copy(bytes[(n+i+1)*zqBytes-len(zBytes):(n+i+1)*zqBytes], zBytes)
+	}
+	return bytes
+}
+
+func (proof *AssetSetProof) SetBytes(b []byte) error {
+	bLen := len(b)
+	zqBytes := common.Bn256ZqBits / common.ByteBits
+	if bLen == 0 || bLen % (2*zqBytes) != 0 {return errors.NewWrongInputLength(bLen)}
+	n := bLen / (2*zqBytes)
+	proof.c, proof.z = make([]*big.Int, n), make([]*big.Int, n)
+	for i := 0; i < n; i++ {
+		proof.c[i] = new(big.Int).SetBytes(b[i*zqBytes:(i+1)*zqBytes])
+		proof.z[i] = new(big.Int).SetBytes(b[(n+i)*zqBytes:(n+i+1)*zqBytes])
+	}
+	return nil
+}
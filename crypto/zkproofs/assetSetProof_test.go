@@ -0,0 +1,52 @@
+package zkproofs
+
+import (
+	"fmt"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/errors"
+	"golang.org/x/crypto/bn256"
+	"math/big"
+	"testing"
+)
+
+func TestAssetSetProof(t *testing.T) {
+	assets, _, err := crypto.RandomPoints(4)
+	errors.Handle(err)
+	j := crypto.HashToGenerator(crypto.HashBytes("asset set test"))
+	ss, err := crypto.RandomZq(1)
+	errors.Handle(err)
+	s := ss[0]
+	e := big.NewInt(114514)
+
+	tag := new(crypto.Generator).Mul(j, s)
+	tag.G1 = new(bn256.G1).Add(tag.G1, assets[2].G1)
+	fmt.Printf("Value of t\n%s\n\n", tag.String())
+
+	zkProver := new(AssetSetZK).Init()
+	_, err = zkProver.SetPrivate(2, s, tag, j, assets, e)
+	errors.Handle(err)
+	err = zkProver.Proof()
+	errors.Handle(err)
+
+	bytes := zkProver.Bytes()
+	fmt.Printf("Proof Infomation:\n%x\n\n", bytes)
+
+	zkVerifier := new(AssetSetZK).Init()
+	zkVerifier.SetPublic(tag, j, assets, e)
+	err = zkVerifier.SetBytes(bytes)
+	errors.Handle(err)
+	if zkVerifier.Check() {
+		fmt.Println("Asset Set Proof check success.")
+	} else {
+		t.Errorf("Asset Set Proof check failed.")
+	}
+
+	zkVerifier.SetPublic(tag, j, append(assets[:2:2], assets[3]), e)
+	if zkVerifier.Check() {
+		t.Errorf("Asset Set Proof accepted without the asset.")
+	}
+	zkVerifier.SetPublic(tag, j, assets, big.NewInt(1919810))
+	if zkVerifier.Check() {
+		t.Errorf("Asset Set Proof accepted with another context.")
+	}
+}
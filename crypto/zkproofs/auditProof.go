@@ -0,0 +1,125 @@
+package zkproofs
+
+import (
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/errors"
+	"golang.org/x/crypto/bn256"
+	"math/big"
+)
+
+// AuditZK proves c1 = v gv + r1 h1, d1 = r1 g1, c2 = v g2 + r2 h2 and d2 = r2 g2,
+// the ciphertext (c1, d1) under (g1, h1) and the auditor ciphertext (c2, d2) under (g2, h2) encrypt the same v
+type AuditZK struct {
+	*AuditProof
+	*AuditPrivate
+}
+
+func (zk *AuditZK) Init() *AuditZK {
+	zk.AuditProof = new(AuditProof)
+	zk.AuditPrivate = new(AuditPrivate)
+	zk.AuditPublic = new(AuditPublic)
+	return zk
+}
+
+func (zk *AuditZK) Proof() (err error) {
+	zk.AuditProof, err = new(AuditProof).ProofGen(zk.AuditPrivate)
+	return
+}
+
+func (zk *AuditZK) Check() bool {
+	return zk.AuditProof.ProofCheck(zk.AuditPublic)
+}
+
+type AuditPublic struct {
+	gv, g1, h1, g2, h2 *crypto.Generator
+	c1, d1, c2, d2 *crypto.Commitment
+	e *big.Int
+}
+
+func (public *AuditPublic) SetPublic(gv, g1, h1 *crypto.Generator, c1, d1 *crypto.Commitment, g2, h2 *crypto.Generator, c2, d2 *crypto.Commitment, e *big.Int) *AuditPublic {
+	if e == nil {e = big.NewInt(0)}
+	public.gv, public.g1, public.h1, public.c1, public.d1 = gv, g1, h1, c1, d1
+	public.g2, public.h2, public.c2, public.d2, public.e = g2, h2, c2, d2, e
+	return public
+}
+
+func (public *AuditPublic) public() {}
+
+type AuditPrivate struct {
+	*AuditPublic
+	v, r1, r2 *big.Int
+}
+
+func (private *AuditPrivate) SetPrivate(v, r1, r2 *big.Int, gv, g1, h1 *crypto.Generator, c1, d1 *crypto.Commitment, g2, h2 *crypto.Generator, c2, d2 *crypto.Commitment, e *big.Int) *AuditPrivate {
+	private.v, private.r1, private.r2 = v, r1, r2
+	private.AuditPublic.SetPublic(gv, g1, h1, c1, d1, g2, h2, c2, d2, e)
+	return private
+}
+
+func (private *AuditPrivate) private() {}
+
+type AuditProof struct {
+	c, zv, zr1, zr2 *big.Int
+}
+
+func (proof *AuditProof) ProofGen(private *AuditPrivate) (*AuditProof, error) {
+	P := bn256.Order
+	ab, err := crypto.RandomZq(3)
+	if err != nil {return nil, err}
+	a, b1, b2 := ab[0], ab[1], ab[2]
+
+	t1 := new(crypto.Commitment).FixedSet(private.gv, private.h1, a, b1)		//a gv + b1 h1
+	t2 := new(crypto.Commitment).SetIntByGenerator(private.g1, b1)				//b1 g1
+	t3 := new(crypto.Commitment).FixedSet(private.g2, private.h2, a, b2)		//a g2 + b2 h2
+	t4 := new(crypto.Commitment).SetIntByGenerator(private.g2, b2)				//b2 g2
+
+	c := proof.challenge(private.AuditPublic, t1, t2, t3, t4)
+
+	response := func(k, w *big.Int) *big.Int {
+		z := new(big.Int).Mul(c, w)	//cw
+		z.Sub(k, z)					//k-cw
+		return z.Mod(z, P)
+	}
+
+	proof.c = c
+	proof.zv, proof.zr1, proof.zr2 = response(a, private.v), response(b1, private.r1), response(b2, private.r2)
+	return proof, nil
+}
+
+func (proof *AuditProof) ProofCheck(public *AuditPublic) bool {
+	if public.d1 == nil || public.d2 == nil {return false}
+	t1 := new(crypto.Commitment).FixedSet(public.gv, public.h1, proof.zv, proof.zr1).AddBy(new(crypto.Commitment).Mul(public.c1, proof.c))
+	t2 := new(crypto.Commitment).SetIntByGenerator(public.g1, proof.zr1).AddBy(new(crypto.Commitment).Mul(public.d1, proof.c))
+	t3 := new(crypto.Commitment).FixedSet(public.g2, public.h2, proof.zv, proof.zr2).AddBy(new(crypto.Commitment).Mul(public.c2, proof.c))
+	t4 := new(crypto.Commitment).SetIntByGenerator(public.g2, proof.zr2).AddBy(new(crypto.Commitment).Mul(public.d2, proof.c))
+
+	c := proof.challenge(public, t1, t2, t3, t4)
+	return c.Cmp(proof.c) == 0
+}
+
+func (proof *AuditProof) challenge(public *AuditPublic, t1, t2, t3, t4 *crypto.Commitment) *big.Int {
+	c := crypto.Hash_(public.gv, public.g1, public.h1, public.c1, public.d1, public.g2, public.h2, public.c2, public.d2, t1, t2, t3, t4, public.e).BigInt()
+	return c.Mod(c, bn256.Order)
+}
+
+func (proof *AuditProof) Bytes() []byte {
+	zqBytes := common.Bn256ZqBits / common.ByteBits
+	bytes := make([]byte, common.AuditProofLength)
+	for i, k := range []*big.Int{proof.c, proof.zv, proof.zr1, proof.zr2} {
+		kBytes := k.Bytes()
+		copy(bytes[(i+1)*zqBytes-len(kBytes):(i+1)*zqBytes], kBytes)
+	}
+	return bytes
+}
+
+func (proof *AuditProof) SetBytes(b []byte) error {
+	bLen := len(b)
+	if bLen != common.AuditProofLength {return errors.NewWrongInputLength(bLen)}
+	zqBytes := common.Bn256ZqBits / common.ByteBits
+	proof.c = new(big.Int).SetBytes(b[:zqBytes])
+	proof.zv = new(big.Int).SetBytes(b[zqBytes:2*zqBytes])
+	proof.zr1 = new(big.Int).SetBytes(b[2*zqBytes:3*zqBytes])
+	proof.zr2 = new(big.Int).SetBytes(b[3*zqBytes:])
+	return nil
+}
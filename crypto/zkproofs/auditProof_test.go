@@ -0,0 +1,52 @@
+package zkproofs
+
+import (
+	"fmt"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/errors"
+	"math/big"
+	"testing"
+)
+
+func TestAuditProof(t *testing.T) {
+	mix, _, err := crypto.RandomPoints(4)
+	errors.Handle(err)
+	gv, g1, h1, h2 := mix[0], mix[1], mix[2], mix[3]
+	g2 := new(crypto.Generator).Init(big.NewInt(1))
+	rl, err := crypto.RandomZq(2)
+	errors.Handle(err)
+	r1, r2 := rl[0], rl[1]
+	v := big.NewInt(1919)
+	e := big.NewInt(114514)
+
+	c1 := new(crypto.Commitment).FixedSet(gv, h1, v, r1)
+	d1 := new(crypto.Commitment).SetIntByGenerator(g1, r1)
+	c2 := new(crypto.Commitment).FixedSet(g2, h2, v, r2)
+	d2 := new(crypto.Commitment).SetIntByGenerator(g2, r2)
+	fmt.Printf("Value of c2\n%s\n\n", c2.String())
+
+	zkProver := new(AuditZK).Init()
+	zkProver.SetPrivate(v, r1, r2, gv, g1, h1, c1, d1, g2, h2, c2, d2, e)
+	err = zkProver.Proof()
+	errors.Handle(err)
+
+	bytes := zkProver.Bytes()
+	fmt.Printf("Proof Infomation:\n%x\n\n", bytes)
+
+	zkVerifier := new(AuditZK).Init()
+	zkVerifier.SetPublic(gv, g1, h1, c1, d1, g2, h2, c2, d2, e)
+	err = zkVerifier.SetBytes(bytes)
+	errors.Handle(err)
+	if zkVerifier.Check() {
+		fmt.Println("Audit Proof check success.")
+	} else {
+		t.Errorf("Audit Proof check failed.")
+	}
+
+	// the auditor ciphertext encrypts one more unit
+	c3 := new(crypto.Commitment).SetIntByGenerator(g2, big.NewInt(1)).AddBy(c2)
+	zkVerifier.SetPublic(gv, g1, h1, c1, d1, g2, h2, c3, d2, e)
+	if zkVerifier.Check() {
+		t.Errorf("Audit Proof accepted with a different value.")
+	}
+}
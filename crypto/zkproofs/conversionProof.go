@@ -0,0 +1,124 @@
+package zkproofs
+
+import (
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/errors"
+	"golang.org/x/crypto/bn256"
+	"math/big"
+)
+
+// ConversionZK proves c1 = v g1 + x d1, h1 = x g1 and c2 = v g2 + r h2 with the same v,
+// which carries a solvable value owned by h1 to a commitment under the base (g2, h2)
+type ConversionZK struct {
+	*ConversionProof
+	*ConversionPrivate
+}
+
+func (zk *ConversionZK) Init() *ConversionZK {
+	zk.ConversionProof = new(ConversionProof)
+	zk.ConversionPrivate = new(ConversionPrivate)
+	zk.ConversionPublic = new(ConversionPublic)
+	return zk
+}
+
+func (zk *ConversionZK) Proof() (err error) {
+	zk.ConversionProof, err = new(ConversionProof).ProofGen(zk.ConversionPrivate)
+	return
+}
+
+func (zk *ConversionZK) Check() bool {
+	return zk.ConversionProof.ProofCheck(zk.ConversionPublic)
+}
+
+type ConversionPublic struct {
+	g1, h1, d1, g2, h2 *crypto.Generator
+	c1, c2 *crypto.Commitment
+	e *big.Int
+}
+
+func (public *ConversionPublic) SetPublic(g1, h1, d1 *crypto.Generator, c1 *crypto.Commitment, g2, h2 *crypto.Generator, c2 *crypto.Commitment, e *big.Int) *ConversionPublic {
+	if e == nil {e = big.NewInt(0)}
+	public.g1, public.h1, public.d1, public.c1 = g1, h1, d1, c1
+	public.g2, public.h2, public.c2, public.e = g2, h2, c2, e
+	return public
+}
+
+func (public *ConversionPublic) public() {}
+
+type ConversionPrivate struct {
+	*ConversionPublic
+	v, x, r *big.Int
+}
+
+func (private *ConversionPrivate) SetPrivate(v, x, r *big.Int, g1, h1, d1 *crypto.Generator, c1 *crypto.Commitment, g2, h2 *crypto.Generator, c2 *crypto.Commitment, e *big.Int) *ConversionPrivate {
+	private.v, private.x, private.r = v, x, r
+	private.ConversionPublic.SetPublic(g1, h1, d1, c1, g2, h2, c2, e)
+	return private
+}
+
+func (private *ConversionPrivate) private() {}
+
+type ConversionProof struct {
+	c, zv, zx, zr *big.Int
+}
+
+func (proof *ConversionProof) ProofGen(private *ConversionPrivate) (*ConversionProof, error) {
+	P := bn256.Order
+	abc, err := crypto.RandomZq(3)
+	if err != nil {return nil, err}
+	a, b, k := abc[0], abc[1], abc[2]
+
+	t1 := new(crypto.Commitment).FixedSet(private.g1, private.d1, a, b)	//a g1 + b d1
+	t2 := new(crypto.Commitment).SetIntByGenerator(private.g1, b)			//b g1
+	t3 := new(crypto.Commitment).FixedSet(private.g2, private.h2, a, k)	//a g2 + k h2
+
+	c := proof.challenge(private.ConversionPublic, t1, t2, t3)
+
+	response := func(k, w *big.Int) *big.Int {
+		z := new(big.Int).Mul(c, w)	//cw
+		z.Sub(k, z)					//k-cw
+		return z.Mod(z, P)
+	}
+
+	proof.c = c
+	proof.zv, proof.zx, proof.zr = response(a, private.v), response(b, private.x), response(k, private.r)
+	return proof, nil
+}
+
+func (proof *ConversionProof) ProofCheck(public *ConversionPublic) bool {
+	h1 := &crypto.Commitment{G1: public.h1.G1}
+
+	t1 := new(crypto.Commitment).FixedSet(public.g1, public.d1, proof.zv, proof.zx).AddBy(new(crypto.Commitment).Mul(public.c1, proof.c))
+	t2 := new(crypto.Commitment).SetIntByGenerator(public.g1, proof.zx).AddBy(new(crypto.Commitment).Mul(h1, proof.c))
+	t3 := new(crypto.Commitment).FixedSet(public.g2, public.h2, proof.zv, proof.zr).AddBy(new(crypto.Commitment).Mul(public.c2, proof.c))
+
+	c := proof.challenge(public, t1, t2, t3)
+	return c.Cmp(proof.c) == 0
+}
+
+func (proof *ConversionProof) challenge(public *ConversionPublic, t1, t2, t3 *crypto.Commitment) *big.Int {
+	c := crypto.Hash_(public.g1, public.h1, public.d1, public.c1, public.g2, public.h2, public.c2, t1, t2, t3, public.e).BigInt()
+	return c.Mod(c, bn256.Order)
+}
+
+func (proof *ConversionProof) Bytes() []byte {
+	zqBytes := common.Bn256ZqBits / common.ByteBits
+	bytes := make([]byte, common.ConversionProofLength)
+	for i, k := range []*big.Int{proof.c, proof.zv, proof.zx, proof.zr} {
+		kBytes := k.Bytes()
+		copy(bytes[(i+1)*zqBytes-len(kBytes):(i+1)*zqBytes], kBytes)
+	}
+	return bytes
+}
+
+func (proof *ConversionProof) SetBytes(b []byte) error {
+	bLen := len(b)
+	if bLen != common.ConversionProofLength {return errors.NewWrongInputLength(bLen)}
+	zqBytes := common.Bn256ZqBits / common.ByteBits
+	proof.c = new(big.Int).SetBytes(b[:zqBytes])
+	proof.zv = new(big.Int).SetBytes(b[zqBytes:2*zqBytes])
+	proof.zx = new(big.Int).SetBytes(b[2*zqBytes:3*zqBytes])
+	proof.zr = new(big.Int).SetBytes(b[3*zqBytes:])
+	return nil
+}
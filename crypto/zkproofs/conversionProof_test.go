@@ -0,0 +1,49 @@
+package zkproofs
+
+import (
+	"fmt"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/errors"
+	"math/big"
+	"testing"
+)
+
+func TestConversionProof(t *testing.T) {
+	v := big.NewInt(114514)
+	g1 := new(crypto.Generator).Init(big.NewInt(1))
+	mix, err := crypto.RandomZq(4)
+	errors.Handle(err)
+	x, r1, s, r2 := mix[0], mix[1], mix[2], mix[3]
+
+	h1 := new(crypto.Generator).Init(x)
+	d1 := new(crypto.Generator).Init(r1)
+	c1 := new(crypto.Commitment).FixedSet(g1, h1, v, r1)
+	g2 := new(crypto.Generator).Init(s)
+	h2 := new(crypto.Generator).Mul(h1, s)
+	c2 := new(crypto.Commitment).FixedSet(g2, h2, v, r2)
+	e := big.NewInt(1919810)
+
+	zkProver := new(ConversionZK).Init()
+	zkProver.SetPrivate(v, x, r2, g1, h1, d1, c1, g2, h2, c2, e)
+	err = zkProver.Proof()
+	errors.Handle(err)
+
+	bytes := zkProver.Bytes()
+	fmt.Printf("Proof Infomation:\n%x\n\n", bytes)
+
+	zkVerifier := new(ConversionZK).Init()
+	zkVerifier.SetPublic(g1, h1, d1, c1, g2, h2, c2, e)
+	err = zkVerifier.SetBytes(bytes)
+	errors.Handle(err)
+	if zkVerifier.Check() {
+		fmt.Println("Conversion Proof check success.")
+	} else {
+		t.Errorf("Conversion Proof check failed.")
+	}
+
+	c3 := new(crypto.Commitment).FixedSet(g2, h2, big.NewInt(114515), r2)
+	zkVerifier.SetPublic(g1, h1, d1, c1, g2, h2, c3, e)
+	if zkVerifier.Check() {
+		t.Errorf("Conversion Proof accepted with another value.")
+	}
+}
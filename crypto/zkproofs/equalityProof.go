@@ -0,0 +1,116 @@
+package zkproofs
+
+import (
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/errors"
+	"golang.org/x/crypto/bn256"
+	"math/big"
+)
+
+// EqualityZK proves y1 = x g1 and y2 = x g2 with the same x, the challenge is bound to the context e
+type EqualityZK struct {
+	*EqualityProof
+	*EqualityPrivate
+}
+
+func (zk *EqualityZK) Init() *EqualityZK {
+	zk.EqualityProof = new(EqualityProof)
+	zk.EqualityPrivate = new(EqualityPrivate)
+	zk.EqualityPublic = new(EqualityPublic)
+	return zk
+}
+
+func (zk *EqualityZK) Proof() (err error) {
+	zk.EqualityProof, err = new(EqualityProof).ProofGen(zk.EqualityPrivate)
+	return
+}
+
+func (zk *EqualityZK) Check() bool {
+	return zk.EqualityProof.ProofCheck(zk.EqualityPublic)
+}
+
+type EqualityPublic struct {
+	g1, g2 *crypto.Generator
+	y1, y2 *crypto.Commitment
+	e *big.Int
+}
+
+func (public *EqualityPublic) SetPublic(g1 *crypto.Generator, y1 *crypto.Commitment, g2 *crypto.Generator, y2 *crypto.Commitment, e *big.Int) *EqualityPublic {
+	if e == nil {e = big.NewInt(0)}
+	public.g1, public.y1, public.g2, public.y2, public.e = g1, y1, g2, y2, e
+	return public
+}
+
+func (public *EqualityPublic) public() {}
+
+type EqualityPrivate struct {
+	*EqualityPublic
+	x *big.Int
+}
+
+func (private *EqualityPrivate) SetPrivate(x *big.Int, g1 *crypto.Generator, y1 *crypto.Commitment, g2 *crypto.Generator, y2 *crypto.Commitment, e *big.Int) *EqualityPrivate {
+	private.x = x
+	private.EqualityPublic.SetPublic(g1, y1, g2, y2, e)
+	return private
+}
+
+func (private *EqualityPrivate) private() {}
+
+type EqualityProof struct {
+	c, z *big.Int
+}
+
+func (proof *EqualityProof) ProofGen(private *EqualityPrivate) (*EqualityProof, error) {
+	P := bn256.Order
+	ks, err := crypto.RandomZq(1)
+	if err != nil {return nil, err}
+	k := ks[0]
+
+	t1 := new(crypto.Commitment).SetIntByGenerator(private.g1, k)	//k g1
+	t2 := new(crypto.Commitment).SetIntByGenerator(private.g2, k)	//k g2
+
+	c := crypto.Hash_(private.g1, private.y1, private.g2, private.y2, t1, t2, private.e).BigInt()
+	c.Mod(c, P)
+
+	z := new(big.Int).Mul(c, private.x)	//cx
+	z.Sub(k, z)							//k-cx
+	z.Mod(z, P)
+
+	proof.c, proof.z = c, z
+	return proof, nil
+}
+
+func (proof *EqualityProof) ProofCheck(public *EqualityPublic) bool {
+	P := bn256.Order
+
+	t1 := new(crypto.Commitment).SetIntByGenerator(public.g1, proof.z).AddBy(new(crypto.Commitment).Mul(public.y1, proof.c))	//z g1 + c y1
+	t2 := new(crypto.Commitment).SetIntByGenerator(public.g2, proof.z).AddBy(new(crypto.Commitment).Mul(public.y2, proof.c))	//z g2 + c y2
+
+	c := crypto.Hash_(public.g1, public.y1, public.g2, public.y2, t1, t2, public.e).BigInt()
+	c.Mod(c, P)
+
+	return c.Cmp(proof.c) == 0
+}
+
+func (proof *EqualityProof) Bytes() []byte {
+	zqBytes := common.Bn256ZqBits / common.ByteBits
+	bytes := make([]byte, common.EqualityProofLength)
+
+	cBytes := proof.c.Bytes()
+	zBytes := proof.z.Bytes()
+
+	copy(bytes[zqBytes-len(cBytes):zqBytes], cBytes)
+	copy(bytes[2*zqBytes-len(zBytes):], zBytes)
+
+	return bytes
+}
+
+func (proof *EqualityProof) SetBytes(b []byte) error {
+	bLen := len(b)
+	if bLen != common.EqualityProofLength {return errors.NewWrongInputLength(bLen)}
+	zqBytes := common.Bn256ZqBits / common.ByteBits
+	proof.c = new(big.Int).SetBytes(b[:zqBytes])
+	proof.z = new(big.Int).SetBytes(b[zqBytes:])
+	return nil
+}
@@ -0,0 +1,47 @@
+package zkproofs
+
+import (
+	"fmt"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/errors"
+	"math/big"
+	"testing"
+)
+
+func TestEqualityProof(t *testing.T) {
+	mix, _, err := crypto.RandomPoints(2)
+	errors.Handle(err)
+	g1, g2 := mix[0], mix[1]
+	xs, err := crypto.RandomZq(1)
+	errors.Handle(err)
+	x := xs[0]
+	e := big.NewInt(114514)
+
+	y1 := new(crypto.Commitment).SetIntByGenerator(g1, x)
+	y2 := new(crypto.Commitment).SetIntByGenerator(g2, x)
+	fmt.Printf("Value of y1\n%s\n\n", y1.String())
+	fmt.Printf("Value of y2\n%s\n\n", y2.String())
+
+	zkProver := new(EqualityZK).Init()
+	zkProver.SetPrivate(x, g1, y1, g2, y2, e)
+	err = zkProver.Proof()
+	errors.Handle(err)
+
+	bytes := zkProver.Bytes()
+	fmt.Printf("Proof Infomation:\n%x\n\n", bytes)
+
+	zkVerifier := new(EqualityZK).Init()
+	zkVerifier.SetPublic(g1, y1, g2, y2, e)
+	err = zkVerifier.SetBytes(bytes)
+	errors.Handle(err)
+	if zkVerifier.Check() {
+		fmt.Println("Equality Proof check success.")
+	} else {
+		t.Errorf("Equality Proof check failed.")
+	}
+
+	zkVerifier.SetPublic(g1, y1, g2, y2, big.NewInt(1919810))
+	if zkVerifier.Check() {
+		t.Errorf("Equality Proof accepted with another context.")
+	}
+}
@@ -30,12 +30,18 @@ func (zk *FormatZK) Check() bool {
 }
 
 type FormatPublic struct {
-	g, h *crypto.Generator
+	g, h, gv *crypto.Generator
 	c1, c2 *crypto.Commitment
 }
 
 func (public *FormatPublic) SetPublic(g, h *crypto.Generator, c1, c2 *crypto.Commitment) *FormatPublic {
-	public.g, public.h, public.c1, public.c2 = g, h, c1, c2
+	public.g, public.h, public.gv, public.c1, public.c2 = g, h, g, c1, c2
+	return public
+}
+
+// SetValueGenerator proves c1 = v gv + r h instead of c1 = v g + r h, it is reset by SetPublic
+func (public *FormatPublic) SetValueGenerator(gv *crypto.Generator) *FormatPublic {
+	public.gv = gv
 	return public
 }
 
@@ -64,7 +70,7 @@ func (proof *FormatProof) ProofGen(private *FormatPrivate) (*FormatProof, error)
 	P := bn256.Order
 
 	a, b := ab[0], ab[1]
-	t1p := new(crypto.Commitment).FixedSet(private.g, private.h, a, b)
+	t1p := new(crypto.Commitment).FixedSet(private.gv, private.h, a, b)
 	t2p := new(crypto.Commitment).SetIntByGenerator(private.g, b)
 
 	c := crypto.Hash_(t1p, t2p).BigInt()
@@ -90,7 +96,7 @@ func (proof *FormatProof) ProofCheck(public *FormatPublic) bool {
 
 	cc1 := new(crypto.Commitment).Mul(public.c1, proof.c)
 	cc2 := new(crypto.Commitment).Mul(public.c2, proof.c)
-	gz1 := new(crypto.Commitment).SetIntByGenerator(public.g, proof.z1)
+	gz1 := new(crypto.Commitment).SetIntByGenerator(public.gv, proof.z1)
 	gz2 := new(crypto.Commitment).SetIntByGenerator(public.g, proof.z2)
 	hz2 := new(crypto.Commitment).SetIntByGenerator(public.h, proof.z2)
 
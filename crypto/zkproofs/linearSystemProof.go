@@ -0,0 +1,202 @@
+package zkproofs
+
+import (
+	"encoding/binary"
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/errors"
+	"golang.org/x/crypto/bn256"
+	"math/big"
+)
+
+// LinearSystemZK proves y_k = sum(x_i g_ki) for every row k with the same x and sum(a_i x_i) = b,
+// a nil g_ki leaves x_i out of the row k. The challenge is bound to the context e
+type LinearSystemZK struct {
+	*LinearSystemProof
+	*LinearSystemPrivate
+}
+
+func (zk *LinearSystemZK) Init() *LinearSystemZK {
+	zk.LinearSystemProof = new(LinearSystemProof)
+	zk.LinearSystemPrivate = new(LinearSystemPrivate)
+	zk.LinearSystemPublic = new(LinearSystemPublic)
+	return zk
+}
+
+func (zk *LinearSystemZK) Proof() (err error) {
+	zk.LinearSystemProof, err = new(LinearSystemProof).ProofGen(zk.LinearSystemPrivate)
+	return
+}
+
+func (zk *LinearSystemZK) Check() bool {
+	return zk.LinearSystemProof.ProofCheck(zk.LinearSystemPublic)
+}
+
+type LinearSystemPublic struct {
+	a []*big.Int
+	b *big.Int
+	y []*crypto.Commitment
+	g [][]*crypto.Generator
+	e *big.Int
+}
+
+func (public *LinearSystemPublic) SetPublic(a []*big.Int, b *big.Int, y []*crypto.Commitment, g [][]*crypto.Generator, e *big.Int) (*LinearSystemPublic, error) {
+	if len(y) != len(g) {return nil, errors.NewLengthNotMatchError(len(y), len(g))}
+	for _, row := range g {
+		if len(row) != len(a) {return nil, errors.NewLengthNotMatchError(len(row), len(a))}
+	}
+	if e == nil {e = big.NewInt(0)}
+	public.a, public.b, public.y, public.g, public.e = a, b, y, g, e
+	return public, nil
+}
+
+func (public *LinearSystemPublic) public() {}
+
+// challenge hashes the statement and the commitments t of the rows
+func (public *LinearSystemPublic) challenge(t []*crypto.Commitment) *big.Int {
+	hashVar := make([]crypto.HashVariable, 0)
+	for _, a := range public.a {
+		hashVar = append(hashVar, a)
+	}
+	hashVar = append(hashVar, public.b)
+	for k, row := range public.g {
+		hashVar = append(hashVar, public.y[k])
+		for i, g := range row {
+			if g != nil {hashVar = append(hashVar, crypto.HashBytes{byte(i >> 8), byte(i)}, g)}
+		}
+	}
+	for _, tk := range t {
+		hashVar = append(hashVar, tk)
+	}
+	c := crypto.Hash_(append(hashVar, public.e)...).BigInt()
+	return c.Mod(c, bn256.Order)
+}
+
+// row returns sum(v_i g_ki) of the row k
+func (public *LinearSystemPublic) row(k int, v []*big.Int) *crypto.Commitment {
+	g := make([]*crypto.Generator, 0, len(v))
+	x := make([]*big.Int, 0, len(v))
+	for i, gi := range public.g[k] {
+		if gi == nil {continue}
+		g = append(g, gi)
+		x = append(x, v[i])
+	}
+	t, _ := new(crypto.Commitment).MultiSet(g, x)
+	return t
+}
+
+type LinearSystemPrivate struct {
+	*LinearSystemPublic
+	x []*big.Int
+}
+
+func (private *LinearSystemPrivate) SetPrivate(a []*big.Int, b *big.Int, x []*big.Int, y []*crypto.Commitment, g [][]*crypto.Generator, e *big.Int) (*LinearSystemPrivate, error) {
+	if len(a) != len(x) {return nil, errors.NewLengthNotMatchError(len(a), len(x))}
+	private.x = x
+	_, err := private.SetPublic(a, b, y, g, e)
+	return private, err
+}
+
+func (private *LinearSystemPrivate) private() {}
+
+type LinearSystemProof struct {
+	t []*crypto.Commitment
+	s []*big.Int
+}
+
+func (proof *LinearSystemProof) ProofGen(private *LinearSystemPrivate) (*LinearSystemProof, error) {
+	P := bn256.Order
+	n := len(private.x)
+	k, err := crypto.RandomZq(n)
+	if err != nil {return nil, err}
+
+	// choose k with sum(a_i k_i) = 0, so the responses satisfy the constraint
+	last := -1
+	sum := big.NewInt(0)
+	for i, a := range private.a {
+		if a.Sign() == 0 {continue}
+		if last >= 0 {sum.Add(sum, new(big.Int).Mul(private.a[last], k[last]))}
+		last = i
+	}
+	if last >= 0 {
+		inverse := new(big.Int).ModInverse(private.a[last], P)
+		k[last] = sum.Neg(sum).Mul(sum, inverse).Mod(sum, P)
+	}
+
+	proof.t = make([]*crypto.Commitment, len(private.g))
+	for row := range private.g {
+		proof.t[row] = private.row(row, k)
+	}
+
+	c := private.challenge(proof.t)
+	proof.s = make([]*big.Int, n)
+	for i := range k {
+		s := new(big.Int).Mul(c, private.x[i])	// c x
+		s.Sub(k[i], s)							// k - c x
+		proof.s[i] = s.Mod(s, P)
+	}
+	return proof, nil
+}
+
+func (proof *LinearSystemProof) ProofCheck(public *LinearSystemPublic) bool {
+	P := bn256.Order
+	if len(proof.t) != len(public.g) || len(proof.s) != len(public.a) {return false}
+	c := public.challenge(proof.t)
+
+	// t_k = sum(s_i g_ki) + c y_k
+	for row := range public.g {
+		t := public.row(row, proof.s).AddBy(new(crypto.Commitment).Mul(public.y[row], c))
+		if !t.Cmp(proof.t[row]) {return false}
+	}
+
+	// sum(a_i s_i) + c b = 0
+	as := new(big.Int).Mul(c, public.b)
+	for i, a := range public.a {
+		as.Add(as, new(big.Int).Mul(a, proof.s[i]))
+	}
+	return as.Mod(as, P).Sign() == 0
+}
+
+// Bytes returns the number of rows | t | s
+func (proof *LinearSystemProof) Bytes() []byte {
+	zqBytes := common.Bn256ZqBits / common.ByteBits
+	pointBytes := common.Bn256PointBits / common.ByteBits
+	bytes := make([]byte, common.LinearSystemCountLength + len(proof.t) * pointBytes + len(proof.s) * zqBytes)
+	binary.BigEndian.PutUint16(bytes, uint16(len(proof.t)))
+	start := common.LinearSystemCountLength
+	for _, t := range proof.t {
+		copy(bytes[start:start+pointBytes], t.Bytes())
+		start += pointBytes
+	}
+	for _, s := range proof.s {
+		sBytes := s.Bytes()
+		copy(bytes[start+zqBytes-len(sBytes):start+zqBytes], sBytes)
+		start += zqBytes
+	}
+	return bytes
+}
+
+func (proof *LinearSystemProof) SetBytes(b []byte) error {
+	bLen := len(b)
+	zqBytes := common.Bn256ZqBits / common.ByteBits
+	pointBytes := common.Bn256PointBits / common.ByteBits
+	if bLen < common.LinearSystemCountLength {return errors.NewWrongInputLength(bLen)}
+	m := int(binary.BigEndian.Uint16(b))
+	start := common.LinearSystemCountLength
+	if bLen < start + m * pointBytes || (bLen - start - m * pointBytes) % zqBytes != 0 {return errors.NewWrongInputLength(bLen)}
+
+	proof.t = make([]*crypto.Commitment, m)
+	for i := range proof.t {
+		point, err := crypto.SetBytes(b[start:start+pointBytes])
+		if err != nil {return err}
+		if point == nil {return errors.NewInvalidPointError()}
+		proof.t[i] = &crypto.Commitment{G1: point}
+		start += pointBytes
+	}
+	proof.s = make([]*big.Int, (bLen - start) / zqBytes)
+	for i := range proof.s {
+		proof.s[i] = new(big.Int).SetBytes(b[start:start+zqBytes])
+		start += zqBytes
+	}
+	return nil
+}
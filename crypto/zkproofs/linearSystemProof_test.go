@@ -0,0 +1,68 @@
+package zkproofs
+
+import (
+	"fmt"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/errors"
+	"golang.org/x/crypto/bn256"
+	"math/big"
+	"testing"
+)
+
+func TestLinearSystem(t *testing.T) {
+	mix, _, err := crypto.RandomPoints(4)
+	errors.Handle(err)
+	x, err := crypto.RandomZq(3)
+	errors.Handle(err)
+	e := big.NewInt(114514)
+
+	// y0 = x0 g0 + x1 g1 + x2 g2, y1 = x1 g3 with x0 + x2 = b
+	a := []*big.Int{big.NewInt(1), big.NewInt(0), big.NewInt(1)}
+	b := new(big.Int).Add(x[0], x[2])
+	b.Mod(b, bn256.Order)
+	g := [][]*crypto.Generator{{mix[0], mix[1], mix[2]}, {nil, mix[3], nil}}
+	y0, err := new(crypto.Commitment).MultiSet(g[0], x)
+	errors.Handle(err)
+	y := []*crypto.Commitment{y0, new(crypto.Commitment).SetIntByGenerator(mix[3], x[1])}
+
+	zkProver := new(LinearSystemZK).Init()
+	_, err = zkProver.SetPrivate(a, b, x, y, g, e)
+	errors.Handle(err)
+	err = zkProver.Proof()
+	errors.Handle(err)
+
+	bytes := zkProver.Bytes()
+	fmt.Printf("Proof Infomation:\n%x\n\n", bytes)
+
+	zkVerifier := new(LinearSystemZK).Init()
+	_, err = zkVerifier.SetPublic(a, b, y, g, e)
+	errors.Handle(err)
+	err = zkVerifier.SetBytes(bytes)
+	errors.Handle(err)
+	if !zkVerifier.Check() {
+		t.Errorf("Linear System Proof check failed.")
+	}
+
+	_, err = zkVerifier.SetPublic(a, b, y, g, big.NewInt(1919810))
+	errors.Handle(err)
+	if zkVerifier.Check() {
+		t.Errorf("Linear System Proof accepted with another context.")
+	}
+
+	// the second row binds x1, another x1 in the first row fails
+	forged := []*big.Int{x[0], new(big.Int).Add(x[1], big.NewInt(1)), x[2]}
+	y0, err = new(crypto.Commitment).MultiSet(g[0], forged)
+	errors.Handle(err)
+	y[0] = y0
+	_, err = zkProver.SetPrivate(a, b, forged, y, g, e)
+	errors.Handle(err)
+	err = zkProver.Proof()
+	errors.Handle(err)
+	_, err = zkVerifier.SetPublic(a, b, y, g, e)
+	errors.Handle(err)
+	err = zkVerifier.SetBytes(zkProver.Bytes())
+	errors.Handle(err)
+	if zkVerifier.Check() {
+		t.Errorf("Linear System Proof accepted rows with different witnesses.")
+	}
+}
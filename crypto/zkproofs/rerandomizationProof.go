@@ -0,0 +1,126 @@
+package zkproofs
+
+import (
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/errors"
+	"golang.org/x/crypto/bn256"
+	"math/big"
+)
+
+// RerandomizationZK proves g2 = k g1, h2 = k h1, c2 = k c1 + t h2 and d2 = k d1 + t g2,
+// the ciphertext (c2, d2) under (g2, h2) encrypts the same value as (c1, d1) under (g1, h1)
+type RerandomizationZK struct {
+	*RerandomizationProof
+	*RerandomizationPrivate
+}
+
+func (zk *RerandomizationZK) Init() *RerandomizationZK {
+	zk.RerandomizationProof = new(RerandomizationProof)
+	zk.RerandomizationPrivate = new(RerandomizationPrivate)
+	zk.RerandomizationPublic = new(RerandomizationPublic)
+	return zk
+}
+
+func (zk *RerandomizationZK) Proof() (err error) {
+	zk.RerandomizationProof, err = new(RerandomizationProof).ProofGen(zk.RerandomizationPrivate)
+	return
+}
+
+func (zk *RerandomizationZK) Check() bool {
+	return zk.RerandomizationProof.ProofCheck(zk.RerandomizationPublic)
+}
+
+type RerandomizationPublic struct {
+	g1, h1, g2, h2 *crypto.Generator
+	c1, d1, c2, d2 *crypto.Commitment
+	e *big.Int
+}
+
+func (public *RerandomizationPublic) SetPublic(g1, h1 *crypto.Generator, c1, d1 *crypto.Commitment, g2, h2 *crypto.Generator, c2, d2 *crypto.Commitment, e *big.Int) *RerandomizationPublic {
+	if e == nil {e = big.NewInt(0)}
+	public.g1, public.h1, public.c1, public.d1 = g1, h1, c1, d1
+	public.g2, public.h2, public.c2, public.d2, public.e = g2, h2, c2, d2, e
+	return public
+}
+
+func (public *RerandomizationPublic) public() {}
+
+type RerandomizationPrivate struct {
+	*RerandomizationPublic
+	k, t *big.Int
+}
+
+func (private *RerandomizationPrivate) SetPrivate(k, t *big.Int, g1, h1 *crypto.Generator, c1, d1 *crypto.Commitment, g2, h2 *crypto.Generator, c2, d2 *crypto.Commitment, e *big.Int) *RerandomizationPrivate {
+	private.k, private.t = k, t
+	private.RerandomizationPublic.SetPublic(g1, h1, c1, d1, g2, h2, c2, d2, e)
+	return private
+}
+
+func (private *RerandomizationPrivate) private() {}
+
+type RerandomizationProof struct {
+	c, zk, zt *big.Int
+}
+
+func (proof *RerandomizationProof) ProofGen(private *RerandomizationPrivate) (*RerandomizationProof, error) {
+	P := bn256.Order
+	ab, err := crypto.RandomZq(2)
+	if err != nil {return nil, err}
+	a, b := ab[0], ab[1]
+
+	t1 := new(crypto.Commitment).SetIntByGenerator(private.g1, a)										//a g1
+	t2 := new(crypto.Commitment).SetIntByGenerator(private.h1, a)										//a h1
+	t3 := new(crypto.Commitment).Mul(private.c1, a).AddBy(new(crypto.Commitment).SetIntByGenerator(private.h2, b))	//a c1 + b h2
+	t4 := new(crypto.Commitment).Mul(private.d1, a).AddBy(new(crypto.Commitment).SetIntByGenerator(private.g2, b))	//a d1 + b g2
+
+	c := proof.challenge(private.RerandomizationPublic, t1, t2, t3, t4)
+
+	response := func(k, w *big.Int) *big.Int {
+		z := new(big.Int).Mul(c, w)	//cw
+		z.Sub(k, z)					//k-cw
+		return z.Mod(z, P)
+	}
+
+	proof.c = c
+	proof.zk, proof.zt = response(a, private.k), response(b, private.t)
+	return proof, nil
+}
+
+func (proof *RerandomizationProof) ProofCheck(public *RerandomizationPublic) bool {
+	g2 := &crypto.Commitment{G1: public.g2.G1}
+	h2 := &crypto.Commitment{G1: public.h2.G1}
+
+	t1 := new(crypto.Commitment).SetIntByGenerator(public.g1, proof.zk).AddBy(new(crypto.Commitment).Mul(g2, proof.c))
+	t2 := new(crypto.Commitment).SetIntByGenerator(public.h1, proof.zk).AddBy(new(crypto.Commitment).Mul(h2, proof.c))
+	t3 := new(crypto.Commitment).Mul(public.c1, proof.zk).AddBy(new(crypto.Commitment).SetIntByGenerator(public.h2, proof.zt)).AddBy(new(crypto.Commitment).Mul(public.c2, proof.c))
+	t4 := new(crypto.Commitment).Mul(public.d1, proof.zk).AddBy(new(crypto.Commitment).SetIntByGenerator(public.g2, proof.zt)).AddBy(new(crypto.Commitment).Mul(public.d2, proof.c))
+
+	c := proof.challenge(public, t1, t2, t3, t4)
+	return c.Cmp(proof.c) == 0
+}
+
+func (proof *RerandomizationProof) challenge(public *RerandomizationPublic, t1, t2, t3, t4 *crypto.Commitment) *big.Int {
+	c := crypto.Hash_(public.g1, public.h1, public.c1, public.d1, public.g2, public.h2, public.c2, public.d2, t1, t2, t3, t4, public.e).BigInt()
+	return c.Mod(c, bn256.Order)
+}
+
+func (proof *RerandomizationProof) Bytes() []byte {
+	zqBytes := common.Bn256ZqBits / common.ByteBits
+	bytes := make([]byte, common.RerandomizationProofLength)
+	for i, k := range []*big.Int{proof.c, proof.zk, proof.zt} {
+		kBytes := k.Bytes()
+		copy(bytes[(i+1)*zqBytes-len(kBytes):(i+1)*zqBytes], kBytes)
+	}
+	return bytes
+}
+
+func (proof *RerandomizationProof) SetBytes(b []byte) error {
+	bLen := len(b)
+	if bLen != common.RerandomizationProofLength {return errors.NewWrongInputLength(bLen)}
+	zqBytes := common.Bn256ZqBits / common.ByteBits
+	proof.c = new(big.Int).SetBytes(b[:zqBytes])
+	proof.zk = new(big.Int).SetBytes(b[zqBytes:2*zqBytes])
+	proof.zt = new(big.Int).SetBytes(b[2*zqBytes:])
+	return nil
+}
@@ -0,0 +1,54 @@
+package zkproofs
+
+import (
+	"fmt"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/errors"
+	"math/big"
+	"testing"
+)
+
+func TestRerandomizationProof(t *testing.T) {
+	mix, _, err := crypto.RandomPoints(2)
+	errors.Handle(err)
+	g1, h1 := mix[0], mix[1]
+	rl, err := crypto.RandomZq(3)
+	errors.Handle(err)
+	r, k, s := rl[0], rl[1], rl[2]
+	v := big.NewInt(1919)
+	e := big.NewInt(114514)
+
+	c1 := new(crypto.Commitment).FixedSet(g1, h1, v, r)
+	d1 := new(crypto.Commitment).SetIntByGenerator(g1, r)
+	g2 := new(crypto.Generator).Mul(g1, k)
+	h2 := new(crypto.Generator).Mul(h1, k)
+	c2 := new(crypto.Commitment).Mul(c1, k).AddBy(new(crypto.Commitment).SetIntByGenerator(h2, s))
+	d2 := new(crypto.Commitment).Mul(d1, k).AddBy(new(crypto.Commitment).SetIntByGenerator(g2, s))
+	fmt.Printf("Value of c2\n%s\n\n", c2.String())
+	fmt.Printf("Value of d2\n%s\n\n", d2.String())
+
+	zkProver := new(RerandomizationZK).Init()
+	zkProver.SetPrivate(k, s, g1, h1, c1, d1, g2, h2, c2, d2, e)
+	err = zkProver.Proof()
+	errors.Handle(err)
+
+	bytes := zkProver.Bytes()
+	fmt.Printf("Proof Infomation:\n%x\n\n", bytes)
+
+	zkVerifier := new(RerandomizationZK).Init()
+	zkVerifier.SetPublic(g1, h1, c1, d1, g2, h2, c2, d2, e)
+	err = zkVerifier.SetBytes(bytes)
+	errors.Handle(err)
+	if zkVerifier.Check() {
+		fmt.Println("Rerandomization Proof check success.")
+	} else {
+		t.Errorf("Rerandomization Proof check failed.")
+	}
+
+	// one more unit of value under the new base
+	c3 := new(crypto.Commitment).SetIntByGenerator(g2, big.NewInt(1)).AddBy(c2)
+	zkVerifier.SetPublic(g1, h1, c1, d1, g2, h2, c3, d2, e)
+	if zkVerifier.Check() {
+		t.Errorf("Rerandomization Proof accepted with a changed value.")
+	}
+}
@@ -0,0 +1,508 @@
+package zkproofs
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/errors"
+	"golang.org/x/crypto/bn256"
+	"math/big"
+	"sync"
+)
+
+// ShuffleZK proves y_i = k (x_pi(i) + s_pi(i) z_pi(i)) for every row (g, h, ..., c, d) of commitments with a secret
+// permutation pi, a secret scalar k and a secret s_j for every row, where z_j = (0, ..., 0, h_j, g_j) re-encrypts the
+// ciphertext (c, d) of the row. It is the Terelius-Wikstrom shuffle argument: a commitment u to the permutation matrix,
+// a product argument on the permuted challenges e', a commitment to s and a linear relation between the rows.
+type ShuffleZK struct {
+	*ShuffleProof
+	*ShufflePrivate
+}
+
+func (zk *ShuffleZK) Init() *ShuffleZK {
+	zk.ShuffleProof = new(ShuffleProof)
+	zk.ShufflePrivate = new(ShufflePrivate)
+	zk.ShufflePublic = new(ShufflePublic)
+	return zk
+}
+
+func (zk *ShuffleZK) Proof() (err error) {
+	zk.ShuffleProof, err = new(ShuffleProof).ProofGen(zk.ShufflePrivate)
+	return
+}
+
+func (zk *ShuffleZK) Check() bool {
+	return zk.ShuffleProof.ProofCheck(zk.ShufflePublic)
+}
+
+// Shuffle permutes the rows of x, re-encrypts each of them by a random s_j and multiplies them by a random k,
+// returns the rows with pi, k and s
+func Shuffle(x [][]*crypto.Commitment) ([][]*crypto.Commitment, []int, *big.Int, []*big.Int, error) {
+	pi, err := RandomPermutation(len(x))
+	if err != nil {return nil, nil, nil, nil, err}
+	ks, err := crypto.RandomZq(len(x) + 1)
+	if err != nil {return nil, nil, nil, nil, err}
+	k, s := ks[0], ks[1:]
+
+	y := make([][]*crypto.Commitment, len(x))
+	for i := range y {
+		j := pi[i]
+		row := x[j]
+		y[i] = make([]*crypto.Commitment, len(row))
+		for l, p := range row {
+			y[i][l] = &crypto.Commitment{G1: p.G1}
+			z := reencryption(row, l)
+			if z != nil {y[i][l] = new(crypto.Commitment).Mul(z, s[j]).AddBy(y[i][l])}
+			y[i][l] = new(crypto.Commitment).Mul(y[i][l], k)
+		}
+	}
+	return y, pi, k, s, nil
+}
+
+// reencryption returns the column l of z = (0, ..., 0, h, g) of a row (g, h, ..., c, d), nil for a zero
+func reencryption(row []*crypto.Commitment, l int) *crypto.Commitment {
+	switch l {
+	case len(row) - 2:
+		return row[1]
+	case len(row) - 1:
+		return row[0]
+	default:
+		return nil
+	}
+}
+
+// RandomPermutation returns a uniformly random permutation of n by Fisher-Yates
+func RandomPermutation(n int) ([]int, error) {
+	pi := make([]int, n)
+	for i := range pi {
+		pi[i] = i
+	}
+	for i := n - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {return nil, err}
+		pi[i], pi[j.Int64()] = pi[j.Int64()], pi[i]
+	}
+	return pi, nil
+}
+
+var shuffleDomain = crypto.HashBytes("maskash shuffle")
+var shuffleG = crypto.HashToGenerator(shuffleDomain, crypto.HashBytes("g"))
+
+var shuffleH []*crypto.Generator
+var shuffleHLock sync.Mutex
+
+// shuffleGenerators returns the independent generators h_0..h_n, h_0 is the base of the product argument
+func shuffleGenerators(n int) []*crypto.Generator {
+	shuffleHLock.Lock()
+	defer shuffleHLock.Unlock()
+	for i := len(shuffleH); i <= n; i++ {
+		shuffleH = append(shuffleH, crypto.HashToGenerator(shuffleDomain, crypto.HashBytes("h"), big.NewInt(int64(i))))
+	}
+	return shuffleH[:n+1]
+}
+
+type ShufflePublic struct {
+	x, y [][]*crypto.Commitment
+	e *big.Int
+}
+
+// SetPublic sets the input rows x and the output rows y, all rows have the same width of at least 4
+func (public *ShufflePublic) SetPublic(x, y [][]*crypto.Commitment, e *big.Int) (*ShufflePublic, error) {
+	if len(x) != len(y) {return nil, errors.NewLengthNotMatchError(len(x), len(y))}
+	if len(x) == 0 {return nil, errors.NewWrongInputLength(0)}
+	width := len(x[0])
+	if width < 4 || width > common.MaxShuffleWidth {return nil, errors.NewWrongInputLength(width)}
+	for i := range x {
+		if len(x[i]) != width {return nil, errors.NewLengthNotMatchError(len(x[i]), width)}
+		if len(y[i]) != width {return nil, errors.NewLengthNotMatchError(len(y[i]), width)}
+	}
+	if e == nil {e = big.NewInt(0)}
+	public.x, public.y, public.e = x, y, e
+	return public, nil
+}
+
+func (public *ShufflePublic) public() {}
+
+func (public *ShufflePublic) width() int {return len(public.x[0])}
+
+// seed hashes the statement with the permutation commitment u and the commitment cs to s
+func (public *ShufflePublic) seed(u []*crypto.Commitment, cs *crypto.Commitment) crypto.Hash {
+	hashVar := make([]crypto.HashVariable, 0, (2*public.width()+1)*len(public.x)+2)
+	for i := range public.x {
+		for l := range public.x[i] {
+			hashVar = append(hashVar, public.x[i][l], public.y[i][l])
+		}
+		hashVar = append(hashVar, u[i])
+	}
+	hashVar = append(hashVar, cs, public.e)
+	return crypto.Hash_(hashVar...)
+}
+
+// challenges derives the vector e of the permutation matrix argument from the seed
+func (public *ShufflePublic) challenges(seed crypto.Hash) []*big.Int {
+	e := make([]*big.Int, len(public.x))
+	for i := range e {
+		e[i] = crypto.Hash_(seed, big.NewInt(int64(i))).BigInt()
+		e[i].Mod(e[i], bn256.Order)
+	}
+	return e
+}
+
+// combine returns x_e = sum(e_j x_j) of each column
+func (public *ShufflePublic) combine(e []*big.Int) []*crypto.Commitment {
+	xe := make([]*crypto.Commitment, public.width())
+	column := make([]*crypto.Commitment, len(public.x))
+	for l := range xe {
+		for j := range public.x {
+			column[j] = public.x[j][l]
+		}
+		xe[l] = multiMul(column, e)
+	}
+	return xe
+}
+
+// reencrypt returns sum(e_j k_j z_j) of the column l, nil for a column without re-encryption
+func (public *ShufflePublic) reencrypt(l int, e, k []*big.Int) *crypto.Commitment {
+	if reencryption(public.x[0], l) == nil {return nil}
+	column := make([]*crypto.Commitment, len(public.x))
+	scalars := make([]*big.Int, len(public.x))
+	for j := range public.x {
+		column[j] = reencryption(public.x[j], l)
+		scalars[j] = new(big.Int).Mul(e[j], k[j])
+	}
+	return multiMul(column, scalars)
+}
+
+type ShufflePrivate struct {
+	*ShufflePublic
+	pi []int
+	k *big.Int
+	s []*big.Int
+}
+
+// SetPrivate sets the permutation pi, the scalar k and the re-encryption s with y_i = k (x_pi(i) + s_pi(i) z_pi(i))
+func (private *ShufflePrivate) SetPrivate(pi []int, k *big.Int, s []*big.Int, x, y [][]*crypto.Commitment, e *big.Int) (*ShufflePrivate, error) {
+	if len(pi) != len(x) {return nil, errors.NewLengthNotMatchError(len(pi), len(x))}
+	if len(s) != len(x) {return nil, errors.NewLengthNotMatchError(len(s), len(x))}
+	seen := make([]bool, len(pi))
+	for _, j := range pi {
+		if j < 0 || j >= len(pi) || seen[j] {return nil, errors.NewWrongInputLength(j)}
+		seen[j] = true
+	}
+	private.pi, private.k, private.s = pi, k, s
+	_, err := private.ShufflePublic.SetPublic(x, y, e)
+	if err != nil {return nil, err}
+	return private, nil
+}
+
+func (private *ShufflePrivate) private() {}
+
+type ShuffleProof struct {
+	u, cHat, tHat []*crypto.Commitment
+	cs, t1, t2, t3, t5 *crypto.Commitment
+	t4 []*crypto.Commitment
+	k1, k2, k3, k4, k5 *big.Int
+	kHat, kPrime, kS []*big.Int
+}
+
+func (proof *ShuffleProof) ProofGen(private *ShufflePrivate) (*ShuffleProof, error) {
+	P := bn256.Order
+	n, width := len(private.x), private.width()
+	g, h := shuffleG, shuffleGenerators(n)
+
+	// u_j = r_j g + h_(pi^-1(j)), the column j of the permutation matrix
+	r, err := crypto.RandomZq(n)
+	if err != nil {return nil, err}
+	u := make([]*crypto.Commitment, n)
+	for i, j := range private.pi {
+		u[j] = new(crypto.Commitment).SetIntByGenerator(g, r[j]).AddGenerator(h[i+1])
+	}
+
+	// cs = rho g + sum(k s_j h_j) fixes the re-encryption before the challenges
+	rhos, err := crypto.RandomZq(1)
+	if err != nil {return nil, err}
+	rho := rhos[0]
+	ks := make([]*big.Int, n)
+	for j, s := range private.s {
+		ks[j] = new(big.Int).Mul(private.k, s)
+		ks[j].Mod(ks[j], P)
+	}
+	cs := new(crypto.Commitment).SetIntByGenerator(g, rho).AddBy(multiMul(toCommitments(h[1:]), ks))
+
+	seed := private.seed(u, cs)
+	e := private.challenges(seed)
+	ePrime := make([]*big.Int, n)
+	for i, j := range private.pi {
+		ePrime[i] = e[j]
+	}
+
+	// c_i = rHat_i g + e'_i c_(i-1) with c_(-1) = h_0, so c_(n-1) = rHat g + prod(e') h_0
+	rHat, err := crypto.RandomZq(n)
+	if err != nil {return nil, err}
+	cHat := make([]*crypto.Commitment, n)
+	previous := &crypto.Commitment{G1: h[0].G1}
+	rHatSum := big.NewInt(0)
+	for i := 0; i < n; i++ {
+		cHat[i] = new(crypto.Commitment).SetIntByGenerator(g, rHat[i]).AddBy(new(crypto.Commitment).Mul(previous, ePrime[i]))
+		rHatSum.Mul(rHatSum, ePrime[i]).Add(rHatSum, rHat[i]).Mod(rHatSum, P)
+		previous = cHat[i]
+	}
+	rBar, rTilde := big.NewInt(0), big.NewInt(0)
+	for j := 0; j < n; j++ {
+		rBar.Add(rBar, r[j])
+		rTilde.Add(rTilde, new(big.Int).Mul(r[j], e[j]))
+	}
+	rBar.Mod(rBar, P)
+	rTilde.Mod(rTilde, P)
+
+	omega, err := crypto.RandomZq(5 + 3*n)
+	if err != nil {return nil, err}
+	w1, w2, w3, w4, w5 := omega[0], omega[1], omega[2], omega[3], omega[4]
+	wHat, wPrime, wS := omega[5:5+n], omega[5+n:5+2*n], omega[5+2*n:]
+
+	t1 := new(crypto.Commitment).SetIntByGenerator(g, w1)
+	t2 := new(crypto.Commitment).SetIntByGenerator(g, w2)
+	t3 := new(crypto.Commitment).SetIntByGenerator(g, w3).AddBy(multiMul(toCommitments(h[1:]), wPrime))
+	t5 := new(crypto.Commitment).SetIntByGenerator(g, w5).AddBy(multiMul(toCommitments(h[1:]), wS))
+	xe := private.combine(e)
+	t4 := make([]*crypto.Commitment, width)
+	column := make([]*crypto.Commitment, n)
+	for l := range t4 {
+		for i := range private.y {
+			column[i] = private.y[i][l]
+		}
+		t4[l] = multiMul(column, wPrime).AddBy(new(crypto.Commitment).Mul(xe[l], w4).Neg())
+		z := private.reencrypt(l, e, wS)
+		if z != nil {t4[l].AddBy(z.Neg())}
+	}
+	tHat := make([]*crypto.Commitment, n)
+	previous = &crypto.Commitment{G1: h[0].G1}
+	for i := 0; i < n; i++ {
+		tHat[i] = new(crypto.Commitment).SetIntByGenerator(g, wHat[i]).AddBy(new(crypto.Commitment).Mul(previous, wPrime[i]))
+		previous = cHat[i]
+	}
+
+	proof.u, proof.cHat, proof.tHat = u, cHat, tHat
+	proof.cs, proof.t1, proof.t2, proof.t3, proof.t4, proof.t5 = cs, t1, t2, t3, t4, t5
+	v := proof.challenge(seed)
+
+	response := func(w, x *big.Int) *big.Int {
+		z := new(big.Int).Mul(v, x)	//vx
+		z.Add(w, z)					//w+vx
+		return z.Mod(z, P)
+	}
+	proof.k1, proof.k2, proof.k3, proof.k4 = response(w1, rBar), response(w2, rHatSum), response(w3, rTilde), response(w4, private.k)
+	proof.k5 = response(w5, rho)
+	proof.kHat, proof.kPrime, proof.kS = make([]*big.Int, n), make([]*big.Int, n), make([]*big.Int, n)
+	for i := 0; i < n; i++ {
+		proof.kHat[i], proof.kPrime[i], proof.kS[i] = response(wHat[i], rHat[i]), response(wPrime[i], ePrime[i]), response(wS[i], ks[i])
+	}
+	return proof, nil
+}
+
+func (proof *ShuffleProof) ProofCheck(public *ShufflePublic) bool {
+	P := bn256.Order
+	n, width := len(public.x), public.width()
+	if len(proof.u) != n || len(proof.cHat) != n || len(proof.tHat) != n || len(proof.t4) != width {return false}
+	if len(proof.kHat) != n || len(proof.kPrime) != n || len(proof.kS) != n {return false}
+	g, h := shuffleG, shuffleGenerators(n)
+
+	seed := public.seed(proof.u, proof.cs)
+	e := public.challenges(seed)
+	v := proof.challenge(seed)
+
+	// A = sum(u) - sum(h) = rBar g
+	a := sumPoints(proof.u).AddBy(sumPoints(toCommitments(h[1:])).Neg())
+	if !new(crypto.Commitment).SetIntByGenerator(g, proof.k1).Cmp(new(crypto.Commitment).Mul(a, v).AddBy(proof.t1)) {return false}
+
+	// C = c_(n-1) - prod(e) h_0 = rHat g
+	prod := big.NewInt(1)
+	for _, ei := range e {
+		prod.Mul(prod, ei).Mod(prod, P)
+	}
+	c := new(crypto.Commitment).SetIntByGenerator(h[0], prod).Neg().AddBy(proof.cHat[n-1])
+	if !new(crypto.Commitment).SetIntByGenerator(g, proof.k2).Cmp(new(crypto.Commitment).Mul(c, v).AddBy(proof.t2)) {return false}
+
+	// D = sum(e_j u_j) = rTilde g + sum(e'_i h_i)
+	d := multiMul(proof.u, e)
+	left := new(crypto.Commitment).SetIntByGenerator(g, proof.k3).AddBy(multiMul(toCommitments(h[1:]), proof.kPrime))
+	if !left.Cmp(new(crypto.Commitment).Mul(d, v).AddBy(proof.t3)) {return false}
+
+	// cs = rho g + sum(k s_j h_j)
+	left = new(crypto.Commitment).SetIntByGenerator(g, proof.k5).AddBy(multiMul(toCommitments(h[1:]), proof.kS))
+	if !left.Cmp(new(crypto.Commitment).Mul(proof.cs, v).AddBy(proof.t5)) {return false}
+
+	// sum(e'_i y_i) = k sum(e_j x_j) + sum(e_j k s_j z_j) for every column, k s_j is answered by kS_j
+	xe := public.combine(e)
+	column := make([]*crypto.Commitment, n)
+	for l := 0; l < width; l++ {
+		for i := range public.y {
+			column[i] = public.y[i][l]
+		}
+		left := multiMul(column, proof.kPrime).AddBy(new(crypto.Commitment).Mul(xe[l], proof.k4).Neg())
+		z := public.reencrypt(l, e, proof.kS)
+		if z != nil {left.AddBy(z.Neg())}
+		if !left.Cmp(proof.t4[l]) {return false}
+	}
+
+	// c_i = rHat_i g + e'_i c_(i-1) for every i, checked at once by a random linear combination rho
+	rho, err := crypto.RandomZq(n)
+	if err != nil {return false}
+	points := make([]*crypto.Commitment, 0, 3*n+1)
+	scalars := make([]*big.Int, 0, 3*n+1)
+	gSum := big.NewInt(0)
+	previous := &crypto.Commitment{G1: h[0].G1}
+	for i := 0; i < n; i++ {
+		gSum.Add(gSum, new(big.Int).Mul(rho[i], proof.kHat[i]))
+		points = append(points, previous, proof.cHat[i], proof.tHat[i])
+		scalars = append(scalars, new(big.Int).Mul(rho[i], proof.kPrime[i]), new(big.Int).Mul(rho[i], new(big.Int).Neg(v)), new(big.Int).Neg(rho[i]))
+		previous = proof.cHat[i]
+	}
+	points = append(points, &crypto.Commitment{G1: g.G1})
+	scalars = append(scalars, gSum)
+	zero := new(crypto.Commitment).SetInt(big.NewInt(0))
+	return multiMul(points, scalars).Cmp(zero)
+}
+
+func (proof *ShuffleProof) challenge(seed crypto.Hash) *big.Int {
+	hashVar := make([]crypto.HashVariable, 0, 2*len(proof.cHat)+len(proof.t4)+5)
+	hashVar = append(hashVar, seed)
+	for i := range proof.cHat {
+		hashVar = append(hashVar, proof.cHat[i], proof.tHat[i])
+	}
+	hashVar = append(hashVar, proof.t1, proof.t2, proof.t3, proof.t5)
+	for _, t := range proof.t4 {
+		hashVar = append(hashVar, t)
+	}
+	v := crypto.Hash_(hashVar...).BigInt()
+	return v.Mod(v, bn256.Order)
+}
+
+// Bytes returns n | width | u | c | t | cs t1 t2 t3 t5 | t4 | k1 k2 k3 k4 k5 | kHat | k' | kS
+func (proof *ShuffleProof) Bytes() []byte {
+	n, width := len(proof.u), len(proof.t4)
+	pointBytes := common.Bn256PointBits / common.ByteBits
+	zqBytes := common.Bn256ZqBits / common.ByteBits
+	bytes := make([]byte, shuffleProofLength(n, width))
+	binary.BigEndian.PutUint32(bytes[:common.ShuffleCountLength], uint32(n))
+	bytes[common.ShuffleCountLength] = uint8(width)
+
+	start := common.ShuffleHeaderLength
+	points := make([]*crypto.Commitment, 0, 3*n+5+width)
+	points = append(append(append(points, proof.u...), proof.cHat...), proof.tHat...)
+	points = append(append(points, proof.cs, proof.t1, proof.t2, proof.t3, proof.t5), proof.t4...)
+	for _, p := range points {
+		copy(bytes[start:start+pointBytes], p.Bytes())
+		start += pointBytes
+	}
+	scalars := make([]*big.Int, 0, 3*n+5)
+	scalars = append(append(append(scalars, proof.k1, proof.k2, proof.k3, proof.k4, proof.k5), proof.kHat...), proof.kPrime...)
+	scalars = append(scalars, proof.kS...)
+	for _, k := range scalars {
+		kBytes := k.Bytes()
+		copy(bytes[start+zqBytes-len(kBytes):start+zqBytes], kBytes)
+		start += zqBytes
+	}
+	return bytes
+}
+
+func (proof *ShuffleProof) SetBytes(b []byte) error {
+	bLen := len(b)
+	if bLen < common.ShuffleHeaderLength {return errors.NewWrongInputLength(bLen)}
+	n, width := int(binary.BigEndian.Uint32(b[:common.ShuffleCountLength])), int(b[common.ShuffleCountLength])
+	if n == 0 || width < 4 || n > common.MaxShuffleSize || bLen != shuffleProofLength(n, width) {return errors.NewWrongInputLength(bLen)}
+	pointBytes := common.Bn256PointBits / common.ByteBits
+	zqBytes := common.Bn256ZqBits / common.ByteBits
+
+	start := common.ShuffleHeaderLength
+	points := make([]*crypto.Commitment, 3*n+5+width)
+	for i := range points {
+		p, err := crypto.SetBytes(b[start:start+pointBytes])
+		if err != nil {return err}
+		if p == nil {return errors.NewWrongInputLength(bLen)}
+		points[i] = &crypto.Commitment{G1: p}
+		start += pointBytes
+	}
+	scalars := make([]*big.Int, 3*n+5)
+	for i := range scalars {
+		scalars[i] = new(big.Int).SetBytes(b[start:start+zqBytes])
+		start += zqBytes
+	}
+
+	proof.u, proof.cHat, proof.tHat = points[:n], points[n:2*n], points[2*n:3*n]
+	proof.cs, proof.t1, proof.t2, proof.t3, proof.t5 = points[3*n], points[3*n+1], points[3*n+2], points[3*n+3], points[3*n+4]
+	proof.t4 = points[3*n+5:]
+	proof.k1, proof.k2, proof.k3, proof.k4, proof.k5 = scalars[0], scalars[1], scalars[2], scalars[3], scalars[4]
+	proof.kHat, proof.kPrime, proof.kS = scalars[5:5+n], scalars[5+n:5+2*n], scalars[5+2*n:]
+	return nil
+}
+
+// multiMul returns sum(k_i p_i) by the bucket method of Pippenger, which costs about
+// 256/w (n + 2^w) additions instead of 384 n for n separate scalar multiplications
+func multiMul(p []*crypto.Commitment, k []*big.Int) *crypto.Commitment {
+	n := len(p)
+	w := 1
+	for 1 << (w + 2) < n {
+		w++
+	}
+	scalars := make([]*big.Int, n)
+	for i := range k {
+		scalars[i] = new(big.Int).Mod(k[i], bn256.Order)
+	}
+
+	infinity := func() *bn256.G1 {return new(bn256.G1).ScalarBaseMult(big.NewInt(0))}
+	sum := infinity()
+	buckets := make([]*bn256.G1, 1 << w)
+	for window := (bn256.Order.BitLen() + w - 1) / w - 1; window >= 0; window-- {
+		for j := 0; j < w; j++ {
+			sum = new(bn256.G1).Add(sum, sum)
+		}
+		for j := range buckets {
+			buckets[j] = nil
+		}
+		for i := 0; i < n; i++ {
+			digit := 0
+			for j := w - 1; j >= 0; j-- {
+				digit = digit << 1 | int(scalars[i].Bit(window * w + j))
+			}
+			if digit == 0 {continue}
+			if buckets[digit] == nil {
+				buckets[digit] = p[i].G1
+			} else {
+				buckets[digit] = new(bn256.G1).Add(buckets[digit], p[i].G1)
+			}
+		}
+		// sum(j bucket_j) by running sums from the top bucket
+		running, windowSum := infinity(), infinity()
+		for j := len(buckets) - 1; j > 0; j-- {
+			if buckets[j] != nil {running = new(bn256.G1).Add(running, buckets[j])}
+			windowSum = new(bn256.G1).Add(windowSum, running)
+		}
+		sum = new(bn256.G1).Add(sum, windowSum)
+	}
+	return &crypto.Commitment{G1: sum}
+}
+
+func toCommitments(g []*crypto.Generator) []*crypto.Commitment {
+	c := make([]*crypto.Commitment, len(g))
+	for i := range g {
+		c[i] = &crypto.Commitment{G1: g[i].G1}
+	}
+	return c
+}
+
+func sumPoints(p []*crypto.Commitment) *crypto.Commitment {
+	sum := new(bn256.G1).ScalarBaseMult(big.NewInt(0))
+	for i := range p {
+		sum = new(bn256.G1).Add(sum, p[i].G1)
+	}
+	return &crypto.Commitment{G1: sum}
+}
+
+// shuffleProofLength is the length of a ShuffleProof of n rows of width commitments
+func shuffleProofLength(n, width int) int {
+	pointBytes := common.Bn256PointBits / common.ByteBits
+	zqBytes := common.Bn256ZqBits / common.ByteBits
+	return common.ShuffleHeaderLength + (3*n + 5 + width) * pointBytes + (3*n + 5) * zqBytes
+}
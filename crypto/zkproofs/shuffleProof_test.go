@@ -0,0 +1,107 @@
+package zkproofs
+
+import (
+	"fmt"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/errors"
+	"math/big"
+	"testing"
+)
+
+func randomRows(n, width int) [][]*crypto.Commitment {
+	x := make([][]*crypto.Commitment, n)
+	for i := range x {
+		points, _, err := crypto.RandomPoints(width)
+		errors.Handle(err)
+		x[i] = make([]*crypto.Commitment, width)
+		for l, p := range points {
+			x[i][l] = &crypto.Commitment{G1: p.G1}
+		}
+	}
+	return x
+}
+
+func TestShuffleProof(t *testing.T) {
+	x := randomRows(16, 4)
+	e := big.NewInt(114514)
+	y, pi, k, s, err := Shuffle(x)
+	errors.Handle(err)
+	fmt.Printf("Permutation\n%v\n\n", pi)
+
+	zkProver := new(ShuffleZK).Init()
+	_, err = zkProver.SetPrivate(pi, k, s, x, y, e)
+	errors.Handle(err)
+	err = zkProver.Proof()
+	errors.Handle(err)
+
+	bytes := zkProver.Bytes()
+	fmt.Printf("Proof Length\n%d\n\n", len(bytes))
+
+	zkVerifier := new(ShuffleZK).Init()
+	_, err = zkVerifier.SetPublic(x, y, e)
+	errors.Handle(err)
+	err = zkVerifier.SetBytes(bytes)
+	errors.Handle(err)
+	if zkVerifier.Check() {
+		fmt.Println("Shuffle Proof check success.")
+	} else {
+		t.Errorf("Shuffle Proof check failed.")
+	}
+
+	// one output replaced by a copy of another
+	forged := append([][]*crypto.Commitment{y[1]}, y[1:]...)
+	_, err = zkVerifier.SetPublic(x, forged, e)
+	errors.Handle(err)
+	if zkVerifier.Check() {
+		t.Errorf("Shuffle Proof accepted with a duplicated row.")
+	}
+
+	// a ciphertext re-encrypted once more after the proof
+	forged = make([][]*crypto.Commitment, len(y))
+	copy(forged, y)
+	forged[0] = []*crypto.Commitment{y[0][0], y[0][1], new(crypto.Commitment).Mul(y[0][1], e).AddBy(y[0][2]), new(crypto.Commitment).Mul(y[0][0], e).AddBy(y[0][3])}
+	_, err = zkVerifier.SetPublic(x, forged, e)
+	errors.Handle(err)
+	if zkVerifier.Check() {
+		t.Errorf("Shuffle Proof accepted with an unproven re-encryption.")
+	}
+
+	// the same rows scaled by a wrong scalar
+	zkProver.SetPrivate(pi, new(big.Int).Add(k, big.NewInt(1)), s, x, y, e)
+	err = zkProver.Proof()
+	errors.Handle(err)
+	zkVerifier.SetPublic(x, y, e)
+	zkVerifier.ShuffleProof = zkProver.ShuffleProof
+	if zkVerifier.Check() {
+		t.Errorf("Shuffle Proof accepted with a wrong scalar.")
+	}
+}
+
+func benchmarkShuffle(b *testing.B, n int) {
+	x := randomRows(n, 4)
+	y, pi, k, s, err := Shuffle(x)
+	errors.Handle(err)
+	zk := new(ShuffleZK).Init()
+	_, err = zk.SetPrivate(pi, k, s, x, y, nil)
+	errors.Handle(err)
+	shuffleGenerators(n)
+
+	b.Run("Prove", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			errors.Handle(zk.Proof())
+		}
+	})
+	b.Run("Verify", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if !zk.Check() {b.Fatal("Shuffle Proof check failed.")}
+		}
+	})
+}
+
+func BenchmarkShuffle10(b *testing.B) {benchmarkShuffle(b, 10)}
+
+func BenchmarkShuffle100(b *testing.B) {benchmarkShuffle(b, 100)}
+
+func BenchmarkShuffle1000(b *testing.B) {benchmarkShuffle(b, 1000)}
+
+func BenchmarkShuffle4000(b *testing.B) {benchmarkShuffle(b, 4000)}
@@ -139,3 +139,299 @@ func NewCannotFindValueError() *CannotFindValueError {
 func (err *CannotFindValueError) Error() string {
 	return fmt.Sprintf("The answer of this commitment can not be found.\n")
 }
+
+// InvalidDerivationPathError derivation path can not be parsed
+type InvalidDerivationPathError struct {
+	path string
+}
+
+func NewInvalidDerivationPathError(path string) *InvalidDerivationPathError {
+	return &InvalidDerivationPathError{path}
+}
+
+func (err *InvalidDerivationPathError) Error() string {
+	return fmt.Sprintf("The derivation path %q is invalid\n", err.path)
+}
+
+// HardenedDerivationError hardened child derived from public
+type HardenedDerivationError struct {
+	index uint32
+}
+
+func NewHardenedDerivationError(index uint32) *HardenedDerivationError {
+	return &HardenedDerivationError{index}
+}
+
+func (err *HardenedDerivationError) Error() string {
+	return fmt.Sprintf("The hardened child %d can not be derived from an extended base\n", err.index)
+}
+
+// InvalidChildKeyError derived key is out of range
+type InvalidChildKeyError struct {
+	index uint32
+}
+
+func NewInvalidChildKeyError(index uint32) *InvalidChildKeyError {
+	return &InvalidChildKeyError{index}
+}
+
+func (err *InvalidChildKeyError) Error() string {
+	return fmt.Sprintf("The child key %d is invalid, try the next index\n", err.index)
+}
+
+// UnsupportedKeystoreError keystore field not supported
+type UnsupportedKeystoreError struct {
+	field, value string
+}
+
+func NewUnsupportedKeystoreError(field, value string) *UnsupportedKeystoreError {
+	return &UnsupportedKeystoreError{field, value}
+}
+
+func (err *UnsupportedKeystoreError) Error() string {
+	return fmt.Sprintf("The keystore %s %q is not supported\n", err.field, err.value)
+}
+
+// WrongPasswordError keystore can not be decrypted
+type WrongPasswordError struct {}
+
+func NewWrongPasswordError() *WrongPasswordError {
+	return &WrongPasswordError{}
+}
+
+func (err *WrongPasswordError) Error() string {
+	return fmt.Sprintf("The keystore can not be decrypted, the password is wrong or the file is tampered\n")
+}
+
+// KeystoreMismatchError decrypted key does not match the stored bases
+type KeystoreMismatchError struct {}
+
+func NewKeystoreMismatchError() *KeystoreMismatchError {
+	return &KeystoreMismatchError{}
+}
+
+func (err *KeystoreMismatchError) Error() string {
+	return fmt.Sprintf("The decrypted key does not match the bases of the keystore\n")
+}
+
+// CannotOpenMemoError memo decryption error
+type CannotOpenMemoError struct {}
+
+func NewCannotOpenMemoError() *CannotOpenMemoError {
+	return &CannotOpenMemoError{}
+}
+
+func (err *CannotOpenMemoError) Error() string {
+	return fmt.Sprintf("The memo can not be opened by this key or it has been swapped.\n")
+}
+
+// AssetNotAllowedError asset is not in the allowed set
+type AssetNotAllowedError struct {
+	asset []byte
+}
+
+func NewAssetNotAllowedError(asset []byte) *AssetNotAllowedError {
+	return &AssetNotAllowedError{asset}
+}
+
+func (err *AssetNotAllowedError) Error() string {
+	return fmt.Sprintf("The asset %x is not in the allowed asset set\n", err.asset)
+}
+
+// UnbalancedError balance check failed
+type UnbalancedError struct {}
+
+func NewUnbalancedError() *UnbalancedError {
+	return &UnbalancedError{}
+}
+
+func (err *UnbalancedError) Error() string {
+	return fmt.Sprintf("The inputs and the outputs are not balanced.\n")
+}
+
+// AssetTaggedError operation does not support asset tagged values
+type AssetTaggedError struct {}
+
+func NewAssetTaggedError() *AssetTaggedError {
+	return &AssetTaggedError{}
+}
+
+func (err *AssetTaggedError) Error() string {
+	return fmt.Sprintf("The operation does not support asset tagged values.\n")
+}
+
+// RecordNotFoundError store has no record of the hash
+type RecordNotFoundError struct {
+	hash []byte
+}
+
+func NewRecordNotFoundError(hash []byte) *RecordNotFoundError {
+	return &RecordNotFoundError{hash}
+}
+
+func (err *RecordNotFoundError) Error() string {
+	return fmt.Sprintf("The record %x is not found in the store.\n", err.hash)
+}
+
+// InsufficientFundsError unspent outputs can not pay the amount
+type InsufficientFundsError struct {
+	have, want *big.Int
+}
+
+func NewInsufficientFundsError(have, want *big.Int) *InsufficientFundsError {
+	return &InsufficientFundsError{have, want}
+}
+
+func (err *InsufficientFundsError) Error() string {
+	return fmt.Sprintf("The unspent outputs of %d can not pay %d.\n", err.have, err.want)
+}
+
+// InvalidTxError transaction is malformed
+type InvalidTxError struct {
+	reason string
+}
+
+func NewInvalidTxError(reason string) *InvalidTxError {
+	return &InvalidTxError{reason}
+}
+
+func (err *InvalidTxError) Error() string {
+	return fmt.Sprintf("The transaction is invalid: %s.\n", err.reason)
+}
+
+// DoubleSpendError output is already spent
+type DoubleSpendError struct {
+	hash []byte
+}
+
+func NewDoubleSpendError(hash []byte) *DoubleSpendError {
+	return &DoubleSpendError{hash}
+}
+
+func (err *DoubleSpendError) Error() string {
+	return fmt.Sprintf("The output %x is already spent.\n", err.hash)
+}
+
+// StaleNonceError plaintext nonce is not the next nonce of the address
+type StaleNonceError struct {
+	nonce, want *big.Int
+}
+
+func NewStaleNonceError(nonce, want *big.Int) *StaleNonceError {
+	return &StaleNonceError{nonce, want}
+}
+
+func (err *StaleNonceError) Error() string {
+	return fmt.Sprintf("The nonce %d is not the next nonce %d of the address.\n", err.nonce, err.want)
+}
+
+// InvalidSnapshotError snapshot is released or unknown
+type InvalidSnapshotError struct {
+	id int
+}
+
+func NewInvalidSnapshotError(id int) *InvalidSnapshotError {
+	return &InvalidSnapshotError{id}
+}
+
+func (err *InvalidSnapshotError) Error() string {
+	return fmt.Sprintf("The snapshot %d can not be rolled back to.\n", err.id)
+}
+
+// MissingKeyError no key is given for the address
+type MissingKeyError struct {
+	address []byte
+}
+
+func NewMissingKeyError(address []byte) *MissingKeyError {
+	return &MissingKeyError{address}
+}
+
+func (err *MissingKeyError) Error() string {
+	return fmt.Sprintf("No key of the address %x is given.\n", err.address)
+}
+
+// MempoolFullError transaction pays too little to stay in the full mempool
+type MempoolFullError struct {}
+
+func NewMempoolFullError() *MempoolFullError {
+	return &MempoolFullError{}
+}
+
+func (err *MempoolFullError) Error() string {
+	return fmt.Sprintf("The mempool is full and the transaction pays too little.\n")
+}
+
+// OutOfGasError contract execution used up its gas
+type OutOfGasError struct {
+	gas uint64
+}
+
+func NewOutOfGasError(gas uint64) *OutOfGasError {
+	return &OutOfGasError{gas}
+}
+
+func (err *OutOfGasError) Error() string {
+	return fmt.Sprintf("The contract ran out of its %d gas.\n", err.gas)
+}
+
+// ContractError contract execution failed at the instruction pc
+type ContractError struct {
+	pc int
+	reason string
+}
+
+func NewContractError(pc int, reason string) *ContractError {
+	return &ContractError{pc, reason}
+}
+
+func (err *ContractError) Error() string {
+	return fmt.Sprintf("The contract failed at %d: %s.\n", err.pc, err.reason)
+}
+
+// InvalidPointError bytes are not a point of the curve
+type InvalidPointError struct {}
+
+func NewInvalidPointError() *InvalidPointError {
+	return &InvalidPointError{}
+}
+
+func (err *InvalidPointError) Error() string {
+	return fmt.Sprintf("The bytes are not a point of the curve.\n")
+}
+
+// UnauthorizedSpendError the key is not the spend key of the slot
+type UnauthorizedSpendError struct {}
+
+func NewUnauthorizedSpendError() *UnauthorizedSpendError {
+	return &UnauthorizedSpendError{}
+}
+
+func (err *UnauthorizedSpendError) Error() string {
+	return fmt.Sprintf("The key can not spend this slot.\n")
+}
+
+// UnauditableError the output can not carry an audit tag while auditing is enabled
+type UnauditableError struct {}
+
+func NewUnauditableError() *UnauditableError {
+	return &UnauditableError{}
+}
+
+func (err *UnauditableError) Error() string {
+	return fmt.Sprintf("Non-solvable outputs can not be audited.\n")
+}
+
+// CorruptedStoreError an entry before the end of the log is damaged
+type CorruptedStoreError struct {
+	path string
+	offset int64
+}
+
+func NewCorruptedStoreError(path string, offset int64) *CorruptedStoreError {
+	return &CorruptedStoreError{path, offset}
+}
+
+func (err *CorruptedStoreError) Error() string {
+	return fmt.Sprintf("The log %s is corrupted at offset %d.\n", err.path, err.offset)
+}
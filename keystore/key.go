@@ -0,0 +1,117 @@
+package keystore
+
+import (
+	"encoding/hex"
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto/privacy"
+	"github.com/Acoustical/maskash/errors"
+	"math/big"
+)
+
+// EncryptPrivateKey stores the PrivateKey with its PlaintextBase and SecretBase
+func EncryptPrivateKey(prv *privacy.PrivateKey, password string, params ScryptParams) (*KeyFile, error) {
+	zqBytes := common.Bn256ZqBits / common.ByteBits
+	secret := make([]byte, zqBytes)
+	kBytes := prv.Bytes()
+	copy(secret[zqBytes-len(kBytes):], kBytes)
+	return Encrypt(KindPrivateKey, secret, prv.GenPlaintextBase().Bytes(), prv.GenSecretBase().Bytes(), password, params)
+}
+
+// DecryptPrivateKey opens the PrivateKey and checks it against the stored bases
+func (file *KeyFile) DecryptPrivateKey(password string) (*privacy.PrivateKey, error) {
+	if file.Kind != KindPrivateKey {return nil, errors.NewUnsupportedKeystoreError("kind", file.Kind)}
+	secret, err := file.Decrypt(password)
+	if err != nil {return nil, err}
+	if len(secret) != common.Bn256ZqBits / common.ByteBits {return nil, errors.NewWrongInputLength(len(secret))}
+
+	prv := &privacy.PrivateKey{Int: new(big.Int).SetBytes(secret)}
+	err = file.checkBases(prv.GenPlaintextBase().Bytes(), prv.GenSecretBase().Bytes())
+	if err != nil {return nil, err}
+	return prv, nil
+}
+
+// EncryptAccountKey stores the AccountKey with its PlaintextBase and AccountBase
+func EncryptAccountKey(key *privacy.AccountKey, password string, params ScryptParams) (*KeyFile, error) {
+	return Encrypt(KindAccountKey, key.Bytes(), key.GenPlaintextBase().Bytes(), key.GenAccountBase().Bytes(), password, params)
+}
+
+// DecryptAccountKey opens the AccountKey and checks it against the stored bases
+func (file *KeyFile) DecryptAccountKey(password string) (*privacy.AccountKey, error) {
+	if file.Kind != KindAccountKey {return nil, errors.NewUnsupportedKeystoreError("kind", file.Kind)}
+	secret, err := file.Decrypt(password)
+	if err != nil {return nil, err}
+
+	key, err := new(privacy.AccountKey).SetBytes(secret)
+	if err != nil {return nil, err}
+	err = file.checkBases(key.GenPlaintextBase().Bytes(), key.GenAccountBase().Bytes())
+	if err != nil {return nil, err}
+	return key, nil
+}
+
+// PlaintextBase returns the stored address without the password
+func (file *KeyFile) PlaintextBase() (*privacy.PlaintextBase, error) {
+	raw, err := hex.DecodeString(file.Address)
+	if err != nil {return nil, err}
+	base := new(privacy.PlaintextBase)
+	err = base.SetBytes(raw)
+	if err != nil {return nil, err}
+	return base, nil
+}
+
+// SecretBase returns the stored base of a PrivateKey file without the password
+func (file *KeyFile) SecretBase() (*privacy.SecretBase, error) {
+	if file.Kind != KindPrivateKey {return nil, errors.NewUnsupportedKeystoreError("kind", file.Kind)}
+	raw, err := hex.DecodeString(file.Base)
+	if err != nil {return nil, err}
+	base := new(privacy.SecretBase)
+	err = base.SetBytes(raw)
+	if err != nil {return nil, err}
+	return base, nil
+}
+
+// AccountBase returns the stored base of an AccountKey file without the password
+func (file *KeyFile) AccountBase() (*privacy.AccountBase, error) {
+	if file.Kind != KindAccountKey {return nil, errors.NewUnsupportedKeystoreError("kind", file.Kind)}
+	raw, err := hex.DecodeString(file.Base)
+	if err != nil {return nil, err}
+	base := new(privacy.AccountBase)
+	err = base.SetBytes(raw)
+	if err != nil {return nil, err}
+	return base, nil
+}
+
+func (file *KeyFile) checkBases(address, base []byte) error {
+	if file.Address != hex.EncodeToString(address) || file.Base != hex.EncodeToString(base) {
+		return errors.NewKeystoreMismatchError()
+	}
+	return nil
+}
+
+// SavePrivateKey encrypts the PrivateKey to path
+func SavePrivateKey(path string, prv *privacy.PrivateKey, password string, params ScryptParams) error {
+	file, err := EncryptPrivateKey(prv, password, params)
+	if err != nil {return err}
+	return file.WriteFile(path)
+}
+
+// LoadPrivateKey decrypts the PrivateKey from path
+func LoadPrivateKey(path, password string) (*privacy.PrivateKey, error) {
+	file, err := ReadFile(path)
+	if err != nil {return nil, err}
+	return file.DecryptPrivateKey(password)
+}
+
+// SaveAccountKey encrypts the AccountKey to path
+func SaveAccountKey(path string, key *privacy.AccountKey, password string, params ScryptParams) error {
+	file, err := EncryptAccountKey(key, password, params)
+	if err != nil {return err}
+	return file.WriteFile(path)
+}
+
+// LoadAccountKey decrypts the AccountKey from path
+func LoadAccountKey(path, password string) (*privacy.AccountKey, error) {
+	file, err := ReadFile(path)
+	if err != nil {return nil, err}
+	return file.DecryptAccountKey(password)
+}
+
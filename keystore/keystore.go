@@ -0,0 +1,202 @@
+package keystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/Acoustical/maskash/errors"
+	"golang.org/x/crypto/scrypt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+const Version int = 1
+
+const KindPrivateKey = "private"
+const KindAccountKey = "account"
+
+const CipherAES256GCM = "aes-256-gcm"
+const KDFScrypt = "scrypt"
+
+const keyLength = 32
+const saltLength = 32
+
+// ScryptParams are the cost parameters of scrypt
+type ScryptParams struct {
+	N int `json:"n"`
+	R int `json:"r"`
+	P int `json:"p"`
+}
+
+// StandardScrypt is for keys at rest, LightScrypt is for tests and low power devices
+var StandardScrypt = ScryptParams{N: 1 << 18, R: 8, P: 1}
+var LightScrypt = ScryptParams{N: 1 << 12, R: 8, P: 1}
+
+// MaxScrypt bounds the parameters read from a file, scrypt needs 128 N R bytes of memory and P times the work
+var MaxScrypt = ScryptParams{N: 1 << 20, R: 8, P: 4}
+
+// check returns an error if the parameters are not accepted by scrypt or exceed MaxScrypt
+func (params ScryptParams) check() error {
+	if params.N <= 1 || params.N & (params.N - 1) != 0 || params.N > MaxScrypt.N ||
+		params.R <= 0 || params.R > MaxScrypt.R || params.P <= 0 || params.P > MaxScrypt.P {
+		return errors.NewUnsupportedKeystoreError("kdfparams", fmt.Sprintf("n=%d r=%d p=%d", params.N, params.R, params.P))
+	}
+	return nil
+}
+
+// KeyFile is the versioned JSON envelope of an encrypted key
+type KeyFile struct {
+	Version int `json:"version"`
+	Kind string `json:"kind"`
+	Address string `json:"address"`
+	Base string `json:"base"`
+	Crypto CryptoJSON `json:"crypto"`
+}
+
+type CryptoJSON struct {
+	Cipher string `json:"cipher"`
+	CipherText string `json:"ciphertext"`
+	Nonce string `json:"nonce"`
+	KDF string `json:"kdf"`
+	KDFParams KDFParamsJSON `json:"kdfparams"`
+}
+
+type KDFParamsJSON struct {
+	ScryptParams
+	Salt string `json:"salt"`
+}
+
+// Encrypt seals secret with password, the header fields are authenticated with the secret
+func Encrypt(kind string, secret []byte, address, base []byte, password string, params ScryptParams) (*KeyFile, error) {
+	file := &KeyFile{
+		Version: Version,
+		Kind: kind,
+		Address: hex.EncodeToString(address),
+		Base: hex.EncodeToString(base),
+	}
+	err := file.seal(secret, password, params)
+	if err != nil {return nil, err}
+	return file, nil
+}
+
+// Decrypt opens the secret with password, any change of the file fails the decryption
+func (file *KeyFile) Decrypt(password string) ([]byte, error) {
+	if file.Version != Version {return nil, errors.NewUnsupportedKeystoreError("version", strconv.Itoa(file.Version))}
+	if file.Crypto.Cipher != CipherAES256GCM {return nil, errors.NewUnsupportedKeystoreError("cipher", file.Crypto.Cipher)}
+	if file.Crypto.KDF != KDFScrypt {return nil, errors.NewUnsupportedKeystoreError("kdf", file.Crypto.KDF)}
+
+	salt, err := hex.DecodeString(file.Crypto.KDFParams.Salt)
+	if err != nil {return nil, err}
+	nonce, err := hex.DecodeString(file.Crypto.Nonce)
+	if err != nil {return nil, err}
+	cipherText, err := hex.DecodeString(file.Crypto.CipherText)
+	if err != nil {return nil, err}
+
+	aead, err := newAEAD(password, salt, file.Crypto.KDFParams.ScryptParams)
+	if err != nil {return nil, err}
+	if len(nonce) != aead.NonceSize() {return nil, errors.NewWrongInputLength(len(nonce))}
+
+	secret, err := aead.Open(nil, nonce, cipherText, file.additionalData())
+	if err != nil {return nil, errors.NewWrongPasswordError()}
+	return secret, nil
+}
+
+// ChangePassword re-encrypts the secret with a new salt and nonce
+func (file *KeyFile) ChangePassword(oldPassword, newPassword string, params ScryptParams) error {
+	secret, err := file.Decrypt(oldPassword)
+	if err != nil {return err}
+	return file.seal(secret, newPassword, params)
+}
+
+func (file *KeyFile) seal(secret []byte, password string, params ScryptParams) error {
+	salt := make([]byte, saltLength)
+	_, err := rand.Read(salt)
+	if err != nil {return err}
+
+	aead, err := newAEAD(password, salt, params)
+	if err != nil {return err}
+	nonce := make([]byte, aead.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {return err}
+
+	file.Crypto = CryptoJSON{
+		Cipher: CipherAES256GCM,
+		Nonce: hex.EncodeToString(nonce),
+		KDF: KDFScrypt,
+		KDFParams: KDFParamsJSON{params, hex.EncodeToString(salt)},
+	}
+	file.Crypto.CipherText = hex.EncodeToString(aead.Seal(nil, nonce, secret, file.additionalData()))
+	return nil
+}
+
+// additionalData binds the header and the kdf parameters to the cipher text
+func (file *KeyFile) additionalData() []byte {
+	fields := []string{
+		file.Kind, file.Address, file.Base,
+		file.Crypto.Cipher, file.Crypto.KDF, file.Crypto.KDFParams.Salt,
+	}
+	data := make([]byte, 16)
+	binary.BigEndian.PutUint32(data[0:4], uint32(file.Version))
+	binary.BigEndian.PutUint32(data[4:8], uint32(file.Crypto.KDFParams.N))
+	binary.BigEndian.PutUint32(data[8:12], uint32(file.Crypto.KDFParams.R))
+	binary.BigEndian.PutUint32(data[12:16], uint32(file.Crypto.KDFParams.P))
+	for _, field := range fields {
+		var length [4]byte
+		binary.BigEndian.PutUint32(length[:], uint32(len(field)))
+		data = append(data, length[:]...)
+		data = append(data, field...)
+	}
+	return data
+}
+
+func newAEAD(password string, salt []byte, params ScryptParams) (cipher.AEAD, error) {
+	err := params.check()
+	if err != nil {return nil, err}
+	key, err := scrypt.Key([]byte(password), salt, params.N, params.R, params.P, keyLength)
+	if err != nil {return nil, err}
+	block, err := aes.NewCipher(key)
+	if err != nil {return nil, err}
+	return cipher.NewGCM(block)
+}
+
+// ReadFile reads a KeyFile from path
+func ReadFile(path string) (*KeyFile, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {return nil, err}
+	file := new(KeyFile)
+	err = json.Unmarshal(raw, file)
+	if err != nil {return nil, err}
+	return file, nil
+}
+
+// WriteFile writes the KeyFile to path through a temporary file, so a crash never leaves half a key
+func (file *KeyFile) WriteFile(path string) error {
+	raw, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {return err}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {return err}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(raw)
+	if err == nil {err = tmp.Chmod(0600)}
+	if err == nil {err = tmp.Sync()}
+	if closeErr := tmp.Close(); err == nil {err = closeErr}
+	if err != nil {return err}
+	return os.Rename(tmp.Name(), path)
+}
+
+// ChangePassword re-encrypts the key file at path
+func ChangePassword(path, oldPassword, newPassword string, params ScryptParams) error {
+	file, err := ReadFile(path)
+	if err != nil {return err}
+	err = file.ChangePassword(oldPassword, newPassword, params)
+	if err != nil {return err}
+	return file.WriteFile(path)
+}
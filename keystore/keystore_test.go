@@ -0,0 +1,85 @@
+package keystore
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/Acoustical/maskash/crypto/privacy"
+	"github.com/Acoustical/maskash/errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const knownPassword = "maskash"
+const knownKey = "1145141919810114514191981011451419198101145141919810"
+
+func TestKnownAnswer(t *testing.T) {
+	k, _ := new(big.Int).SetString(knownKey, 10)
+	known := &privacy.PrivateKey{Int: k}
+
+	prv, err := LoadPrivateKey("testdata/private_v1.json", knownPassword)
+	errors.Handle(err)
+	fmt.Printf("Private Key\n%x\n\n", prv)
+	if prv.Cmp(known.Int) != 0 {
+		t.Errorf("private key known answer mismatch")
+	}
+
+	key, err := LoadAccountKey("testdata/account_v1.json", knownPassword)
+	errors.Handle(err)
+	fmt.Printf("Account Key\n%x\n\n", key.Bytes())
+	if !bytes.Equal(key.Bytes(), privacy.NewAccountKey(known).Bytes()) {
+		t.Errorf("account key known answer mismatch")
+	}
+
+	_, err = LoadPrivateKey("testdata/private_v1.json", "wrong password")
+	if _, ok := err.(*errors.WrongPasswordError); !ok {
+		t.Errorf("wrong password accepted: %v", err)
+	}
+
+	for _, name := range []string{"tampered_address_v1.json", "tampered_ciphertext_v1.json"} {
+		_, err = LoadPrivateKey(filepath.Join("testdata", name), knownPassword)
+		fmt.Printf("Load %s\n%v\n", name, err)
+		if _, ok := err.(*errors.WrongPasswordError); !ok {
+			t.Errorf("tampered file %s accepted: %v", name, err)
+		}
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keystore")
+	errors.Handle(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "key.json")
+
+	prv := privacy.NewRandomPrivateKey()
+	err = SavePrivateKey(path, prv, "old", LightScrypt)
+	errors.Handle(err)
+
+	err = ChangePassword(path, "old", "new", LightScrypt)
+	errors.Handle(err)
+	_, err = LoadPrivateKey(path, "old")
+	if err == nil {
+		t.Errorf("old password still works")
+	}
+	prv0, err := LoadPrivateKey(path, "new")
+	errors.Handle(err)
+	if prv0.Cmp(prv.Int) != 0 {
+		t.Errorf("private key changed by ChangePassword")
+	}
+
+	file, err := ReadFile(path)
+	errors.Handle(err)
+	base, err := file.SecretBase()
+	errors.Handle(err)
+	if !bytes.Equal(base.Bytes(), prv.GenSecretBase().Bytes()) {
+		t.Errorf("stored secret base mismatch")
+	}
+
+	file.Crypto.KDFParams.N = 1 << 40
+	_, err = file.Decrypt("new")
+	if _, ok := err.(*errors.UnsupportedKeystoreError); !ok {
+		t.Errorf("decrypted with scrypt parameters above MaxScrypt")
+	}
+}
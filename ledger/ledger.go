@@ -0,0 +1,230 @@
+package ledger
+
+import (
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/crypto/elgamal"
+	"github.com/Acoustical/maskash/crypto/privacy"
+	"github.com/Acoustical/maskash/errors"
+	"github.com/Acoustical/maskash/storage"
+	"math/big"
+	"sync"
+)
+
+// Account is the state of a Plaintext address, Nonce is the nonce of its next input
+type Account struct {
+	Balance *big.Int
+	Nonce *big.Int
+}
+
+// Ledger is the global state of the chain chainID: the unspent Secret and Anonymous outputs, the Plaintext accounts
+// and the nullifiers of the spent outputs. Every change is journaled so the state can be rolled back
+type Ledger struct {
+	chainID uint64
+	utxos storage.Store
+	nonces NonceRegistry
+	auditor *elgamal.PublicKey
+	assets []privacy.AssetID
+	accounts map[string]*Account
+	nullifiers map[crypto.Hash]bool
+	journal []func() error
+	mu sync.RWMutex
+}
+
+// New returns an empty ledger of the chain chainID keeping the unspent outputs in store and the nonces in nonces,
+// nil keeps them in memory. Confidential outputs must carry an AuditTag to auditor unless it is nil
+func New(chainID uint64, store storage.Store, nonces NonceRegistry, auditor *elgamal.PublicKey) *Ledger {
+	if store == nil {store = storage.NewMemoryStore()}
+	if nonces == nil {nonces = NewMemoryNonceRegistry()}
+	return &Ledger{
+		chainID: chainID,
+		utxos: store,
+		nonces: nonces,
+		auditor: auditor,
+		accounts: make(map[string]*Account),
+		nullifiers: make(map[crypto.Hash]bool),
+	}
+}
+
+// AllowAssets sets the assets the asset tagged outputs may be proven to belong to besides the native asset
+func (ledger *Ledger) AllowAssets(assets ...privacy.AssetID) {
+	ledger.mu.Lock()
+	defer ledger.mu.Unlock()
+	ledger.assets = append([]privacy.AssetID{}, assets...)
+}
+
+func (ledger *Ledger) allowedAssets() []privacy.AssetID {return append([]privacy.AssetID{privacy.NativeAsset}, ledger.assets...)}
+
+// Genesis adds outputs to the state without inputs
+func (ledger *Ledger) Genesis(outputs ...privacy.Slot) error {
+	ledger.mu.Lock()
+	defer ledger.mu.Unlock()
+	u := &update{credits: make(map[string]*big.Int)}
+	for _, slot := range outputs {
+		if slot.SlotMode() & common.TxSlotKind != common.OutputSlot {return errors.NewInvalidTxError("input slot in the genesis")}
+		if confidential(slot) {
+			u.created = append(u.created, &storage.Record{Hash: storage.OutputHash(slot), Owner: slot.Base().Bytes(), Slot: slot, Asset: privacy.NativeAsset})
+			continue
+		}
+		address := string(slot.Base().Bytes())
+		credit, ok := u.credits[address]
+		if !ok {credit = new(big.Int)}
+		u.credits[address] = credit.Add(credit, plaintextAmount(slot))
+	}
+	return ledger.commit(u)
+}
+
+// Apply validates tx against the state and applies it atomically
+func (ledger *Ledger) Apply(tx *Tx) error {
+	ledger.mu.Lock()
+	defer ledger.mu.Unlock()
+	u, err := ledger.validate(tx, ledger.nonces)
+	if err != nil {return err}
+	return ledger.commit(u)
+}
+
+// Validate checks tx against the state without applying it
+func (ledger *Ledger) Validate(tx *Tx) error {
+	ledger.mu.RLock()
+	defer ledger.mu.RUnlock()
+	_, err := ledger.validate(tx, ledger.nonces)
+	return err
+}
+
+// ValidatePending checks tx against the state with the next nonces of nonces, such as the nonces after the pending transactions
+func (ledger *Ledger) ValidatePending(tx *Tx, nonces NonceRegistry) error {
+	ledger.mu.RLock()
+	defer ledger.mu.RUnlock()
+	_, err := ledger.validate(tx, nonces)
+	return err
+}
+
+// commit applies the state change, a failure of the store rolls the change back
+func (ledger *Ledger) commit(u *update) error {
+	snapshot := len(ledger.journal)
+	err := ledger.write(u)
+	if err != nil {
+		_ = ledger.rollback(snapshot)
+		return err
+	}
+	return nil
+}
+
+func (ledger *Ledger) write(u *update) error {
+	for _, hash := range u.spent {
+		record, err := ledger.utxos.Get(hash)
+		if err != nil {return err}
+		err = ledger.utxos.Delete(hash)
+		if err != nil {return err}
+		ledger.nullifiers[hash] = true
+		h := hash
+		ledger.journal = append(ledger.journal, func() error {
+			delete(ledger.nullifiers, h)
+			return ledger.utxos.Put(record)
+		})
+	}
+	for _, record := range u.created {
+		err := ledger.utxos.Put(record)
+		if err != nil {return err}
+		hash := record.Hash
+		ledger.journal = append(ledger.journal, func() error {return ledger.utxos.Delete(hash)})
+	}
+	for address, nonce := range u.nonces {
+		err := ledger.setNonce(address, nonce)
+		if err != nil {return err}
+	}
+	for address, debit := range u.debits {
+		ledger.setBalance(address, new(big.Int).Sub(ledger.balance(address), debit))
+	}
+	for address, credit := range u.credits {
+		ledger.setBalance(address, new(big.Int).Add(ledger.balance(address), credit))
+	}
+	return nil
+}
+
+// balance returns the balance of address, zero for an unknown address
+func (ledger *Ledger) balance(address string) *big.Int {
+	if account, ok := ledger.accounts[address]; ok {return account.Balance}
+	return new(big.Int)
+}
+
+// setBalance journals the balance of address and sets it
+func (ledger *Ledger) setBalance(address string, balance *big.Int) {
+	old, existed := ledger.accounts[address]
+	ledger.accounts[address] = &Account{Balance: balance}
+	ledger.journal = append(ledger.journal, func() error {
+		if existed {
+			ledger.accounts[address] = old
+		} else {
+			delete(ledger.accounts, address)
+		}
+		return nil
+	})
+}
+
+// setNonce journals the next nonce of address and sets it
+func (ledger *Ledger) setNonce(address string, nonce *big.Int) error {
+	old, err := ledger.nonces.Next([]byte(address))
+	if err != nil {return err}
+	err = ledger.nonces.SetNext([]byte(address), nonce)
+	if err != nil {return err}
+	ledger.journal = append(ledger.journal, func() error {return ledger.nonces.SetNext([]byte(address), old)})
+	return nil
+}
+
+// Snapshot returns the id of the current state for Rollback
+func (ledger *Ledger) Snapshot() int {
+	ledger.mu.RLock()
+	defer ledger.mu.RUnlock()
+	return len(ledger.journal)
+}
+
+// Rollback reverts the state to the snapshot, the later snapshots are invalidated
+func (ledger *Ledger) Rollback(snapshot int) error {
+	ledger.mu.Lock()
+	defer ledger.mu.Unlock()
+	return ledger.rollback(snapshot)
+}
+
+func (ledger *Ledger) rollback(snapshot int) error {
+	if snapshot < 0 || snapshot > len(ledger.journal) {return errors.NewInvalidSnapshotError(snapshot)}
+	for i := len(ledger.journal)-1; i >= snapshot; i-- {
+		err := ledger.journal[i]()
+		ledger.journal = ledger.journal[:i]
+		if err != nil {return err}
+	}
+	return nil
+}
+
+// Commit forgets the journal, the state can no longer be rolled back to the earlier snapshots
+func (ledger *Ledger) Commit() {
+	ledger.mu.Lock()
+	defer ledger.mu.Unlock()
+	ledger.journal = nil
+}
+
+// Account returns the balance and the next nonce of a Plaintext address
+func (ledger *Ledger) Account(base *privacy.PlaintextBase) (*Account, error) {
+	ledger.mu.RLock()
+	defer ledger.mu.RUnlock()
+	nonce, err := ledger.nonces.Next(base.Bytes())
+	if err != nil {return nil, err}
+	return &Account{new(big.Int).Set(ledger.balance(string(base.Bytes()))), nonce}, nil
+}
+
+// ChainID returns the chain ID the plaintext inputs sign
+func (ledger *Ledger) ChainID() uint64 {return ledger.chainID}
+
+// Unspent returns the record of an unspent Secret or Anonymous output
+func (ledger *Ledger) Unspent(hash crypto.Hash) (*storage.Record, error) {
+	ledger.mu.RLock()
+	defer ledger.mu.RUnlock()
+	return ledger.utxos.Get(hash)
+}
+
+// Spent returns whether the nullifier of an output is recorded
+func (ledger *Ledger) Spent(hash crypto.Hash) bool {
+	ledger.mu.RLock()
+	defer ledger.mu.RUnlock()
+	return ledger.nullifiers[hash]
+}
@@ -0,0 +1,150 @@
+package ledger
+
+import (
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/crypto/privacy"
+	"github.com/Acoustical/maskash/errors"
+	"github.com/Acoustical/maskash/storage"
+	"math/big"
+	"testing"
+)
+
+func TestLedger(t *testing.T) {
+	alice, bob := privacy.NewRandomPrivateKey(), privacy.NewRandomPrivateKey()
+	ledger := New(1, nil, nil, nil)
+	rl, _ := crypto.RandomZq(3)
+
+	funds, err := alice.GenPlaintextBase().NewPlaintextOutputSlot(big.NewInt(1000), common.NoneContractSlot, nil)
+	errors.Handle(err)
+	coin, err := alice.GenSecretBase().NewSecretOutputSlot(big.NewInt(500), rl[0], true, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+	errors.Handle(ledger.Genesis(funds, coin))
+
+	paid, err := bob.GenPlaintextBase().NewPlaintextOutputSlot(big.NewInt(290), common.NoneContractSlot, nil)
+	errors.Handle(err)
+	tx1 := &Tx{Inputs: []privacy.Slot{alice.NewPlaintextInputSlot(big.NewInt(0), big.NewInt(300))}, Outputs: []privacy.Slot{paid}, Fee: big.NewInt(10)}
+	errors.Handle(tx1.Sign(1, alice))
+	errors.Handle(ledger.Apply(tx1))
+	if account, _ := ledger.Account(alice.GenPlaintextBase()); account.Balance.Int64() != 700 || account.Nonce.Int64() != 1 {
+		t.Errorf("alice account %d with nonce %d, want 700 with nonce 1", account.Balance, account.Nonce)
+	}
+	if _, ok := ledger.Apply(tx1).(*errors.StaleNonceError); !ok {
+		t.Errorf("ledger applied a replayed plaintext input")
+	}
+	tx1.Inputs = []privacy.Slot{alice.NewPlaintextInputSlot(big.NewInt(1), big.NewInt(100))}
+	errors.Handle(tx1.Sign(1, alice))
+	if ledger.Apply(tx1) == nil {
+		t.Errorf("ledger applied an unbalanced transaction")
+	}
+
+	snapshot := ledger.Snapshot()
+	out, err := bob.GenAnonymousBase().NewAnonymousOutputSlot(big.NewInt(400), rl[1], true, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+	change, err := alice.GenSecretBase().NewSecretOutputSlot(big.NewInt(100), rl[2], true, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+	input := privacy.NewSecretInputSlot(coin)
+	balance, err := privacy.NewBalance([]*privacy.Input{{Slot: input, Key: alice}}, []*privacy.Output{{Slot: out, Value: big.NewInt(400), R: rl[1]}, {Slot: change, Value: big.NewInt(100), R: rl[2]}}, nil)
+	errors.Handle(err)
+	tx2 := &Tx{Inputs: []privacy.Slot{input}, Outputs: []privacy.Slot{out, change}, Balance: balance}
+	errors.Handle(tx2.Sign(1, alice))
+	errors.Handle(ledger.Apply(tx2))
+	if !ledger.Spent(storage.OutputHash(coin)) {
+		t.Errorf("ledger did not record the nullifier")
+	}
+	if _, ok := ledger.Apply(tx2).(*errors.DoubleSpendError); !ok {
+		t.Errorf("ledger applied a double spend")
+	}
+
+	errors.Handle(ledger.Rollback(snapshot))
+	if ledger.Spent(storage.OutputHash(coin)) {
+		t.Errorf("ledger rollback kept the nullifier")
+	}
+	if _, err = ledger.Unspent(storage.OutputHash(coin)); err != nil {
+		t.Errorf("ledger rollback did not restore the output")
+	}
+	if _, err = ledger.Unspent(storage.OutputHash(out)); err == nil {
+		t.Errorf("ledger rollback kept the created output")
+	}
+	errors.Handle(ledger.Rollback(0))
+	if account, _ := ledger.Account(alice.GenPlaintextBase()); account.Balance.Sign() != 0 || account.Nonce.Sign() != 0 {
+		t.Errorf("ledger rollback kept the alice account")
+	}
+}
+
+func TestLedgerSpend(t *testing.T) {
+	owner := privacy.NewSeparateAccountKey(privacy.NewRandomPrivateKey(), privacy.NewRandomPrivateKey())
+	thief := privacy.NewRandomPrivateKey()
+	ledger := New(1, nil, nil, nil)
+	rl, _ := crypto.RandomZq(3)
+	coin, err := owner.GenSecretBase().NewSecretOutputSlot(big.NewInt(500), rl[0], true, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+	errors.Handle(ledger.Genesis(coin))
+
+	// the view key solves the coin for the balance but can not spend it
+	input := privacy.NewSecretInputSlot(coin)
+	stolen, err := thief.GenSecretBase().NewSecretOutputSlot(big.NewInt(500), rl[1], true, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+	balance, err := privacy.NewBalance([]*privacy.Input{{Slot: input, Key: owner.ViewKey().Key()}}, []*privacy.Output{{Slot: stolen, Value: big.NewInt(500), R: rl[1]}}, nil)
+	errors.Handle(err)
+	theft := &Tx{Inputs: []privacy.Slot{input}, Outputs: []privacy.Slot{stolen}, Balance: balance}
+	if _, ok := theft.Sign(1, owner.ViewKey().Key(), thief).(*errors.MissingKeyError); !ok {
+		t.Errorf("spend proof made without the spend key")
+	}
+	if _, ok := ledger.Apply(theft).(*errors.UnauthorizedSpendError); !ok {
+		t.Errorf("ledger applied a confidential input without spend proof")
+	}
+
+	paid, err := owner.GenSecretBase().NewSecretOutputSlot(big.NewInt(500), rl[2], true, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+	balance, err = privacy.NewBalance([]*privacy.Input{{Slot: input, Key: owner.ViewKey().Key()}}, []*privacy.Output{{Slot: paid, Value: big.NewInt(500), R: rl[2]}}, nil)
+	errors.Handle(err)
+	tx := &Tx{Inputs: []privacy.Slot{input}, Outputs: []privacy.Slot{paid}, Balance: balance}
+	errors.Handle(tx.Sign(1, owner.SpendKey()))
+
+	// the spend proof of another transaction does not authorize the theft, nor does it keep the hash
+	hash := theft.Hash()
+	theft.Spends = tx.Spends
+	if theft.Hash() == hash {
+		t.Errorf("transaction hash does not cover the spend proofs")
+	}
+	if _, ok := ledger.Apply(theft).(*errors.UnauthorizedSpendError); !ok {
+		t.Errorf("ledger applied a confidential input with a replayed spend proof")
+	}
+	errors.Handle(ledger.Apply(tx))
+}
+
+func TestLedgerAsset(t *testing.T) {
+	alice, bob := privacy.NewRandomPrivateKey(), privacy.NewRandomPrivateKey()
+	gold, silver := privacy.NewAssetID("gold"), privacy.NewAssetID("silver")
+	ledger := New(1, nil, nil, nil)
+	ledger.AllowAssets(gold)
+	rl, _ := crypto.RandomZq(3)
+	coin, err := alice.GenSecretBase().NewSecretAssetOutputSlot(big.NewInt(100), rl[0], gold, []privacy.AssetID{privacy.NativeAsset, gold}, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+	errors.Handle(ledger.Genesis(coin))
+
+	input := privacy.NewSecretInputSlot(coin)
+	transfer := func(assets []privacy.AssetID, v int64, proved bool) *Tx {
+		out, err := bob.GenSecretBase().NewSecretAssetOutputSlot(big.NewInt(v), rl[1], gold, assets, common.NoneContractSlot, nil, nil)
+		errors.Handle(err)
+		tx := &Tx{Inputs: []privacy.Slot{input}, Outputs: []privacy.Slot{out}}
+		if proved {
+			tx.AssetBalance, err = privacy.NewAssetBalance([]*privacy.AssetInput{{Slot: input, Key: alice}}, []*privacy.AssetOutput{{Slot: out, Value: big.NewInt(v), R: rl[1]}})
+			errors.Handle(err)
+		}
+		errors.Handle(tx.Sign(1, alice))
+		return tx
+	}
+
+	if _, ok := ledger.Apply(transfer([]privacy.AssetID{gold, silver}, 100, true)).(*errors.AssetNotAllowedError); !ok {
+		t.Errorf("ledger applied an output of a disallowed asset set")
+	}
+	if _, ok := ledger.Apply(transfer([]privacy.AssetID{gold}, 100, false)).(*errors.InvalidTxError); !ok {
+		t.Errorf("ledger applied asset tagged slots without an asset balance")
+	}
+	errors.Handle(ledger.Apply(transfer([]privacy.AssetID{gold}, 100, true)))
+	if !ledger.Spent(storage.OutputHash(coin)) {
+		t.Errorf("ledger did not spend the asset tagged input")
+	}
+}
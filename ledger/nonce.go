@@ -0,0 +1,41 @@
+package ledger
+
+import (
+	"math/big"
+	"sync"
+)
+
+// NonceRegistry keeps the nonce of the next input of each Plaintext address
+type NonceRegistry interface {
+	Next(address []byte) (*big.Int, error)
+	SetNext(address []byte, nonce *big.Int) error
+}
+
+// MemoryNonceRegistry keeps the nonces in memory, it is safe for concurrent use
+type MemoryNonceRegistry struct {
+	nonces map[string]*big.Int
+	mu sync.RWMutex
+}
+
+func NewMemoryNonceRegistry() *MemoryNonceRegistry {
+	return &MemoryNonceRegistry{nonces: make(map[string]*big.Int)}
+}
+
+// Next returns the next nonce of address, zero for an unknown address
+func (registry *MemoryNonceRegistry) Next(address []byte) (*big.Int, error) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	if nonce, ok := registry.nonces[string(address)]; ok {return new(big.Int).Set(nonce), nil}
+	return new(big.Int), nil
+}
+
+func (registry *MemoryNonceRegistry) SetNext(address []byte, nonce *big.Int) error {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if nonce.Sign() == 0 {
+		delete(registry.nonces, string(address))
+	} else {
+		registry.nonces[string(address)] = new(big.Int).Set(nonce)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package ledger
+
+import (
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto/privacy"
+	"github.com/Acoustical/maskash/errors"
+	"math/big"
+	"testing"
+)
+
+func TestReplayProtection(t *testing.T) {
+	alice, bob := privacy.NewRandomPrivateKey(), privacy.NewRandomPrivateKey()
+	nonces := NewMemoryNonceRegistry()
+	ledger := New(7, nil, nonces, nil)
+	funds, err := alice.GenPlaintextBase().NewPlaintextOutputSlot(big.NewInt(1000), common.NoneContractSlot, nil)
+	errors.Handle(err)
+	errors.Handle(ledger.Genesis(funds))
+
+	pay := func(nonce int64, chainID uint64) *Tx {
+		out, err := bob.GenPlaintextBase().NewPlaintextOutputSlot(big.NewInt(100), common.NoneContractSlot, nil)
+		errors.Handle(err)
+		tx := &Tx{Inputs: []privacy.Slot{alice.NewPlaintextInputSlot(big.NewInt(nonce), big.NewInt(100))}, Outputs: []privacy.Slot{out}}
+		errors.Handle(tx.Sign(chainID, alice))
+		return tx
+	}
+
+	if ledger.Apply(pay(0, 8)) == nil {
+		t.Errorf("ledger applied a transaction signed for another chain")
+	}
+	if _, ok := ledger.Apply(pay(1, 7)).(*errors.StaleNonceError); !ok {
+		t.Errorf("ledger applied an out of order nonce")
+	}
+	tx := pay(0, 7)
+	tx.Outputs[0], _ = alice.GenPlaintextBase().NewPlaintextOutputSlot(big.NewInt(100), common.NoneContractSlot, nil)
+	if ledger.Apply(tx) == nil {
+		t.Errorf("ledger applied a transaction with a swapped output")
+	}
+
+	errors.Handle(ledger.Apply(pay(0, 7)))
+	errors.Handle(ledger.Apply(pay(1, 7)))
+	if _, ok := ledger.Apply(pay(1, 7)).(*errors.StaleNonceError); !ok {
+		t.Errorf("ledger applied a reused nonce")
+	}
+	if next, _ := nonces.Next(alice.GenPlaintextBase().Bytes()); next.Int64() != 2 {
+		t.Errorf("next nonce %d, want 2", next)
+	}
+}
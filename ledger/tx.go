@@ -0,0 +1,265 @@
+package ledger
+
+import (
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/crypto/privacy"
+	"github.com/Acoustical/maskash/errors"
+	"github.com/Acoustical/maskash/storage"
+	"math/big"
+)
+
+// Tx moves value from its inputs to its outputs and the public fee. Balance proves the native Secret and Anonymous
+// slots, its fee is the amount leaving the confidential slots, so plaintext inputs + Balance.Fee = plaintext outputs + Fee.
+// AssetBalance proves every asset of the asset tagged slots is conserved. Spends authorizes the Secret and Anonymous
+// inputs in order with the spend keys of the outputs they spend
+type Tx struct {
+	Inputs []privacy.Slot
+	Outputs []privacy.Slot
+	Balance *privacy.Balance
+	AssetBalance *privacy.AssetBalance
+	Spends []*privacy.SpendProof
+	Fee *big.Int
+}
+
+func (tx *Tx) Bytes() []byte {
+	bytes := make([]byte, 0)
+	for _, slot := range tx.Inputs {
+		bytes = append(bytes, slot.Bytes()...)
+	}
+	for _, slot := range tx.Outputs {
+		bytes = append(bytes, slot.Bytes()...)
+	}
+	if tx.Fee != nil {bytes = append(bytes, tx.Fee.Bytes()...)}
+	if tx.Balance != nil {bytes = append(bytes, tx.Balance.Bytes()...)}
+	if tx.AssetBalance != nil {bytes = append(bytes, tx.AssetBalance.Bytes()...)}
+	for _, spend := range tx.Spends {
+		bytes = append(bytes, spend.Bytes()...)
+	}
+	return bytes
+}
+
+// Hash returns the hash of the slots, the fee and the proofs, so the transaction root of a block commits to the proofs
+func (tx *Tx) Hash() crypto.Hash {return crypto.Hash_(tx)}
+
+// SigHash returns the message signed by the inputs, it binds the chain ID and every slot
+// except the signatures of the plaintext inputs
+func (tx *Tx) SigHash(chainID uint64) crypto.Hash {
+	args := []crypto.HashVariable{txDomain, new(big.Int).SetUint64(chainID)}
+	for _, slot := range tx.Inputs {
+		if slot.SlotMode() & common.PrivacyMode == common.Plaintext {
+			args = append(args, crypto.HashBytes{slot.SlotMode()}, slot.Base(), slot.Value())
+		} else {
+			args = append(args, slot)
+		}
+	}
+	for _, slot := range tx.Outputs {
+		args = append(args, slot)
+	}
+	fee := tx.Fee
+	if fee == nil {fee = new(big.Int)}
+	return crypto.Hash_(append(args, fee)...)
+}
+
+// Sign signs the plaintext inputs for the chain chainID with the keys of their addresses and proves the spend keys
+// of the confidential inputs into Spends
+func (tx *Tx) Sign(chainID uint64, keys ...*privacy.PrivateKey) error {
+	e := tx.SigHash(chainID).BigInt()
+	tx.Spends = nil
+	for _, slot := range tx.Inputs {
+		if confidential(slot) {
+			proof, err := spendProof(slot, e, keys)
+			if err != nil {return err}
+			tx.Spends = append(tx.Spends, proof)
+			continue
+		}
+		plaintext, ok := slot.(*privacy.PlaintextSlot)
+		if !ok {continue}
+		signed := false
+		for _, key := range keys {
+			if string(key.GenPlaintextBase().Bytes()) != string(plaintext.Base().Bytes()) {continue}
+			err := plaintext.Sign(key, e)
+			if err != nil {return err}
+			signed = true
+			break
+		}
+		if !signed {return errors.NewMissingKeyError(plaintext.Base().Bytes())}
+	}
+	return nil
+}
+
+// spendProof proves the spend key of a confidential input among keys for the message e
+func spendProof(slot privacy.Slot, e *big.Int, keys []*privacy.PrivateKey) (*privacy.SpendProof, error) {
+	for _, key := range keys {
+		proof, err := privacy.NewSpendProof(key, slot, e)
+		if _, ok := err.(*errors.UnauthorizedSpendError); ok {continue}
+		return proof, err
+	}
+	return nil, errors.NewMissingKeyError(slot.Base().Bytes())
+}
+
+var txDomain = crypto.HashBytes("maskash tx")
+
+// GasSlots returns the Plaintext outputs marked by the gas slot bits, they are credited to their address like any output
+func (tx *Tx) GasSlots() []privacy.Slot {
+	slots := make([]privacy.Slot, 0)
+	for _, slot := range tx.Outputs {
+		if slot.SlotMode() & common.IsGasSlot != 0 && slot.SlotMode() & common.PrivacyMode == common.Plaintext {slots = append(slots, slot)}
+	}
+	return slots
+}
+
+// GasFee returns the public fee, which validate burns from the inputs, and the amounts of the gas slots paid to an
+// address that is not the base of an input. A gas slot paying the sender back buys nothing
+func (tx *Tx) GasFee() *big.Int {
+	fee := new(big.Int)
+	if tx.Fee != nil {fee.Set(tx.Fee)}
+	for _, slot := range tx.GasSlots() {
+		if tx.sender(slot.Base()) {continue}
+		v, err := slot.Value().Solve(nil)
+		if err == nil {fee.Add(fee, v)}
+	}
+	return fee
+}
+
+// sender returns whether base is the base of an input of tx
+func (tx *Tx) sender(base privacy.Base) bool {
+	for _, input := range tx.Inputs {
+		if string(input.Base().Bytes()) == string(base.Bytes()) {return true}
+	}
+	return false
+}
+
+// confidential returns whether the slot is a Secret or Anonymous slot
+func confidential(slot privacy.Slot) bool {
+	mode := slot.SlotMode() & common.PrivacyMode
+	return mode == common.Secret || mode == common.Anonymous
+}
+
+// plaintextAmount returns the amount of a Plaintext slot
+func plaintextAmount(slot privacy.Slot) *big.Int {
+	v, _ := slot.Value().Solve(nil)
+	return v
+}
+
+// update is the state change of a validated transaction
+type update struct {
+	spent []crypto.Hash
+	created []*storage.Record
+	debits, credits map[string]*big.Int
+	nonces map[string]*big.Int
+}
+
+// validate checks tx against the state and the next nonces of nonces without changing them, returns its state change
+func (ledger *Ledger) validate(tx *Tx, nonces NonceRegistry) (*update, error) {
+	if len(tx.Inputs) + len(tx.Outputs) == 0 {return nil, errors.NewInvalidTxError("no slot")}
+	fee := tx.Fee
+	if fee == nil {fee = new(big.Int)}
+	if fee.Sign() < 0 {return nil, errors.NewInvalidTxError("negative fee")}
+
+	e := tx.SigHash(ledger.chainID).BigInt()
+	u := &update{debits: make(map[string]*big.Int), credits: make(map[string]*big.Int), nonces: make(map[string]*big.Int)}
+	plaintextIn, plaintextOut := new(big.Int), new(big.Int)
+	confidentialIn := make(map[crypto.Hash]int)
+	confidentialOut := make(map[crypto.Hash]int)
+	taggedIn := make(map[crypto.Hash]int)
+	taggedOut := make(map[crypto.Hash]int)
+
+	for _, slot := range tx.Inputs {
+		if slot.SlotMode() & common.TxSlotKind != common.InputSlot {return nil, errors.NewInvalidTxError("output slot in the inputs")}
+		if confidential(slot) {
+			hash := storage.OutputHash(slot)
+			if confidentialIn[hash] > 0 || taggedIn[hash] > 0 || ledger.nullifiers[hash] {return nil, errors.NewDoubleSpendError(hash.Bytes())}
+			record, err := ledger.utxos.Get(hash)
+			if err != nil {return nil, err}
+			spend := len(u.spent)
+			if spend >= len(tx.Spends) || !tx.Spends[spend].Check(record.Slot, e) {return nil, errors.NewUnauthorizedSpendError()}
+			// the stored output tells whether the input is asset tagged, not the input itself
+			if privacy.AssetTagOf(record.Slot) != nil {
+				taggedIn[hash]++
+			} else {
+				confidentialIn[hash]++
+			}
+			u.spent = append(u.spent, hash)
+			continue
+		}
+		plaintext, ok := slot.(*privacy.PlaintextSlot)
+		if !ok || !plaintext.CheckSignature(e) {return nil, errors.NewInvalidTxError("bad plaintext input")}
+		address := string(slot.Base().Bytes())
+		nonce, _ := plaintext.Nonce()
+		want, ok := u.nonces[address]
+		if !ok {
+			var err error
+			want, err = nonces.Next([]byte(address))
+			if err != nil {return nil, err}
+		}
+		if nonce == nil || nonce.Cmp(want) != 0 {return nil, errors.NewStaleNonceError(nonce, want)}
+		u.nonces[address] = new(big.Int).Add(want, big.NewInt(1))
+
+		v := plaintextAmount(slot)
+		debit, ok := u.debits[address]
+		if !ok {debit = new(big.Int)}
+		debit.Add(debit, v)
+		u.debits[address] = debit
+		if balance := ledger.balance(address); balance.Cmp(debit) < 0 {return nil, errors.NewInsufficientFundsError(balance, debit)}
+		plaintextIn.Add(plaintextIn, v)
+	}
+
+	if len(tx.Spends) != len(u.spent) {return nil, errors.NewInvalidTxError("spend proofs do not match the confidential inputs")}
+
+	for _, slot := range tx.Outputs {
+		if slot.SlotMode() & common.TxSlotKind != common.OutputSlot {return nil, errors.NewInvalidTxError("input slot in the outputs")}
+		if confidential(slot) {
+			if !slot.CheckZKs() || !privacy.CheckAudit(slot, ledger.auditor) {return nil, errors.NewInvalidTxError("bad confidential output")}
+			hash := storage.OutputHash(slot)
+			if confidentialOut[hash] > 0 || taggedOut[hash] > 0 || ledger.nullifiers[hash] {return nil, errors.NewInvalidTxError("output exists")}
+			if _, err := ledger.utxos.Get(hash); err == nil {return nil, errors.NewInvalidTxError("output exists")}
+			if tag := privacy.AssetTagOf(slot); tag != nil {
+				err := tag.CheckAllowed(ledger.allowedAssets())
+				if err != nil {return nil, err}
+				taggedOut[hash]++
+			} else {
+				confidentialOut[hash]++
+			}
+			u.created = append(u.created, &storage.Record{Hash: hash, Owner: slot.Base().Bytes(), Slot: slot, Asset: privacy.NativeAsset})
+			continue
+		}
+		if slot.SlotMode() & common.PrivacyMode != common.Plaintext {return nil, errors.NewInvalidTxError("bad plaintext output")}
+		v := plaintextAmount(slot)
+		if v.Sign() < 0 {return nil, errors.NewInvalidTxError("negative output")}
+		address := string(slot.Base().Bytes())
+		credit, ok := u.credits[address]
+		if !ok {credit = new(big.Int)}
+		u.credits[address] = credit.Add(credit, v)
+		plaintextOut.Add(plaintextOut, v)
+	}
+
+	confidentialFee := new(big.Int)
+	if len(confidentialIn) + len(confidentialOut) > 0 {
+		if tx.Balance == nil || !covers(tx.Balance.Inputs, confidentialIn) || !covers(tx.Balance.Outputs, confidentialOut) {return nil, errors.NewInvalidTxError("balance does not cover the confidential slots")}
+		if !tx.Balance.Check() {return nil, errors.NewUnbalancedError()}
+		confidentialFee = tx.Balance.Fee
+	} else if tx.Balance != nil {
+		return nil, errors.NewInvalidTxError("balance without confidential slots")
+	}
+	if len(taggedIn) + len(taggedOut) > 0 {
+		if tx.AssetBalance == nil || !covers(tx.AssetBalance.Inputs, taggedIn) || !covers(tx.AssetBalance.Outputs, taggedOut) {return nil, errors.NewInvalidTxError("asset balance does not cover the asset tagged slots")}
+		if !tx.AssetBalance.Check() {return nil, errors.NewUnbalancedError()}
+	} else if tx.AssetBalance != nil {
+		return nil, errors.NewInvalidTxError("asset balance without asset tagged slots")
+	}
+	if plaintextIn.Add(plaintextIn, confidentialFee).Cmp(plaintextOut.Add(plaintextOut, fee)) != 0 {return nil, errors.NewUnbalancedError()}
+	return u, nil
+}
+
+// covers returns whether slots are exactly the slots of hashes
+func covers(slots []privacy.Slot, hashes map[crypto.Hash]int) bool {
+	if len(slots) != len(hashes) {return false}
+	seen := make(map[crypto.Hash]bool)
+	for _, slot := range slots {
+		hash := storage.OutputHash(slot)
+		if hashes[hash] == 0 || seen[hash] {return false}
+		seen[hash] = true
+	}
+	return true
+}
@@ -0,0 +1,312 @@
+package mempool
+
+import (
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/crypto/privacy"
+	"github.com/Acoustical/maskash/errors"
+	"github.com/Acoustical/maskash/ledger"
+	"github.com/Acoustical/maskash/storage"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+)
+
+const DefaultMaxSize int = 32 << 20
+const DefaultMaxAge = 3 * time.Hour
+
+// entry is a pending transaction with the inputs it spends
+type entry struct {
+	tx *ledger.Tx
+	hash crypto.Hash
+	fee *big.Int
+	size int
+	added time.Time
+	spends []crypto.Hash
+	nonces map[string]*big.Int
+	debits map[string]*big.Int
+}
+
+// pays returns whether a pays a higher fee per byte than b, fee_a / size_a > fee_b / size_b, the older first on a tie
+func (a *entry) pays(b *entry) bool {
+	left := new(big.Int).Mul(a.fee, big.NewInt(int64(b.size)))
+	right := new(big.Int).Mul(b.fee, big.NewInt(int64(a.size)))
+	if c := left.Cmp(right); c != 0 {return c > 0}
+	return a.added.Before(b.added)
+}
+
+// Mempool holds the pending transactions validated against a ledger. The spent outputs and the plaintext nonces
+// of the pending transactions are tracked, so a conflicting transaction is rejected on admission
+type Mempool struct {
+	ledger *ledger.Ledger
+	txs map[crypto.Hash]*entry
+	spends map[crypto.Hash]crypto.Hash
+	nonces map[string]*big.Int
+	debits map[string]*big.Int
+	size int
+	// MaxSize bounds the total size of the transactions in bytes, MaxAge bounds the time a transaction is kept
+	MaxSize int
+	MaxAge time.Duration
+	now func() time.Time
+	mu sync.Mutex
+}
+
+func New(l *ledger.Ledger) *Mempool {
+	return &Mempool{
+		ledger: l,
+		txs: make(map[crypto.Hash]*entry),
+		spends: make(map[crypto.Hash]crypto.Hash),
+		nonces: make(map[string]*big.Int),
+		debits: make(map[string]*big.Int),
+		MaxSize: DefaultMaxSize,
+		MaxAge: DefaultMaxAge,
+		now: time.Now,
+	}
+}
+
+// next returns the next nonce of address after the pending transactions
+func (pool *Mempool) next(address string) (*big.Int, error) {
+	if nonce, ok := pool.nonces[address]; ok {return new(big.Int).Set(nonce), nil}
+	return pool.ledgerNonce(address)
+}
+
+func (pool *Mempool) ledgerNonce(address string) (*big.Int, error) {
+	base := new(privacy.PlaintextBase)
+	err := base.SetBytes([]byte(address))
+	if err != nil {return nil, err}
+	account, err := pool.ledger.Account(base)
+	if err != nil {return nil, err}
+	return account.Nonce, nil
+}
+
+// Add validates tx with every proof against the ledger after the pending transactions and admits it
+func (pool *Mempool) Add(tx *ledger.Tx) error {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	e := &entry{tx: tx, hash: tx.Hash(), fee: tx.GasFee(), size: len(tx.Bytes()), added: pool.now()}
+	if _, ok := pool.txs[e.hash]; ok {return errors.NewInvalidTxError("known transaction")}
+
+	e.nonces = make(map[string]*big.Int)
+	e.debits = make(map[string]*big.Int)
+	for _, slot := range tx.Inputs {
+		if slot.SlotMode() & common.PrivacyMode == common.Plaintext {
+			address := string(slot.Base().Bytes())
+			nonce, _ := slot.(*privacy.PlaintextSlot).Nonce()
+			if _, ok := e.nonces[address]; !ok {e.nonces[address] = nonce}
+			v, _ := slot.Value().Solve(nil)
+			debit, ok := e.debits[address]
+			if !ok {debit = new(big.Int)}
+			e.debits[address] = debit.Add(debit, v)
+			continue
+		}
+		hash := storage.OutputHash(slot)
+		if _, ok := pool.spends[hash]; ok {return errors.NewDoubleSpendError(hash.Bytes())}
+		e.spends = append(e.spends, hash)
+	}
+
+	overlay := ledger.NewMemoryNonceRegistry()
+	for address := range e.nonces {
+		next, err := pool.next(address)
+		if err != nil {return err}
+		_ = overlay.SetNext([]byte(address), next)
+	}
+	err := pool.ledger.ValidatePending(tx, overlay)
+	if err != nil {return err}
+	for address, debit := range e.debits {
+		base := new(privacy.PlaintextBase)
+		_ = base.SetBytes([]byte(address))
+		account, err := pool.ledger.Account(base)
+		if err != nil {return err}
+		pending := new(big.Int).Add(debit, pool.debit(address))
+		if account.Balance.Cmp(pending) < 0 {return errors.NewInsufficientFundsError(account.Balance, pending)}
+	}
+
+	pool.insert(e)
+	for pool.size > pool.MaxSize {
+		lowest := pool.lowest()
+		pool.remove(lowest, true)
+		if lowest == e {return errors.NewMempoolFullError()}
+	}
+	return nil
+}
+
+func (pool *Mempool) debit(address string) *big.Int {
+	if debit, ok := pool.debits[address]; ok {return debit}
+	return new(big.Int)
+}
+
+func (pool *Mempool) insert(e *entry) {
+	pool.txs[e.hash] = e
+	pool.size += e.size
+	for _, hash := range e.spends {
+		pool.spends[hash] = e.hash
+	}
+	for address, nonce := range e.nonces {
+		next := new(big.Int).Add(nonce, big.NewInt(int64(pool.inputCount(e.tx, address))))
+		pool.nonces[address] = next
+		pool.debits[address] = new(big.Int).Add(pool.debit(address), e.debits[address])
+	}
+}
+
+// inputCount returns the number of plaintext inputs of address in tx
+func (pool *Mempool) inputCount(tx *ledger.Tx, address string) int {
+	n := 0
+	for _, slot := range tx.Inputs {
+		if slot.SlotMode() & common.PrivacyMode == common.Plaintext && string(slot.Base().Bytes()) == address {n++}
+	}
+	return n
+}
+
+// remove drops e, with cascade the later pending transactions of its plaintext addresses are dropped too
+// since their nonces can no longer be used
+func (pool *Mempool) remove(e *entry, cascade bool) {
+	if _, ok := pool.txs[e.hash]; !ok {return}
+	delete(pool.txs, e.hash)
+	pool.size -= e.size
+	for _, hash := range e.spends {
+		delete(pool.spends, hash)
+	}
+	for address, debit := range e.debits {
+		pool.debits[address] = new(big.Int).Sub(pool.debit(address), debit)
+		if pool.debits[address].Sign() == 0 {delete(pool.debits, address)}
+	}
+	if !cascade {return}
+	for address, nonce := range e.nonces {
+		for _, other := range pool.txs {
+			if n, ok := other.nonces[address]; ok && n.Cmp(nonce) > 0 {pool.remove(other, true)}
+		}
+		// the nested removals set their own higher nonces, the nonce of e is the next one once they are gone
+		if pool.hasAddress(address) {
+			pool.nonces[address] = nonce
+		} else {
+			delete(pool.nonces, address)
+		}
+	}
+}
+
+func (pool *Mempool) hasAddress(address string) bool {
+	for _, e := range pool.txs {
+		if _, ok := e.nonces[address]; ok {return true}
+	}
+	return false
+}
+
+// lowest returns the entry paying the lowest fee rate
+func (pool *Mempool) lowest() *entry {
+	var lowest *entry
+	for _, e := range pool.txs {
+		if lowest == nil || lowest.pays(e) {lowest = e}
+	}
+	return lowest
+}
+
+// Expire drops the transactions older than MaxAge and returns their number
+func (pool *Mempool) Expire() int {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	n := len(pool.txs)
+	deadline := pool.now().Add(-pool.MaxAge)
+	for _, e := range pool.txs {
+		if e.added.Before(deadline) {pool.remove(e, true)}
+	}
+	return n - len(pool.txs)
+}
+
+// Pending returns at most max transactions by descending fee rate, the plaintext inputs of an address stay in nonce order
+func (pool *Mempool) Pending(max int) []*ledger.Tx {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	sorted := make([]*entry, 0, len(pool.txs))
+	for _, e := range pool.txs {
+		sorted = append(sorted, e)
+	}
+	sort.Slice(sorted, func(i, j int) bool {return sorted[i].pays(sorted[j])})
+
+	next := make(map[string]*big.Int)
+	ready := func(e *entry) bool {
+		for address, nonce := range e.nonces {
+			want, ok := next[address]
+			if !ok {
+				var err error
+				want, err = pool.ledgerNonce(address)
+				if err != nil {return false}
+				next[address] = want
+			}
+			if nonce.Cmp(want) != 0 {return false}
+		}
+		return true
+	}
+
+	txs := make([]*ledger.Tx, 0)
+	taken := make(map[crypto.Hash]bool)
+	for progress := true; progress && len(txs) < max; {
+		progress = false
+		for _, e := range sorted {
+			if taken[e.hash] || !ready(e) {continue}
+			taken[e.hash] = true
+			txs = append(txs, e.tx)
+			for address, nonce := range e.nonces {
+				next[address] = new(big.Int).Add(nonce, big.NewInt(int64(pool.inputCount(e.tx, address))))
+			}
+			progress = true
+			break
+		}
+	}
+	return txs
+}
+
+// Update drops the transactions of a new block and the pending transactions it invalidates
+func (pool *Mempool) Update(included []*ledger.Tx) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	for _, tx := range included {
+		if e, ok := pool.txs[tx.Hash()]; ok {pool.remove(e, false)}
+	}
+	entries := make([]*entry, 0, len(pool.txs))
+	for _, e := range pool.txs {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {return entries[i].added.Before(entries[j].added)})
+
+	pool.txs = make(map[crypto.Hash]*entry)
+	pool.spends = make(map[crypto.Hash]crypto.Hash)
+	pool.nonces = make(map[string]*big.Int)
+	pool.debits = make(map[string]*big.Int)
+	pool.size = 0
+	for progress := true; progress; {
+		progress = false
+		for i, e := range entries {
+			if e == nil || !pool.stillValid(e) {continue}
+			pool.insert(e)
+			entries[i] = nil
+			progress = true
+		}
+	}
+}
+
+// stillValid checks the state dependent conditions of an admitted transaction, its proofs are not checked again
+func (pool *Mempool) stillValid(e *entry) bool {
+	for _, hash := range e.spends {
+		if _, ok := pool.spends[hash]; ok {return false}
+		if _, err := pool.ledger.Unspent(hash); err != nil {return false}
+	}
+	for address, nonce := range e.nonces {
+		want, err := pool.next(address)
+		if err != nil || nonce.Cmp(want) != 0 {return false}
+	}
+	return true
+}
+
+func (pool *Mempool) Len() int {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	return len(pool.txs)
+}
+
+// Size returns the total size of the pending transactions in bytes
+func (pool *Mempool) Size() int {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	return pool.size
+}
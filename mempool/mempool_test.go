@@ -0,0 +1,131 @@
+package mempool
+
+import (
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/crypto/privacy"
+	"github.com/Acoustical/maskash/errors"
+	"github.com/Acoustical/maskash/ledger"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func transfer(from *privacy.PrivateKey, to *privacy.PlaintextBase, nonce, v, fee int64) *ledger.Tx {
+	paid, err := to.NewPlaintextOutputSlot(big.NewInt(v), common.NoneContractSlot, nil)
+	errors.Handle(err)
+	tx := &ledger.Tx{Inputs: []privacy.Slot{from.NewPlaintextInputSlot(big.NewInt(nonce), big.NewInt(v+fee))}, Outputs: []privacy.Slot{paid}, Fee: big.NewInt(fee)}
+	errors.Handle(tx.Sign(1, from))
+	return tx
+}
+
+func TestMempool(t *testing.T) {
+	alice, bob, carol := privacy.NewRandomPrivateKey(), privacy.NewRandomPrivateKey(), privacy.NewRandomPrivateKey()
+	l := ledger.New(1, nil, nil, nil)
+	r, _ := crypto.RandomZq(1)
+	funds, err := alice.GenPlaintextBase().NewPlaintextOutputSlot(big.NewInt(1000), common.NoneContractSlot, nil)
+	errors.Handle(err)
+	bobFunds, err := bob.GenPlaintextBase().NewPlaintextOutputSlot(big.NewInt(1000), common.NoneContractSlot, nil)
+	errors.Handle(err)
+	coin, err := alice.GenSecretBase().NewSecretOutputSlot(big.NewInt(500), r[0], true, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+	errors.Handle(l.Genesis(funds, bobFunds, coin))
+
+	now := time.Unix(1000, 0)
+	pool := New(l)
+	pool.now = func() time.Time {return now}
+
+	tx0 := transfer(alice, carol.GenPlaintextBase(), 0, 100, 1)
+	tx1 := transfer(alice, carol.GenPlaintextBase(), 1, 100, 1)
+	if _, ok := pool.Add(tx1).(*errors.StaleNonceError); !ok {
+		t.Errorf("mempool admitted a transaction with a future nonce")
+	}
+	errors.Handle(pool.Add(tx0))
+	errors.Handle(pool.Add(tx1))
+	if err = pool.Add(tx1); err == nil {
+		t.Errorf("mempool admitted a known transaction")
+	}
+	if _, ok := pool.Add(transfer(alice, carol.GenPlaintextBase(), 2, 900, 1)).(*errors.InsufficientFundsError); !ok {
+		t.Errorf("mempool admitted pending debits above the balance")
+	}
+
+	// a gas slot paying bob back does not raise his fee, the burned fee does
+	gas, err := bob.GenPlaintextBase().NewPlaintextOutputSlot(big.NewInt(50), common.NoneContractSlot, nil)
+	errors.Handle(err)
+	errors.Handle(gas.SetMode(gas.SlotMode() | 0b01))
+	paid, err := carol.GenPlaintextBase().NewPlaintextOutputSlot(big.NewInt(100), common.NoneContractSlot, nil)
+	errors.Handle(err)
+	selfPaid := &ledger.Tx{Inputs: []privacy.Slot{bob.NewPlaintextInputSlot(big.NewInt(0), big.NewInt(150))}, Outputs: []privacy.Slot{paid, gas}, Fee: big.NewInt(0)}
+	if selfPaid.GasFee().Sign() != 0 {
+		t.Errorf("gas slot paid to the sender counted as fee %d", selfPaid.GasFee())
+	}
+	tx2 := transfer(bob, carol.GenPlaintextBase(), 0, 100, 50)
+	now = now.Add(time.Minute)
+	errors.Handle(pool.Add(tx2))
+
+	pending := pool.Pending(10)
+	if len(pending) != 3 || pending[0].Hash() != tx2.Hash() || pending[1].Hash() != tx0.Hash() || pending[2].Hash() != tx1.Hash() {
+		t.Errorf("mempool pending transactions out of fee or nonce order")
+	}
+
+	input := privacy.NewSecretInputSlot(coin)
+	spend := func(v int64) *ledger.Tx {
+		rl, _ := crypto.RandomZq(1)
+		out, err := carol.GenSecretBase().NewSecretOutputSlot(big.NewInt(v), rl[0], true, common.NoneContractSlot, nil, nil)
+		errors.Handle(err)
+		balance, err := privacy.NewBalance([]*privacy.Input{{Slot: input, Key: alice}}, []*privacy.Output{{Slot: out, Value: big.NewInt(v), R: rl[0]}}, big.NewInt(500-v))
+		errors.Handle(err)
+		tx := &ledger.Tx{Inputs: []privacy.Slot{input}, Outputs: []privacy.Slot{out}, Balance: balance, Fee: big.NewInt(500-v)}
+		errors.Handle(tx.Sign(1, alice))
+		return tx
+	}
+	errors.Handle(pool.Add(spend(480)))
+	if _, ok := pool.Add(spend(490)).(*errors.DoubleSpendError); !ok {
+		t.Errorf("mempool admitted a conflicting spend")
+	}
+
+	// evicting tx0 for size drops tx1 which depends on its nonce
+	now = now.Add(time.Hour)
+	pool.MaxSize = pool.Size() - 1
+	errors.Handle(pool.Add(transfer(bob, carol.GenPlaintextBase(), 1, 10, 40)))
+	if pool.Len() != 3 {
+		t.Errorf("mempool kept %d transactions after eviction, want 3", pool.Len())
+	}
+	pool.MaxSize = DefaultMaxSize
+
+	errors.Handle(l.Apply(tx2))
+	pool.Update([]*ledger.Tx{tx2})
+	if pool.Len() != 2 {
+		t.Errorf("mempool kept %d transactions after the block, want 2", pool.Len())
+	}
+	now = now.Add(DefaultMaxAge)
+	if n := pool.Expire(); n != 1 || pool.Len() != 1 {
+		t.Errorf("mempool expired %d transactions, want 1", n)
+	}
+}
+
+func TestMempoolCascade(t *testing.T) {
+	alice, carol := privacy.NewRandomPrivateKey(), privacy.NewRandomPrivateKey()
+	l := ledger.New(1, nil, nil, nil)
+	funds, err := alice.GenPlaintextBase().NewPlaintextOutputSlot(big.NewInt(1000), common.NoneContractSlot, nil)
+	errors.Handle(err)
+	errors.Handle(l.Genesis(funds))
+	pool := New(l)
+
+	txs := make([]*ledger.Tx, 3)
+	for i := range txs {
+		txs[i] = transfer(alice, carol.GenPlaintextBase(), int64(i), 100, 1)
+		errors.Handle(pool.Add(txs[i]))
+	}
+	// evicting nonce 1 drops nonce 2, nonce 1 is the next nonce again
+	pool.remove(pool.txs[txs[1].Hash()], true)
+	if pool.Len() != 1 {
+		t.Errorf("mempool kept %d transactions after the cascade, want 1", pool.Len())
+	}
+	if err = pool.Add(txs[1]); err != nil {
+		t.Errorf("mempool rejected the evicted nonce: %v", err)
+	}
+	if _, ok := pool.Add(transfer(alice, carol.GenPlaintextBase(), 3, 100, 1)).(*errors.StaleNonceError); !ok {
+		t.Errorf("mempool admitted a transaction after a nonce gap")
+	}
+}
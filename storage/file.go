@@ -0,0 +1,310 @@
+package storage
+
+import (
+	"encoding/binary"
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/crypto/privacy"
+	"github.com/Acoustical/maskash/errors"
+	"hash/crc32"
+	"io"
+	"math/big"
+	"os"
+	"sync"
+)
+
+// The log is a sequence of entries: length (4) | crc32 of the body (4) | body, the body is op (1) | payload
+const logHeaderLength = 8
+const opPut uint8 = 1
+const opDelete uint8 = 2
+
+// fileEntry locates the body of the latest put of a hash in the log
+type fileEntry struct {
+	offset int64
+	length uint32
+	owner string
+}
+
+// FileStore is an append-only log of records with an in-memory index by hash and by owner.
+// A torn write at the end of the log is detected by its checksum and cut off when the store is opened,
+// a damaged entry followed by other entries is reported as corruption instead
+type FileStore struct {
+	path string
+	file *os.File
+	size int64
+	index map[crypto.Hash]*fileEntry
+	owners map[string]map[crypto.Hash]struct{}
+	// Sync flushes every write to the disk before it returns
+	Sync bool
+	mu sync.RWMutex
+}
+
+// OpenFileStore opens or creates the log at path and rebuilds the index
+func OpenFileStore(path string) (*FileStore, error) {
+	store := &FileStore{path: path}
+	err := store.open()
+	if err != nil {return nil, err}
+	return store, nil
+}
+
+func (store *FileStore) open() error {
+	file, err := os.OpenFile(store.path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {return err}
+	store.file = file
+	store.index = make(map[crypto.Hash]*fileEntry)
+	store.owners = make(map[string]map[crypto.Hash]struct{})
+	store.size = 0
+
+	info, err := file.Stat()
+	if err != nil {return err}
+	header := make([]byte, logHeaderLength)
+	for store.size + logHeaderLength <= info.Size() {
+		_, err = file.ReadAt(header, store.size)
+		if err != nil {return err}
+		length := binary.BigEndian.Uint32(header[:4])
+		end := store.size + logHeaderLength + int64(length)
+		if end > info.Size() {break}
+		body := make([]byte, length)
+		_, err = file.ReadAt(body, store.size + logHeaderLength)
+		if err != nil {return err}
+		if length == 0 || crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(header[4:]) || !store.replay(body, store.size + logHeaderLength) {
+			// only the last entry can be torn by a crash
+			if end < info.Size() {
+				file.Close()
+				return errors.NewCorruptedStoreError(store.path, store.size)
+			}
+			break
+		}
+		store.size = end
+	}
+	if store.size < info.Size() {
+		err = file.Truncate(store.size)
+		if err != nil {return err}
+	}
+	return nil
+}
+
+// replay applies a log entry to the index
+func (store *FileStore) replay(body []byte, offset int64) bool {
+	switch body[0] {
+	case opPut:
+		hash, owner, ok := recordHead(body[1:])
+		if !ok {return false}
+		store.unindex(hash)
+		store.indexEntry(hash, &fileEntry{offset, uint32(len(body)), string(owner)})
+	case opDelete:
+		if len(body) != 1 + len(crypto.Hash{}) {return false}
+		var hash crypto.Hash
+		copy(hash[:], body[1:])
+		store.unindex(hash)
+	default:
+		return false
+	}
+	return true
+}
+
+func (store *FileStore) indexEntry(hash crypto.Hash, entry *fileEntry) {
+	store.index[hash] = entry
+	if store.owners[entry.owner] == nil {store.owners[entry.owner] = make(map[crypto.Hash]struct{})}
+	store.owners[entry.owner][hash] = struct{}{}
+}
+
+func (store *FileStore) unindex(hash crypto.Hash) bool {
+	entry, ok := store.index[hash]
+	if !ok {return false}
+	delete(store.index, hash)
+	delete(store.owners[entry.owner], hash)
+	if len(store.owners[entry.owner]) == 0 {delete(store.owners, entry.owner)}
+	return true
+}
+
+// append writes a checksummed entry at the end of the log and returns the offset of its body
+func (store *FileStore) append(body []byte) (int64, error) {
+	entry := make([]byte, logHeaderLength + len(body))
+	binary.BigEndian.PutUint32(entry[:4], uint32(len(body)))
+	binary.BigEndian.PutUint32(entry[4:8], crc32.ChecksumIEEE(body))
+	copy(entry[logHeaderLength:], body)
+	_, err := store.file.WriteAt(entry, store.size)
+	if err != nil {return 0, err}
+	if store.Sync {
+		err = store.file.Sync()
+		if err != nil {return 0, err}
+	}
+	offset := store.size + logHeaderLength
+	store.size += int64(len(entry))
+	return offset, nil
+}
+
+func (store *FileStore) Put(record *Record) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	body := append([]byte{opPut}, encodeRecord(record)...)
+	offset, err := store.append(body)
+	if err != nil {return err}
+	store.unindex(record.Hash)
+	store.indexEntry(record.Hash, &fileEntry{offset, uint32(len(body)), string(record.Owner)})
+	return nil
+}
+
+func (store *FileStore) Get(hash crypto.Hash) (*Record, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	return store.get(hash)
+}
+
+func (store *FileStore) get(hash crypto.Hash) (*Record, error) {
+	entry, ok := store.index[hash]
+	if !ok {return nil, errors.NewRecordNotFoundError(hash.Bytes())}
+	body := make([]byte, entry.length)
+	_, err := store.file.ReadAt(body, entry.offset)
+	if err != nil {return nil, err}
+	return decodeRecord(body[1:])
+}
+
+func (store *FileStore) Delete(hash crypto.Hash) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if _, ok := store.index[hash]; !ok {return errors.NewRecordNotFoundError(hash.Bytes())}
+	_, err := store.append(append([]byte{opDelete}, hash[:]...))
+	if err != nil {return err}
+	store.unindex(hash)
+	return nil
+}
+
+// Owned returns the records of owner ordered by hash
+func (store *FileStore) Owned(owner []byte) ([]*Record, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	records := make([]*Record, 0, len(store.owners[string(owner)]))
+	for hash := range store.owners[string(owner)] {
+		record, err := store.get(hash)
+		if err != nil {return nil, err}
+		records = append(records, record)
+	}
+	sortRecords(records)
+	return records, nil
+}
+
+// Records returns all the records ordered by hash
+func (store *FileStore) Records() ([]*Record, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	records := make([]*Record, 0, len(store.index))
+	for hash := range store.index {
+		record, err := store.get(hash)
+		if err != nil {return nil, err}
+		records = append(records, record)
+	}
+	sortRecords(records)
+	return records, nil
+}
+
+// Size returns the length of the log in bytes
+func (store *FileStore) Size() int64 {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	return store.size
+}
+
+// Compact rewrites the log with the live records only, the replaced puts, the deletes and the spent outputs are dropped,
+// the input records such as the Plaintext debits are kept
+func (store *FileStore) Compact() error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	tmpPath := store.path + ".compact"
+	tmp, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {return err}
+	compacted := &FileStore{path: tmpPath, file: tmp}
+	for hash := range store.index {
+		record, err := store.get(hash)
+		if err == nil && !spentOutput(record) {_, err = compacted.append(append([]byte{opPut}, encodeRecord(record)...))}
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmpPath)
+			return err
+		}
+	}
+	err = tmp.Sync()
+	if err == nil {err = tmp.Close()}
+	if err == nil {err = os.Rename(tmpPath, store.path)}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	_ = store.file.Close()
+	return store.open()
+}
+
+func spentOutput(record *Record) bool {
+	return record.Spent && record.Slot.SlotMode() & common.TxSlotKind == common.OutputSlot
+}
+
+func (store *FileStore) Close() error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	return store.file.Close()
+}
+
+// encodeRecord returns hash (32) | owner length (2) | owner | slot length (4) | slot | amount length (1) | amount |
+// asset (32) | spent (1), the amount length is 0xff for a nil amount
+func encodeRecord(record *Record) []byte {
+	slot := record.Slot.Bytes()
+	bytes := make([]byte, 0, len(record.Hash) + 2 + len(record.Owner) + 4 + len(slot) + 1 + len(record.Asset) + 1)
+	bytes = append(bytes, record.Hash[:]...)
+	bytes = append(bytes, byte(len(record.Owner) >> 8), byte(len(record.Owner)))
+	bytes = append(bytes, record.Owner...)
+	slotLength := make([]byte, 4)
+	binary.BigEndian.PutUint32(slotLength, uint32(len(slot)))
+	bytes = append(bytes, slotLength...)
+	bytes = append(bytes, slot...)
+	if record.Amount == nil {
+		bytes = append(bytes, 0xff)
+	} else {
+		amount := record.Amount.Bytes()
+		bytes = append(bytes, byte(len(amount)))
+		bytes = append(bytes, amount...)
+	}
+	bytes = append(bytes, record.Asset[:]...)
+	if record.Spent {
+		bytes = append(bytes, 1)
+	} else {
+		bytes = append(bytes, 0)
+	}
+	return bytes
+}
+
+// recordHead parses the hash and the owner of an encoded record
+func recordHead(b []byte) (crypto.Hash, []byte, bool) {
+	var hash crypto.Hash
+	if len(b) < len(hash) + 2 {return hash, nil, false}
+	copy(hash[:], b)
+	ownerLength := int(b[len(hash)]) << 8 + int(b[len(hash)+1])
+	start := len(hash) + 2
+	if len(b) < start + ownerLength {return hash, nil, false}
+	return hash, b[start:start+ownerLength], true
+}
+
+func decodeRecord(b []byte) (*Record, error) {
+	hash, owner, ok := recordHead(b)
+	if !ok {return nil, errors.NewWrongInputLength(len(b))}
+	record := &Record{Hash: hash, Owner: append([]byte{}, owner...)}
+	start := len(hash) + 2 + len(owner)
+	if len(b) < start + 4 {return nil, errors.NewWrongInputLength(len(b))}
+	end := start + 4 + int(binary.BigEndian.Uint32(b[start:start+4]))
+	if len(b) < end + 1 {return nil, io.ErrUnexpectedEOF}
+	slot, err := privacy.SetSlotBytes(b[start+4:end])
+	if err != nil {return nil, err}
+	record.Slot = slot
+
+	start = end + 1
+	if b[end] != 0xff {
+		end = start + int(b[end])
+		if len(b) < end {return nil, io.ErrUnexpectedEOF}
+		record.Amount = new(big.Int).SetBytes(b[start:end])
+		start = end
+	}
+	if len(b) != start + len(record.Asset) + 1 {return nil, errors.NewWrongInputLength(len(b))}
+	copy(record.Asset[:], b[start:])
+	record.Spent = b[len(b)-1] == 1
+	return record, nil
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/crypto/privacy"
+	"github.com/Acoustical/maskash/errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "slots.log")
+	store, err := OpenFileStore(path)
+	errors.Handle(err)
+
+	prv := privacy.NewRandomPrivateKey()
+	owner := prv.GenSecretBase().Bytes()
+	rl, _ := crypto.RandomZq(3)
+	records := make([]*Record, 3)
+	for i := range records {
+		slot, err := prv.GenAnonymousBase().NewAnonymousOutputSlot(big.NewInt(int64(i)), rl[i], true, common.NoneContractSlot, nil, nil)
+		errors.Handle(err)
+		records[i] = &Record{Hash: OutputHash(slot), Owner: owner, Slot: slot, Amount: big.NewInt(int64(i)), Asset: privacy.NativeAsset}
+		errors.Handle(store.Put(records[i]))
+	}
+	records[1].Spent = true
+	errors.Handle(store.Put(records[1]))
+	errors.Handle(store.Delete(records[2].Hash))
+	errors.Handle(store.Close())
+
+	// a torn write at the end of the log
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	errors.Handle(err)
+	_, err = file.Write([]byte{0, 0, 1, 0, 1, 2, 3, 4, 5})
+	errors.Handle(err)
+	errors.Handle(file.Close())
+
+	store, err = OpenFileStore(path)
+	errors.Handle(err)
+	owned, err := store.Owned(owner)
+	errors.Handle(err)
+	if len(owned) != 2 {
+		t.Errorf("reopened store owns %d records, want 2", len(owned))
+	}
+	record, err := store.Get(records[0].Hash)
+	errors.Handle(err)
+	if record.Amount.Int64() != 0 || record.Spent || !record.Slot.CheckZKs() {
+		t.Errorf("reopened store returned a different record")
+	}
+	if record, _ = store.Get(records[1].Hash); !record.Spent {
+		t.Errorf("reopened store lost the spent flag")
+	}
+
+	size := store.Size()
+	errors.Handle(store.Compact())
+	if store.Size() >= size {
+		t.Errorf("compaction did not shrink the log from %d bytes", size)
+	}
+	if _, err = store.Get(records[1].Hash); err == nil {
+		t.Errorf("compaction kept a spent record")
+	}
+	if _, err = store.Get(records[0].Hash); err != nil {
+		t.Errorf("compaction lost an unspent record")
+	}
+	errors.Handle(store.Put(records[2]))
+	errors.Handle(store.Close())
+
+	// a damaged entry before the end of the log is not cut off
+	bytes, err := os.ReadFile(path)
+	errors.Handle(err)
+	bytes[logHeaderLength+1] ^= 1
+	errors.Handle(os.WriteFile(path, bytes, 0600))
+	_, err = OpenFileStore(path)
+	if _, ok := err.(*errors.CorruptedStoreError); !ok {
+		t.Errorf("store opened over a corrupted entry")
+	}
+	if info, _ := os.Stat(path); info.Size() != int64(len(bytes)) {
+		t.Errorf("store truncated the log to %d bytes over a corrupted entry", info.Size())
+	}
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"bytes"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/errors"
+	"sort"
+	"sync"
+)
+
+// MemoryStore keeps the records in memory, it is safe for concurrent use
+type MemoryStore struct {
+	records map[crypto.Hash]*Record
+	owners map[string]map[crypto.Hash]struct{}
+	mu sync.RWMutex
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		records: make(map[crypto.Hash]*Record),
+		owners: make(map[string]map[crypto.Hash]struct{}),
+	}
+}
+
+func (store *MemoryStore) Put(record *Record) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.remove(record.Hash)
+	r := *record
+	store.records[r.Hash] = &r
+	owner := string(r.Owner)
+	if store.owners[owner] == nil {store.owners[owner] = make(map[crypto.Hash]struct{})}
+	store.owners[owner][r.Hash] = struct{}{}
+	return nil
+}
+
+func (store *MemoryStore) Get(hash crypto.Hash) (*Record, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	record, ok := store.records[hash]
+	if !ok {return nil, errors.NewRecordNotFoundError(hash.Bytes())}
+	r := *record
+	return &r, nil
+}
+
+func (store *MemoryStore) Delete(hash crypto.Hash) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if !store.remove(hash) {return errors.NewRecordNotFoundError(hash.Bytes())}
+	return nil
+}
+
+// Owned returns the records of owner ordered by hash
+func (store *MemoryStore) Owned(owner []byte) ([]*Record, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	records := make([]*Record, 0, len(store.owners[string(owner)]))
+	for hash := range store.owners[string(owner)] {
+		r := *store.records[hash]
+		records = append(records, &r)
+	}
+	sortRecords(records)
+	return records, nil
+}
+
+// Records returns all the records ordered by hash
+func (store *MemoryStore) Records() ([]*Record, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	records := make([]*Record, 0, len(store.records))
+	for _, record := range store.records {
+		r := *record
+		records = append(records, &r)
+	}
+	sortRecords(records)
+	return records, nil
+}
+
+func (store *MemoryStore) remove(hash crypto.Hash) bool {
+	record, ok := store.records[hash]
+	if !ok {return false}
+	delete(store.records, hash)
+	owner := string(record.Owner)
+	delete(store.owners[owner], hash)
+	if len(store.owners[owner]) == 0 {delete(store.owners, owner)}
+	return true
+}
+
+func sortRecords(records []*Record) {
+	sort.Slice(records, func(i, j int) bool {return bytes.Compare(records[i].Hash[:], records[j].Hash[:]) < 0})
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto/privacy"
+	"github.com/Acoustical/maskash/errors"
+	"math/big"
+	"testing"
+)
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	prv := privacy.NewRandomPrivateKey()
+	owner := prv.GenSecretBase().Bytes()
+
+	records := make([]*Record, 3)
+	for i := range records {
+		slot, err := prv.GenPlaintextBase().NewPlaintextOutputSlot(big.NewInt(int64(i)), common.NoneContractSlot, nil)
+		errors.Handle(err)
+		records[i] = &Record{Hash: OutputHash(slot), Owner: owner, Slot: slot, Amount: big.NewInt(int64(i))}
+		errors.Handle(store.Put(records[i]))
+	}
+	errors.Handle(store.Put(&Record{Hash: records[0].Hash, Owner: []byte("other"), Slot: records[0].Slot, Spent: true}))
+
+	owned, err := store.Owned(owner)
+	errors.Handle(err)
+	if len(owned) != 2 {
+		t.Errorf("store owns %d records, want 2", len(owned))
+	}
+	record, err := store.Get(records[0].Hash)
+	errors.Handle(err)
+	if !record.Spent || string(record.Owner) != "other" {
+		t.Errorf("store did not replace the record")
+	}
+
+	record.Spent = false
+	if record, _ = store.Get(records[0].Hash); !record.Spent {
+		t.Errorf("store record changed from outside")
+	}
+
+	errors.Handle(store.Delete(records[1].Hash))
+	if _, err = store.Get(records[1].Hash); err == nil {
+		t.Errorf("store returned a deleted record")
+	}
+	if all, _ := store.Records(); len(all) != 2 {
+		t.Errorf("store has %d records, want 2", len(all))
+	}
+}
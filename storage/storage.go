@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/crypto/privacy"
+	"math/big"
+)
+
+// Record is a slot kept by a wallet or a ledger, indexed by its hash and by the base of its owner
+type Record struct {
+	Hash crypto.Hash
+	Owner []byte
+	Slot privacy.Slot
+	Amount *big.Int
+	Asset privacy.AssetID
+	Spent bool
+}
+
+// Store keeps records, Put replaces the record of the same hash
+type Store interface {
+	Put(record *Record) error
+	Get(hash crypto.Hash) (*Record, error)
+	Delete(hash crypto.Hash) error
+	Owned(owner []byte) ([]*Record, error)
+	Records() ([]*Record, error)
+}
+
+// OutputHash returns the hash shared by an output slot and the input slot spending it
+func OutputHash(slot privacy.Slot) crypto.Hash {
+	if spend := privacy.SpendBase(slot.Base()); spend != nil {return crypto.Hash_(slot.Base(), spend, slot.Value())}
+	return crypto.Hash_(slot.Base(), slot.Value())
+}
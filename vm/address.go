@@ -0,0 +1,35 @@
+package vm
+
+import (
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/crypto/privacy"
+	"math/big"
+)
+
+var createDomain = crypto.HashBytes("maskash create")
+var create2Domain = crypto.HashBytes("maskash create2")
+
+// ContractAddress derives the address of the nonce-th contract deployed by creator in the spirit of CREATE
+func ContractAddress(creator privacy.Base, nonce uint64) crypto.Address {
+	return hashAddress(crypto.Hash_(createDomain, crypto.HashBytes{creator.BaseMode()}, creator, wordBytes(new(big.Int).SetUint64(nonce))))
+}
+
+// ContractAddress2 derives the address of code deployed by creator with salt in the spirit of CREATE2,
+// the address is known without the state
+func ContractAddress2(creator privacy.Base, salt *big.Int, code []byte) crypto.Address {
+	return hashAddress(crypto.Hash_(create2Domain, crypto.HashBytes{creator.BaseMode()}, creator, wordBytes(salt), crypto.Hash_(crypto.HashBytes(code))))
+}
+
+// hashAddress takes the address from the low bytes of the hash as crypto.NewAddress does
+func hashAddress(h crypto.Hash) crypto.Address {
+	var address crypto.Address
+	copy(address[:], h[len(h)-len(address):])
+	return address
+}
+
+func wordBytes(v *big.Int) crypto.HashBytes {
+	return new(big.Int).Mod(v, wordModulus).FillBytes(make([]byte, WordLength))
+}
+
+// IsContract returns whether code is deployed at address
+func (vm *VM) IsContract(address crypto.Address) bool {return vm.state.IsContract(address)}
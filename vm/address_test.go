@@ -0,0 +1,66 @@
+package vm
+
+import (
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto/privacy"
+	"github.com/Acoustical/maskash/errors"
+	"github.com/Acoustical/maskash/ledger"
+	"math/big"
+	"testing"
+)
+
+func TestContractAddress(t *testing.T) {
+	alice, bob := privacy.NewRandomPrivateKey(), privacy.NewRandomPrivateKey()
+	creator := alice.GenPlaintextBase()
+	if ContractAddress(creator, 0) != ContractAddress(alice.GenPlaintextBase(), 0) {
+		t.Errorf("contract address is not deterministic")
+	}
+	if ContractAddress(creator, 0) == ContractAddress(creator, 1) || ContractAddress(creator, 0) == ContractAddress(bob.GenPlaintextBase(), 0) {
+		t.Errorf("contract addresses collide")
+	}
+	if ContractAddress2(creator, big.NewInt(1), counter) == ContractAddress2(creator, big.NewInt(1), counter[1:]) || ContractAddress2(creator, big.NewInt(1), counter) == ContractAddress2(creator, big.NewInt(2), counter) {
+		t.Errorf("salted contract addresses collide")
+	}
+
+	l := ledger.New(1, nil, nil, nil)
+	funds, err := creator.NewPlaintextOutputSlot(big.NewInt(10000), common.NoneContractSlot, nil)
+	errors.Handle(err)
+	errors.Handle(l.Genesis(funds))
+	vm := New(nil)
+
+	deploy := func(nonce int64, to *privacy.PlaintextBase, salt *big.Int) uint8 {
+		slot, err := privacy.NewContractCreateSlot(counter, salt)
+		errors.Handle(err)
+		create, err := to.NewPlaintextOutputSlot(big.NewInt(100), common.ContractCreation, slot)
+		errors.Handle(err)
+		tx := &ledger.Tx{Inputs: []privacy.Slot{alice.NewPlaintextInputSlot(big.NewInt(nonce), big.NewInt(1100))}, Outputs: []privacy.Slot{create}, Fee: big.NewInt(1000)}
+		errors.Handle(tx.Sign(1, alice))
+		errors.Handle(l.Apply(tx))
+		receipts, err := vm.Apply(tx)
+		errors.Handle(err)
+		return receipts[0].(*privacy.PlaintextSlot).ContractSlot.(*privacy.ContractReceiptSlot).Status()
+	}
+
+	// the salt is chosen by the creator, not taken from the input
+	salted := privacy.NewPlaintextBase(ContractAddress2(creator, big.NewInt(1919), counter))
+	if deploy(0, salted, big.NewInt(1919)) != StatusSuccess || !vm.IsContract(salted.Address()) {
+		t.Errorf("contract not deployed at its salted address")
+	}
+	created := privacy.NewPlaintextBase(ContractAddress(creator, 1))
+	if deploy(1, created, nil) != StatusSuccess || !vm.IsContract(created.Address()) {
+		t.Errorf("contract not deployed at its address")
+	}
+	unsalted := privacy.NewPlaintextBase(ContractAddress2(creator, big.NewInt(2), counter))
+	if deploy(2, unsalted, nil) != StatusFailed || vm.IsContract(unsalted.Address()) {
+		t.Errorf("contract deployed at a salted address without the salt")
+	}
+	wrong := bob.GenPlaintextBase()
+	if deploy(3, wrong, big.NewInt(1919)) != StatusFailed || vm.IsContract(wrong.Address()) {
+		t.Errorf("contract deployed at an underived address")
+	}
+
+	// the contract address receives Plaintext outputs as any other base
+	if account, _ := l.Account(created); account.Balance.Int64() != 100 {
+		t.Errorf("contract balance %d, want 100", account.Balance)
+	}
+}
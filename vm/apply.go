@@ -0,0 +1,114 @@
+package vm
+
+import (
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/crypto/privacy"
+	"github.com/Acoustical/maskash/errors"
+	"github.com/Acoustical/maskash/ledger"
+	"math"
+	"math/big"
+)
+
+// Status of a contract receipt
+const (
+	StatusSuccess uint8 = 0
+	StatusFailed uint8 = 1
+	StatusOutOfGas uint8 = 2
+)
+
+// GasLimit returns the gas of the contract slots of tx, one gas for every unit of the GasFee of tx
+func GasLimit(tx *ledger.Tx) uint64 {
+	limit := tx.GasFee()
+	if !limit.IsUint64() {return math.MaxUint64}
+	return limit.Uint64()
+}
+
+// Apply executes the ContractCreation and ContractCall outputs of tx, which is valid against the ledger, and returns
+// a Plaintext ContractReceipt output to the contract for each of them. A ContractCreation output deploys its code at the
+// address of the output, which must be the ContractAddress of the creator or its ContractAddress2 salted by the creator
+// input. A ContractCall output calls the contract at its address. The calls share the gas of GasLimit
+func (vm *VM) Apply(tx *ledger.Tx) ([]privacy.Slot, error) {
+	for _, slot := range tx.Outputs {
+		mode := slot.SlotMode() & common.ContractSlotMode
+		if mode == common.NoneContractSlot {continue}
+		if mode == common.ContractReceipt {return nil, errors.NewInvalidTxError("receipt in the outputs")}
+		if _, ok := slot.(*privacy.PlaintextSlot); !ok {return nil, errors.NewInvalidTxError("contract slot on a confidential output")}
+	}
+
+	txHash := tx.Hash()
+	gas := GasLimit(tx)
+	creator := creatorOf(tx)
+	caller := new(big.Int)
+	if creator != nil {caller = callerOf(creator)}
+	receipts := make([]privacy.Slot, 0)
+	for _, slot := range tx.Outputs {
+		plaintext, ok := slot.(*privacy.PlaintextSlot)
+		if !ok || slot.SlotMode() & common.ContractSlotMode == common.NoneContractSlot {continue}
+		var address crypto.Address
+		copy(address[:], plaintext.PlaintextBase.Bytes())
+
+		status, used, emitted := StatusSuccess, uint64(0), []Word(nil)
+		switch c := plaintext.ContractSlot.(type) {
+		case *privacy.ContractCreateSlot:
+			code := c.Code()
+			used = DeployGas * uint64(len(code))
+			if used > gas {
+				status, used = StatusOutOfGas, gas
+			} else if creator == nil || address != createAddress(creator, vm.state.CreateNonce(creator), c.Salt(), code) {
+				status = StatusFailed
+			} else if vm.state.Deploy(address, code) != nil {
+				status = StatusFailed
+			} else {
+				vm.state.setCreateNonce(creator, vm.state.CreateNonce(creator) + 1)
+			}
+		case *privacy.ContractCallSlot:
+			args := make([]Word, len(c.Args()))
+			for i, arg := range c.Args() {
+				args[i] = ValueWord(arg)
+			}
+			callValue, _ := plaintext.Value().Solve(nil)
+			bases, proofs := c.Proofs()
+			ctx := &Context{Address: address, Caller: caller, CallValue: callValue, Function: c.Function(), Args: args, Bases: bases, Proofs: proofs}
+			result, err := vm.Call(ctx, gas)
+			used, emitted = result.GasUsed, result.Emitted
+			if _, ok := err.(*errors.OutOfGasError); ok {
+				status = StatusOutOfGas
+			} else if err != nil {
+				status = StatusFailed
+			}
+		default:
+			return nil, errors.NewNonContractSlotError()
+		}
+		gas -= used
+
+		values := make([]privacy.Value, len(emitted))
+		for i, word := range emitted {
+			values[i] = word.PrivacyValue()
+		}
+		receipt, err := privacy.NewContractReceiptSlot(crypto.Hash_(txHash, slot), status, used, values)
+		if err != nil {return nil, err}
+		out, err := plaintext.PlaintextBase.NewPlaintextOutputSlot(new(big.Int), common.ContractReceipt, receipt)
+		if err != nil {return nil, err}
+		receipts = append(receipts, out)
+	}
+	return receipts, nil
+}
+
+// creatorOf returns the base of the first input of tx, nil if tx has no input
+func creatorOf(tx *ledger.Tx) privacy.Base {
+	if len(tx.Inputs) == 0 {return nil}
+	return tx.Inputs[0].Base()
+}
+
+// createAddress returns the address a creation slot deploys to, the ContractAddress2 of its salt if it has one
+func createAddress(creator privacy.Base, nonce uint64, salt *big.Int, code []byte) crypto.Address {
+	if salt != nil {return ContractAddress2(creator, salt, code)}
+	return ContractAddress(creator, nonce)
+}
+
+// callerOf returns the address of base as an integer, the hash of a confidential base
+func callerOf(base privacy.Base) *big.Int {
+	if base.BaseMode() == common.Plaintext {return new(big.Int).SetBytes(base.Bytes())}
+	return crypto.Hash_(base).BigInt()
+}
@@ -0,0 +1,83 @@
+package vm
+
+import (
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto/privacy"
+	"github.com/Acoustical/maskash/errors"
+	"github.com/Acoustical/maskash/ledger"
+	"math/big"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	alice := privacy.NewRandomPrivateKey()
+	l := ledger.New(1, nil, nil, nil)
+	funds, err := alice.GenPlaintextBase().NewPlaintextOutputSlot(big.NewInt(10000), common.NoneContractSlot, nil)
+	errors.Handle(err)
+	errors.Handle(l.Genesis(funds))
+	contract := privacy.NewPlaintextBase(ContractAddress(alice.GenPlaintextBase(), 0))
+
+	createSlot, err := privacy.NewContractCreateSlot(counter, nil)
+	errors.Handle(err)
+	create, err := contract.NewPlaintextOutputSlot(new(big.Int), common.ContractCreation, createSlot)
+	errors.Handle(err)
+	callSlot, err := privacy.NewContractCallSlot(big.NewInt(1), new(privacy.PlaintextBase).SetValue(nil, big.NewInt(7)))
+	errors.Handle(err)
+	call, err := contract.NewPlaintextOutputSlot(big.NewInt(10), common.ContractCall, callSlot)
+	errors.Handle(err)
+	revertSlot, err := privacy.NewContractCallSlot(big.NewInt(2), new(privacy.PlaintextBase).SetValue(nil, big.NewInt(7)))
+	errors.Handle(err)
+	revert, err := contract.NewPlaintextOutputSlot(new(big.Int), common.ContractCall, revertSlot)
+	errors.Handle(err)
+	// a gas slot paying the sender back buys no gas
+	gas, err := alice.GenPlaintextBase().NewPlaintextOutputSlot(big.NewInt(1000), common.NoneContractSlot, nil)
+	errors.Handle(err)
+	errors.Handle(gas.SetMode(gas.SlotMode() | 0b01))
+	input := alice.NewPlaintextInputSlot(big.NewInt(0), big.NewInt(1000))
+	if limit := GasLimit(&ledger.Tx{Inputs: []privacy.Slot{input}, Outputs: []privacy.Slot{gas}}); limit != 0 {
+		t.Errorf("self paid gas slot bought %d gas", limit)
+	}
+	// a gas slot paying another address buys gas on top of the fee
+	paid, err := privacy.NewRandomPrivateKey().GenPlaintextBase().NewPlaintextOutputSlot(big.NewInt(1000), common.NoneContractSlot, nil)
+	errors.Handle(err)
+	errors.Handle(paid.SetMode(paid.SlotMode() | 0b01))
+	if limit := GasLimit(&ledger.Tx{Inputs: []privacy.Slot{input}, Outputs: []privacy.Slot{paid}, Fee: big.NewInt(10)}); limit != 1010 {
+		t.Errorf("gas slot paid to another address bought %d gas, want 1010", limit)
+	}
+	tx := &ledger.Tx{Inputs: []privacy.Slot{alice.NewPlaintextInputSlot(big.NewInt(0), big.NewInt(1010))}, Outputs: []privacy.Slot{create, call, revert}, Fee: big.NewInt(1000)}
+	errors.Handle(tx.Sign(1, alice))
+	errors.Handle(l.Apply(tx))
+
+	vm := New(nil)
+	receipts, err := vm.Apply(tx)
+	errors.Handle(err)
+	if len(receipts) != 3 {
+		t.Fatalf("%d receipts, want 3", len(receipts))
+	}
+	statuses := []uint8{StatusSuccess, StatusSuccess, StatusFailed}
+	var used uint64
+	for i, slot := range receipts {
+		receipt := slot.(*privacy.PlaintextSlot).ContractSlot.(*privacy.ContractReceiptSlot)
+		if receipt.Status() != statuses[i] {
+			t.Errorf("receipt %d status %d, want %d", i, receipt.Status(), statuses[i])
+		}
+		used += receipt.GasUsed()
+	}
+	receipt := receipts[1].(*privacy.PlaintextSlot).ContractSlot.(*privacy.ContractReceiptSlot)
+	if v, _ := receipt.Values()[0].Solve(nil); v.Int64() != 7 {
+		t.Errorf("call emitted %d, want 7", v)
+	}
+	if !vm.IsContract(contract.Address()) || vm.State().CreateNonce(alice.GenPlaintextBase()) != 1 {
+		t.Errorf("contract not deployed at its derived address")
+	}
+	if used > GasLimit(tx) {
+		t.Errorf("receipts used %d gas above the limit %d", used, GasLimit(tx))
+	}
+
+	// the fee bounds the deployment
+	receipts, err = vm.Apply(&ledger.Tx{Outputs: []privacy.Slot{create}, Fee: new(big.Int).SetUint64(DeployGas * uint64(len(counter)) - 1)})
+	errors.Handle(err)
+	if status := receipts[0].(*privacy.PlaintextSlot).ContractSlot.(*privacy.ContractReceiptSlot).Status(); status != StatusOutOfGas {
+		t.Errorf("deployment above the gas limit has status %d", status)
+	}
+}
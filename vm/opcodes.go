@@ -0,0 +1,108 @@
+package vm
+
+// OpCode is an instruction of the contract VM. A word is an unsigned integer modulo 2^256 or a Secret or Anonymous value
+// the contract can not open. Binary instructions pop y then x and push x op y, immediates follow the opcode
+//
+//	STOP            halt
+//	PUSH n b        push the n bytes big endian integer b, 1 <= n <= 32
+//	POP             drop the top word
+//	DUP n           push a copy of the n-th word below the top, DUP 0 copies the top
+//	SWAP n          swap the top word with the n-th word below it, n >= 1
+//	ADD SUB MUL     arithmetic modulo 2^256
+//	DIV MOD         integer division, x / 0 = x % 0 = 0
+//	LT GT EQ        push 1 if the comparison holds else 0
+//	ISZERO          push 1 if the top word is 0 else 0
+//	HADD HSUB       add or subtract two Secret or two Anonymous values encrypted to the same base, every HSUB result
+//	                must be covered by the next non negative proof attached to the call
+//	JUMP            pop the destination and jump to it, the destination must be a JUMPDEST
+//	JUMPI           pop the condition and the destination, jump if the condition is not 0
+//	JUMPDEST        mark a jump destination
+//	SLOAD           pop the key, push the stored word or 0
+//	SSTORE          pop the word and the key, store the word
+//	ADDRESS CALLER  push the address of the contract, the address of the caller
+//	CALLVALUE       push the Plaintext amount sent with the call
+//	FUNCTION        push the function selector of the call
+//	ARG ARGC        pop i and push the i-th argument of the call, push the number of arguments
+//	EMIT            pop the word and add it to the values of the receipt
+//	REVERT          fail and revert the state changes of the call
+type OpCode byte
+
+const (
+	STOP OpCode = 0x00
+	PUSH OpCode = 0x01
+	POP OpCode = 0x02
+	DUP OpCode = 0x03
+	SWAP OpCode = 0x04
+
+	ADD OpCode = 0x10
+	SUB OpCode = 0x11
+	MUL OpCode = 0x12
+	DIV OpCode = 0x13
+	MOD OpCode = 0x14
+	LT OpCode = 0x15
+	GT OpCode = 0x16
+	EQ OpCode = 0x17
+	ISZERO OpCode = 0x18
+	HADD OpCode = 0x19
+	HSUB OpCode = 0x1a
+
+	JUMP OpCode = 0x20
+	JUMPI OpCode = 0x21
+	JUMPDEST OpCode = 0x22
+
+	SLOAD OpCode = 0x30
+	SSTORE OpCode = 0x31
+
+	ADDRESS OpCode = 0x40
+	CALLER OpCode = 0x41
+	CALLVALUE OpCode = 0x42
+	FUNCTION OpCode = 0x43
+	ARG OpCode = 0x44
+	ARGC OpCode = 0x45
+
+	EMIT OpCode = 0x50
+	REVERT OpCode = 0x51
+)
+
+const MaxStackDepth = 1024
+const WordLength = 32
+// MaxEmitted is the number of values a call can emit, the receipt counts them in one byte
+const MaxEmitted = 1 << 8 - 1
+
+// CallGas is charged for every call, DeployGas for every byte of deployed code
+const CallGas uint64 = 100
+const DeployGas uint64 = 10
+// ProofGas is charged for every non negative proof checked at the end of a call
+const ProofGas uint64 = 1000
+
+// gasCosts is the gas of every instruction, an opcode missing from the table is invalid
+var gasCosts = map[OpCode]uint64{
+	STOP: 0, PUSH: 1, POP: 1, DUP: 1, SWAP: 1,
+	ADD: 2, SUB: 2, MUL: 3, DIV: 3, MOD: 3, LT: 2, GT: 2, EQ: 2, ISZERO: 2, HADD: 10, HSUB: 10,
+	JUMP: 4, JUMPI: 5, JUMPDEST: 1,
+	SLOAD: 50, SSTORE: 100,
+	ADDRESS: 2, CALLER: 2, CALLVALUE: 2, FUNCTION: 2, ARG: 2, ARGC: 2,
+	EMIT: 20, REVERT: 0,
+}
+
+// immediates returns the number of immediate bytes following the opcode at pc
+func immediates(code []byte, pc int) int {
+	switch OpCode(code[pc]) {
+	case PUSH:
+		if pc+1 >= len(code) {return 1}
+		return 1 + int(code[pc+1])
+	case DUP, SWAP:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// jumpDests returns the positions of the JUMPDEST instructions, the immediates are skipped
+func jumpDests(code []byte) map[int]bool {
+	dests := make(map[int]bool)
+	for pc := 0; pc < len(code); pc += 1 + immediates(code, pc) {
+		if OpCode(code[pc]) == JUMPDEST {dests[pc] = true}
+	}
+	return dests
+}
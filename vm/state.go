@@ -0,0 +1,143 @@
+package vm
+
+import (
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/crypto/privacy"
+	"github.com/Acoustical/maskash/errors"
+	"math/big"
+)
+
+// Word is a stack or storage word, an integer modulo 2^256 or a Secret or Anonymous value the contract can not open,
+// Base is the base the value is encrypted to
+type Word struct {
+	Int *big.Int
+	Value privacy.Value
+	Base privacy.Base
+}
+
+func IntWord(v *big.Int) Word {return Word{Int: new(big.Int).Mod(v, wordModulus)}}
+
+// ValueWord wraps value, a Plaintext value becomes its amount
+func ValueWord(value privacy.Value) Word {
+	if value.ValueMode() == common.Plaintext {
+		v, _ := value.Solve(nil)
+		return IntWord(v)
+	}
+	return Word{Value: value}
+}
+
+// BaseWord wraps value encrypted to base
+func BaseWord(base privacy.Base, value privacy.Value) Word {
+	word := ValueWord(value)
+	if word.Confidential() {word.Base = base}
+	return word
+}
+
+// Confidential returns whether the word is a Secret or Anonymous value
+func (word Word) Confidential() bool {return word.Value != nil}
+
+// PrivacyValue returns the word as a value, an integer becomes a Plaintext value
+func (word Word) PrivacyValue() privacy.Value {
+	if word.Confidential() {return word.Value}
+	return new(privacy.PlaintextBase).SetValue(nil, new(big.Int).Set(word.Int))
+}
+
+var wordModulus = new(big.Int).Lsh(big.NewInt(1), 8 * WordLength)
+
+// State is the code and the storage of the contracts, every change is journaled so a failed call can be reverted
+type State struct {
+	code map[crypto.Address][]byte
+	storage map[crypto.Address]map[string]Word
+	creations map[string]uint64
+	journal []func()
+}
+
+func NewState() *State {
+	return &State{
+		code: make(map[crypto.Address][]byte),
+		storage: make(map[crypto.Address]map[string]Word),
+		creations: make(map[string]uint64),
+	}
+}
+
+// Code returns the code of the contract at address, nil if there is none
+func (state *State) Code(address crypto.Address) []byte {return state.code[address]}
+
+// IsContract returns whether code is deployed at address
+func (state *State) IsContract(address crypto.Address) bool {
+	_, ok := state.code[address]
+	return ok
+}
+
+// CreateNonce returns the number of contracts deployed by creator, the nonce of its next ContractAddress
+func (state *State) CreateNonce(creator privacy.Base) uint64 {return state.creations[creatorKey(creator)]}
+
+func (state *State) setCreateNonce(creator privacy.Base, nonce uint64) {
+	key := creatorKey(creator)
+	old, existed := state.creations[key]
+	state.creations[key] = nonce
+	state.journal = append(state.journal, func() {
+		if existed {
+			state.creations[key] = old
+		} else {
+			delete(state.creations, key)
+		}
+	})
+}
+
+func creatorKey(creator privacy.Base) string {return string(append([]byte{creator.BaseMode()}, creator.Bytes()...))}
+
+// Deploy sets the code of the contract at address, which must have no code yet
+func (state *State) Deploy(address crypto.Address, code []byte) error {
+	if _, ok := state.code[address]; ok {return errors.NewInvalidTxError("contract exists")}
+	state.code[address] = append([]byte{}, code...)
+	state.journal = append(state.journal, func() {delete(state.code, address)})
+	return nil
+}
+
+// Load returns the word stored at key by the contract at address, 0 if nothing is stored
+func (state *State) Load(address crypto.Address, key *big.Int) Word {
+	if word, ok := state.storage[address][storageKey(key)]; ok {return word}
+	return Word{Int: new(big.Int)}
+}
+
+// Store stores word at key for the contract at address
+func (state *State) Store(address crypto.Address, key *big.Int, word Word) {
+	cells, ok := state.storage[address]
+	if !ok {
+		cells = make(map[string]Word)
+		state.storage[address] = cells
+	}
+	k := storageKey(key)
+	old, existed := cells[k]
+	cells[k] = word
+	state.journal = append(state.journal, func() {
+		if existed {
+			cells[k] = old
+		} else {
+			delete(cells, k)
+		}
+	})
+}
+
+func storageKey(key *big.Int) string {
+	b := make([]byte, WordLength)
+	return string(new(big.Int).Mod(key, wordModulus).FillBytes(b))
+}
+
+// Snapshot returns the id of the current state for Rollback
+func (state *State) Snapshot() int {return len(state.journal)}
+
+// Rollback reverts the changes made after the snapshot
+func (state *State) Rollback(snapshot int) error {
+	if snapshot < 0 || snapshot > len(state.journal) {return errors.NewInvalidSnapshotError(snapshot)}
+	for i := len(state.journal) - 1; i >= snapshot; i-- {
+		state.journal[i]()
+	}
+	state.journal = state.journal[:snapshot]
+	return nil
+}
+
+// Commit drops the journal, the current state can no longer be rolled back
+func (state *State) Commit() {state.journal = nil}
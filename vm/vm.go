@@ -0,0 +1,299 @@
+package vm
+
+import (
+	"bytes"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/crypto/privacy"
+	"github.com/Acoustical/maskash/errors"
+	"math/big"
+)
+
+// Context is the environment of a call of the contract at Address, Proofs show the confidential Args and then
+// the HSUB results are not negative under Bases in order, a confidential argument is encrypted to its base
+type Context struct {
+	Address crypto.Address
+	Caller *big.Int
+	CallValue *big.Int
+	Function *big.Int
+	Args []Word
+	Bases []privacy.Base
+	Proofs []*privacy.NonNegativeProof
+}
+
+// Result is the outcome of a call, GasUsed is also set when the call fails
+type Result struct {
+	GasUsed uint64
+	Emitted []Word
+}
+
+// VM executes the contracts of a state deterministically
+type VM struct {
+	state *State
+}
+
+// New returns a VM over state, nil starts from an empty state
+func New(state *State) *VM {
+	if state == nil {state = NewState()}
+	return &VM{state: state}
+}
+
+func (vm *VM) State() *State {return vm.state}
+
+// machine is the stack, the program counter and the gas of a running call
+type machine struct {
+	vm *VM
+	ctx *Context
+	code []byte
+	dests map[int]bool
+	stack []Word
+	pc int
+	gas, used uint64
+	emitted []Word
+	args []Word
+	proved int
+	checks []Word
+}
+
+// Call runs the code of the contract ctx.Address with gas, the state changes of a failed call are reverted
+func (vm *VM) Call(ctx *Context, gas uint64) (*Result, error) {
+	code := vm.state.Code(ctx.Address)
+	if code == nil {return &Result{}, errors.NewContractError(0, "no contract")}
+	m := &machine{vm: vm, ctx: ctx, code: code, dests: jumpDests(code), gas: gas}
+	snapshot := vm.state.Snapshot()
+	err := m.charge(CallGas)
+	if err == nil {err = m.bindArgs()}
+	if err == nil {err = m.run()}
+	if err == nil {err = m.checkProofs()}
+	if err != nil {
+		_ = vm.state.Rollback(snapshot)
+		return &Result{GasUsed: m.used}, err
+	}
+	return &Result{GasUsed: m.used, Emitted: m.emitted}, nil
+}
+
+func (m *machine) charge(gas uint64) error {
+	if m.gas - m.used < gas {
+		m.used = m.gas
+		return errors.NewOutOfGasError(m.gas)
+	}
+	m.used += gas
+	return nil
+}
+
+func (m *machine) push(word Word) error {
+	if len(m.stack) >= MaxStackDepth {return errors.NewContractError(m.pc, "stack overflow")}
+	m.stack = append(m.stack, word)
+	return nil
+}
+
+func (m *machine) pop() (Word, error) {
+	if len(m.stack) == 0 {return Word{}, errors.NewContractError(m.pc, "stack underflow")}
+	word := m.stack[len(m.stack)-1]
+	m.stack = m.stack[:len(m.stack)-1]
+	return word, nil
+}
+
+// popInt pops an integer word
+func (m *machine) popInt() (*big.Int, error) {
+	word, err := m.pop()
+	if err != nil {return nil, err}
+	if word.Confidential() {return nil, errors.NewContractError(m.pc, "confidential operand")}
+	return word.Int, nil
+}
+
+func (m *machine) pushInt(v *big.Int) error {return m.push(IntWord(v))}
+
+func (m *machine) pushBool(b bool) error {
+	if b {return m.pushInt(big.NewInt(1))}
+	return m.pushInt(new(big.Int))
+}
+
+// immediate returns the immediate byte after the opcode
+func (m *machine) immediate() (int, error) {
+	if m.pc+1 >= len(m.code) {return 0, errors.NewContractError(m.pc, "missing immediate")}
+	return int(m.code[m.pc+1]), nil
+}
+
+func (m *machine) run() error {
+	for m.pc < len(m.code) {
+		op := OpCode(m.code[m.pc])
+		cost, ok := gasCosts[op]
+		if !ok {return errors.NewContractError(m.pc, "invalid opcode")}
+		err := m.charge(cost)
+		if err != nil {return err}
+		if op == STOP {return nil}
+		next := m.pc + 1 + immediates(m.code, m.pc)
+		next, err = m.step(op, next)
+		if err != nil {return err}
+		m.pc = next
+	}
+	return nil
+}
+
+// step executes op and returns the position of the next instruction
+func (m *machine) step(op OpCode, next int) (int, error) {
+	switch op {
+	case PUSH:
+		n, err := m.immediate()
+		if err != nil {return 0, err}
+		if n == 0 || n > WordLength || next > len(m.code) {return 0, errors.NewContractError(m.pc, "bad push")}
+		return next, m.pushInt(new(big.Int).SetBytes(m.code[m.pc+2:next]))
+	case POP:
+		_, err := m.pop()
+		return next, err
+	case DUP:
+		n, err := m.immediate()
+		if err != nil {return 0, err}
+		if n >= len(m.stack) {return 0, errors.NewContractError(m.pc, "stack underflow")}
+		return next, m.push(m.stack[len(m.stack)-1-n])
+	case SWAP:
+		n, err := m.immediate()
+		if err != nil {return 0, err}
+		if n == 0 || n >= len(m.stack) {return 0, errors.NewContractError(m.pc, "stack underflow")}
+		top := len(m.stack) - 1
+		m.stack[top], m.stack[top-n] = m.stack[top-n], m.stack[top]
+		return next, nil
+	case ADD, SUB, MUL, DIV, MOD, LT, GT, EQ:
+		y, err := m.popInt()
+		if err != nil {return 0, err}
+		x, err := m.popInt()
+		if err != nil {return 0, err}
+		return next, m.arithmetic(op, x, y)
+	case HADD, HSUB:
+		y, err := m.pop()
+		if err != nil {return 0, err}
+		x, err := m.pop()
+		if err != nil {return 0, err}
+		if !x.Confidential() || !y.Confidential() {return 0, errors.NewContractError(m.pc, "plaintext operand")}
+		if !sameBase(x.Base, y.Base) {return 0, errors.NewContractError(m.pc, "operands of different bases")}
+		var value privacy.Value
+		if op == HADD {
+			value, err = privacy.AddValues(x.Value, y.Value)
+		} else {
+			value, err = privacy.SubValues(x.Value, y.Value)
+		}
+		if err != nil {return 0, errors.NewContractError(m.pc, err.Error())}
+		word := Word{Value: value, Base: x.Base}
+		if op == HSUB {m.checks = append(m.checks, word)}
+		return next, m.push(word)
+	case ISZERO:
+		x, err := m.popInt()
+		if err != nil {return 0, err}
+		return next, m.pushBool(x.Sign() == 0)
+	case JUMP, JUMPI:
+		cond := big.NewInt(1)
+		var err error
+		if op == JUMPI {
+			cond, err = m.popInt()
+			if err != nil {return 0, err}
+		}
+		dest, err := m.popInt()
+		if err != nil {return 0, err}
+		if cond.Sign() == 0 {return next, nil}
+		if !dest.IsInt64() || !m.dests[int(dest.Int64())] {return 0, errors.NewContractError(m.pc, "bad jump destination")}
+		return int(dest.Int64()), nil
+	case JUMPDEST:
+		return next, nil
+	case SLOAD:
+		key, err := m.popInt()
+		if err != nil {return 0, err}
+		return next, m.push(m.vm.state.Load(m.ctx.Address, key))
+	case SSTORE:
+		word, err := m.pop()
+		if err != nil {return 0, err}
+		key, err := m.popInt()
+		if err != nil {return 0, err}
+		m.vm.state.Store(m.ctx.Address, key, word)
+		return next, nil
+	case ADDRESS:
+		return next, m.pushInt(new(big.Int).SetBytes(m.ctx.Address[:]))
+	case CALLER:
+		return next, m.pushInt(orZero(m.ctx.Caller))
+	case CALLVALUE:
+		return next, m.pushInt(orZero(m.ctx.CallValue))
+	case FUNCTION:
+		return next, m.pushInt(orZero(m.ctx.Function))
+	case ARG:
+		i, err := m.popInt()
+		if err != nil {return 0, err}
+		if !i.IsInt64() || i.Int64() >= int64(len(m.args)) {return 0, errors.NewContractError(m.pc, "no argument")}
+		return next, m.push(m.args[i.Int64()])
+	case ARGC:
+		return next, m.pushInt(big.NewInt(int64(len(m.ctx.Args))))
+	case EMIT:
+		if len(m.emitted) >= MaxEmitted {return 0, errors.NewContractError(m.pc, "too many values")}
+		word, err := m.pop()
+		if err != nil {return 0, err}
+		m.emitted = append(m.emitted, word)
+		return next, nil
+	case REVERT:
+		return 0, errors.NewContractError(m.pc, "revert")
+	}
+	return 0, errors.NewContractError(m.pc, "invalid opcode")
+}
+
+// bindArgs checks every confidential argument against the non negative proof of the call in the same position
+// and encrypts it to the base of the proof
+func (m *machine) bindArgs() error {
+	if len(m.ctx.Bases) != len(m.ctx.Proofs) {return errors.NewContractError(m.pc, "missing non negative proof")}
+	m.args = make([]Word, len(m.ctx.Args))
+	n := 0
+	for i, arg := range m.ctx.Args {
+		m.args[i] = arg
+		if !arg.Confidential() {continue}
+		if n >= len(m.ctx.Proofs) {return errors.NewContractError(m.pc, "missing non negative proof")}
+		err := m.charge(ProofGas)
+		if err != nil {return err}
+		if !privacy.VerifyNonNegative(m.ctx.Bases[n], arg.Value, m.ctx.Proofs[n]) {return errors.NewContractError(m.pc, "bad non negative proof")}
+		m.args[i].Base = m.ctx.Bases[n]
+		n++
+	}
+	m.proved = n
+	return nil
+}
+
+// checkProofs checks every HSUB result against the non negative proof of the call after the arguments,
+// the proof must be made under the base the result is encrypted to
+func (m *machine) checkProofs() error {
+	if len(m.checks) != len(m.ctx.Proofs) - m.proved {return errors.NewContractError(m.pc, "missing non negative proof")}
+	for i, word := range m.checks {
+		err := m.charge(ProofGas)
+		if err != nil {return err}
+		base := m.ctx.Bases[m.proved+i]
+		if !sameBase(base, word.Base) || !privacy.VerifyNonNegative(word.Base, word.Value, m.ctx.Proofs[m.proved+i]) {return errors.NewContractError(m.pc, "bad non negative proof")}
+	}
+	return nil
+}
+
+// sameBase returns whether a and b are the same known base
+func sameBase(a, b privacy.Base) bool {
+	return a != nil && b != nil && a.BaseMode() == b.BaseMode() && bytes.Equal(a.Bytes(), b.Bytes())
+}
+
+func (m *machine) arithmetic(op OpCode, x, y *big.Int) error {
+	switch op {
+	case ADD:
+		return m.pushInt(new(big.Int).Add(x, y))
+	case SUB:
+		return m.pushInt(new(big.Int).Sub(x, y))
+	case MUL:
+		return m.pushInt(new(big.Int).Mul(x, y))
+	case DIV:
+		if y.Sign() == 0 {return m.pushInt(new(big.Int))}
+		return m.pushInt(new(big.Int).Div(x, y))
+	case MOD:
+		if y.Sign() == 0 {return m.pushInt(new(big.Int))}
+		return m.pushInt(new(big.Int).Mod(x, y))
+	case LT:
+		return m.pushBool(x.Cmp(y) < 0)
+	case GT:
+		return m.pushBool(x.Cmp(y) > 0)
+	default:
+		return m.pushBool(x.Cmp(y) == 0)
+	}
+}
+
+func orZero(v *big.Int) *big.Int {
+	if v == nil {return new(big.Int)}
+	return v
+}
@@ -0,0 +1,154 @@
+package vm
+
+import (
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/crypto/privacy"
+	"github.com/Acoustical/maskash/errors"
+	"golang.org/x/crypto/bn256"
+	"math/big"
+	"testing"
+)
+
+// counter adds the first argument to the word at key 0 and emits the sum, function 2 stores 9 at key 0 and reverts
+var counter = []byte{
+	byte(FUNCTION), byte(PUSH), 1, 2, byte(EQ), byte(PUSH), 1, 28, byte(SWAP), 1, byte(JUMPI),
+	byte(PUSH), 1, 0, byte(PUSH), 1, 0, byte(SLOAD), byte(PUSH), 1, 0, byte(ARG), byte(ADD),
+	byte(DUP), 0, byte(EMIT), byte(SSTORE), byte(STOP),
+	byte(JUMPDEST), byte(PUSH), 1, 0, byte(PUSH), 1, 9, byte(SSTORE), byte(REVERT),
+}
+
+func TestVM(t *testing.T) {
+	vm := New(nil)
+	var address crypto.Address
+	address[0] = 1
+	errors.Handle(vm.State().Deploy(address, counter))
+	if vm.State().Deploy(address, counter) == nil {
+		t.Errorf("contract deployed twice at one address")
+	}
+
+	// a confidential argument comes with the proof it is not negative
+	rl, _ := crypto.RandomZq(1)
+	key := privacy.NewRandomPrivateKey()
+	secret := ValueWord(key.GenSecretBase().SetValue(big.NewInt(1919), rl[0], true))
+	proof, err := privacy.ProveNonNegative(key, key.GenSecretBase(), secret.Value)
+	errors.Handle(err)
+	bases, proofs := []privacy.Base{key.GenSecretBase()}, []*privacy.NonNegativeProof{proof}
+
+	call := func(function int64, arg Word, gas uint64) (*Result, error) {
+		ctx := &Context{Address: address, Function: big.NewInt(function), Args: []Word{arg}}
+		if arg.Confidential() {ctx.Bases, ctx.Proofs = bases, proofs}
+		return vm.Call(ctx, gas)
+	}
+	for _, want := range []int64{5, 10} {
+		result, err := call(1, IntWord(big.NewInt(5)), 1000)
+		errors.Handle(err)
+		if len(result.Emitted) != 1 || result.Emitted[0].Int.Int64() != want {
+			t.Errorf("counter emitted %v, want %d", result.Emitted, want)
+		}
+	}
+
+	if _, err := call(2, IntWord(big.NewInt(5)), 1000); err == nil {
+		t.Errorf("reverted call succeeded")
+	}
+	if v := vm.State().Load(address, big.NewInt(0)); v.Int.Int64() != 10 {
+		t.Errorf("reverted call kept the stored word %d", v.Int)
+	}
+
+	result, err := call(1, IntWord(big.NewInt(5)), CallGas + 10)
+	if _, ok := err.(*errors.OutOfGasError); !ok || result.GasUsed != CallGas + 10 {
+		t.Errorf("call out of gas not reported, used %d gas", result.GasUsed)
+	}
+	if v := vm.State().Load(address, big.NewInt(0)); v.Int.Int64() != 10 {
+		t.Errorf("call out of gas kept the stored word %d", v.Int)
+	}
+
+	if _, err = call(1, secret, 10000); err == nil {
+		t.Errorf("integer arithmetic on a confidential word succeeded")
+	}
+
+	// a confidential word can be stored and loaded as it is
+	var holder crypto.Address
+	holder[0] = 2
+	errors.Handle(vm.State().Deploy(holder, []byte{
+		byte(PUSH), 1, 1, byte(PUSH), 1, 0, byte(ARG), byte(SSTORE),
+		byte(PUSH), 1, 1, byte(SLOAD), byte(EMIT),
+	}))
+	if _, err = vm.Call(&Context{Address: holder, Args: []Word{secret}}, 10000); err == nil {
+		t.Errorf("confidential argument without a non negative proof accepted")
+	}
+	result, err = vm.Call(&Context{Address: holder, Args: []Word{secret}, Bases: bases, Proofs: proofs}, 10000)
+	errors.Handle(err)
+	if len(result.Emitted) != 1 || result.Emitted[0].Value != secret.Value {
+		t.Errorf("confidential word not kept in the storage")
+	}
+
+	var jumper crypto.Address
+	jumper[0] = 3
+	errors.Handle(vm.State().Deploy(jumper, []byte{byte(PUSH), 1, 4, byte(JUMP), byte(STOP)}))
+	if _, err = vm.Call(&Context{Address: jumper}, 1000); err == nil {
+		t.Errorf("jump to a non JUMPDEST succeeded")
+	}
+
+	var emitter crypto.Address
+	emitter[0] = 5
+	errors.Handle(vm.State().Deploy(emitter, []byte{byte(JUMPDEST), byte(PUSH), 1, 0, byte(EMIT), byte(PUSH), 1, 0, byte(JUMP)}))
+	result, err = vm.Call(&Context{Address: emitter}, 100000)
+	if _, ok := err.(*errors.ContractError); !ok || len(result.Emitted) > MaxEmitted {
+		t.Errorf("call emitted %d values above the receipt limit", len(result.Emitted))
+	}
+}
+
+func TestConfidentialStorage(t *testing.T) {
+	vm := New(nil)
+	var address crypto.Address
+	address[0] = 4
+	// withdraw subtracts the first argument from the value at key 0
+	errors.Handle(vm.State().Deploy(address, []byte{
+		byte(PUSH), 1, 0, byte(PUSH), 1, 0, byte(SLOAD), byte(PUSH), 1, 0, byte(ARG), byte(HSUB), byte(SSTORE),
+	}))
+	prv := privacy.NewRandomPrivateKey()
+	base := prv.GenSecretBase()
+	rl, _ := crypto.RandomZq(3)
+	vm.State().Store(address, big.NewInt(0), BaseWord(base, base.SetValue(big.NewInt(100), rl[0], true)))
+	vm.State().Commit()
+
+	// withdraw proves the amount under the base of key and the rest of the cell under the base of the cell,
+	// a value that can not be proved gets the proof of the other one
+	withdraw := func(key *privacy.PrivateKey, amount privacy.Value, proved bool) error {
+		ctx := &Context{Address: address, Args: []Word{ValueWord(amount)}}
+		if proved {
+			rest, err := privacy.SubValues(vm.State().Load(address, big.NewInt(0)).Value, amount)
+			errors.Handle(err)
+			argProof, argErr := privacy.ProveNonNegative(key, key.GenSecretBase(), amount)
+			restProof, restErr := privacy.ProveNonNegative(prv, base, rest)
+			if argErr != nil {argProof = restProof}
+			if restErr != nil {restProof = argProof}
+			ctx.Bases, ctx.Proofs = []privacy.Base{key.GenSecretBase(), base}, []*privacy.NonNegativeProof{argProof, restProof}
+		}
+		_, err := vm.Call(ctx, 10000)
+		return err
+	}
+	errors.Handle(withdraw(prv, base.SetValue(big.NewInt(30), rl[1], true), true))
+	if v, _ := vm.State().Load(address, big.NewInt(0)).Value.Solve(prv); v.Int64() != 70 {
+		t.Errorf("confidential cell holds %d, want 70", v)
+	}
+	if withdraw(prv, base.SetValue(big.NewInt(10), rl[2], true), false) == nil {
+		t.Errorf("subtraction without a non negative proof committed")
+	}
+	if withdraw(prv, base.SetValue(big.NewInt(80), rl[2], true), true) == nil {
+		t.Errorf("negative confidential cell committed")
+	}
+	// a negative amount would raise the cell to 120
+	negative := new(big.Int).Sub(bn256.Order, big.NewInt(50))
+	if withdraw(prv, base.SetValue(negative, rl[2], true), true) == nil {
+		t.Errorf("negative confidential argument accepted")
+	}
+	// the rest of the cell can not be proved under another base
+	other := privacy.NewRandomPrivateKey()
+	if withdraw(other, other.GenSecretBase().SetValue(big.NewInt(10), rl[2], true), true) == nil {
+		t.Errorf("confidential values of different bases subtracted")
+	}
+	if v, _ := vm.State().Load(address, big.NewInt(0)).Value.Solve(prv); v.Int64() != 70 {
+		t.Errorf("failed withdrawals changed the cell to %d", v)
+	}
+}
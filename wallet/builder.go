@@ -0,0 +1,136 @@
+package wallet
+
+import (
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/crypto/elgamal"
+	"github.com/Acoustical/maskash/crypto/privacy"
+	"github.com/Acoustical/maskash/errors"
+	"github.com/Acoustical/maskash/ledger"
+	"github.com/Acoustical/maskash/storage"
+	"math/big"
+)
+
+// Payment is a built transfer, Balance covers the inputs, the payee outputs and the change outputs and Tx is the
+// transaction signed by the spend keys of the inputs
+type Payment struct {
+	Spent []*storage.Record
+	Inputs []privacy.Slot
+	Outputs []*privacy.Output
+	Change []*privacy.Output
+	Balance *privacy.Balance
+	Tx *ledger.Tx
+}
+
+// Pay pays amount and fee to the Secret or Anonymous base to from the unspent native outputs chosen by selector on the
+// chain chainID, only the outputs of the keys the wallet can spend with are chosen. Amounts above MaxShortValue are
+// split into several outputs and the change goes back to the owner of the first input
+func (w *Wallet) Pay(chainID uint64, to privacy.Base, amount, fee *big.Int, selector Selector) (*Payment, error) {
+	if fee == nil {fee = new(big.Int)}
+	if amount.Sign() <= 0 || fee.Sign() < 0 {return nil, errors.NewUnbalancedError()}
+	if selector == nil {selector = LargestFirst}
+
+	unspent, err := w.Unspent()
+	if err != nil {return nil, err}
+	native := make([]*storage.Record, 0, len(unspent))
+	w.mu.RLock()
+	for _, record := range unspent {
+		if record.Asset == privacy.NativeAsset && !assetTagged(record.Slot) && w.spends[w.secret[string(record.Owner)]] != nil {
+			native = append(native, record)
+		}
+	}
+	w.mu.RUnlock()
+	target := new(big.Int).Add(amount, fee)
+	selected, err := selector(native, target)
+	if err != nil {return nil, err}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	payment := &Payment{Spent: selected}
+	inputs := make([]*privacy.Input, len(selected))
+	spends := make([]*privacy.PrivateKey, len(selected))
+	sum := new(big.Int)
+	for i, record := range selected {
+		key := w.keys[w.secret[string(record.Owner)]]
+		spends[i] = w.spends[w.secret[string(record.Owner)]]
+		slot, err := inputSlot(record.Slot)
+		if err != nil {return nil, err}
+		inputs[i] = &privacy.Input{Slot: slot, Key: key.Key()}
+		payment.Inputs = append(payment.Inputs, slot)
+		sum.Add(sum, record.Amount)
+	}
+
+	payment.Outputs, err = newOutputs(to, amount, w.auditor)
+	if err != nil {return nil, err}
+	change := sum.Sub(sum, target)
+	if change.Sign() > 0 {
+		owner := w.keys[w.secret[string(selected[0].Owner)]]
+		var base privacy.Base
+		if to.BaseMode() == common.Anonymous {
+			base = owner.GenAnonymousBase()
+		} else {
+			base = owner.GenSecretBase()
+		}
+		payment.Change, err = newOutputs(base, change, w.auditor)
+		if err != nil {return nil, err}
+	}
+
+	outputs := append(append([]*privacy.Output{}, payment.Outputs...), payment.Change...)
+	payment.Balance, err = privacy.NewBalance(inputs, outputs, fee)
+	if err != nil {return nil, err}
+
+	payment.Tx = &ledger.Tx{Inputs: payment.Inputs, Balance: payment.Balance, Fee: fee}
+	for _, output := range outputs {
+		payment.Tx.Outputs = append(payment.Tx.Outputs, output.Slot)
+	}
+	err = payment.Tx.Sign(chainID, spends...)
+	if err != nil {return nil, err}
+	return payment, nil
+}
+
+// SplitAmount splits v into parts of at most MaxShortValue so each part fits the range proof of an output
+func SplitAmount(v *big.Int) []*big.Int {
+	max := big.NewInt(int64(common.MaxShortValue))
+	parts := make([]*big.Int, 0)
+	rest := new(big.Int).Set(v)
+	for rest.Cmp(max) > 0 {
+		parts = append(parts, new(big.Int).Set(max))
+		rest.Sub(rest, max)
+	}
+	if rest.Sign() > 0 {parts = append(parts, rest)}
+	return parts
+}
+
+// newOutputs creates the outputs paying v to base audited by auditor
+func newOutputs(base privacy.Base, v *big.Int, auditor *elgamal.PublicKey) ([]*privacy.Output, error) {
+	parts := SplitAmount(v)
+	rl, err := crypto.RandomZq(len(parts))
+	if err != nil {return nil, err}
+	outputs := make([]*privacy.Output, len(parts))
+	for i, part := range parts {
+		var slot privacy.Slot
+		switch b := base.(type) {
+		case *privacy.SecretBase:
+			slot, err = b.NewSecretOutputSlot(part, rl[i], true, common.NoneContractSlot, nil, auditor)
+		case *privacy.AnonymousBase:
+			slot, err = b.NewAnonymousOutputSlot(part, rl[i], true, common.NoneContractSlot, nil, auditor)
+		default:
+			return nil, errors.NewWrongSlotModeError(common.Secret, base.BaseMode())
+		}
+		if err != nil {return nil, err}
+		outputs[i] = &privacy.Output{Slot: slot, Value: part, R: rl[i]}
+	}
+	return outputs, nil
+}
+
+// inputSlot returns the input slot spending an owned output
+func inputSlot(slot privacy.Slot) (privacy.Slot, error) {
+	switch s := slot.(type) {
+	case *privacy.SecretSlot:
+		return privacy.NewSecretInputSlot(s), nil
+	case *privacy.AnonymousSlot:
+		return privacy.NewAnonymousInputSlot(s), nil
+	default:
+		return nil, errors.NewWrongSlotModeError(common.Secret, slot.SlotMode())
+	}
+}
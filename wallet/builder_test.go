@@ -0,0 +1,67 @@
+package wallet
+
+import (
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/crypto/privacy"
+	"github.com/Acoustical/maskash/errors"
+	"github.com/Acoustical/maskash/ledger"
+	"math/big"
+	"testing"
+)
+
+func TestPay(t *testing.T) {
+	key := privacy.NewRandomAccountKey()
+	w, viewer := New(nil, nil), New(nil, nil)
+	w.AddAccountKey(key)
+	viewer.AddViewKey(key.ViewKey())
+
+	rl, _ := crypto.RandomZq(3)
+	funds := make([]privacy.Slot, 3)
+	for i, v := range []int64{1000000, 900000, 300} {
+		slot, err := key.GenAnonymousBase().NewAnonymousOutputSlot(big.NewInt(v), rl[i], true, common.NoneContractSlot, nil, nil)
+		errors.Handle(err)
+		funds[i] = slot
+	}
+	_, err := w.Ingest(funds...)
+	errors.Handle(err)
+	_, err = viewer.Ingest(funds...)
+	errors.Handle(err)
+	l := ledger.New(1, nil, nil, nil)
+	errors.Handle(l.Genesis(funds...))
+
+	payee := privacy.NewRandomPrivateKey().GenAnonymousBase()
+	if _, err = viewer.Pay(1, payee, big.NewInt(100), nil, LargestFirst); err == nil {
+		t.Errorf("view only wallet paid")
+	}
+	payment, err := w.Pay(1, payee, big.NewInt(1500000), big.NewInt(100), LargestFirst)
+	errors.Handle(err)
+	if len(payment.Inputs) != 2 || len(payment.Outputs) != 2 || len(payment.Change) != 1 {
+		t.Errorf("payment has %d inputs, %d outputs and %d change outputs", len(payment.Inputs), len(payment.Outputs), len(payment.Change))
+	}
+	if !payment.Balance.Check() {
+		t.Errorf("payment balance check failed")
+	}
+	for _, output := range append(payment.Outputs, payment.Change...) {
+		if !output.Slot.CheckZKs() {
+			t.Errorf("payment output check failed")
+		}
+	}
+
+	if err = l.Apply(payment.Tx); err != nil {
+		t.Errorf("ledger rejected the payment: %v", err)
+	}
+
+	_, err = w.Ingest(payment.Inputs...)
+	errors.Handle(err)
+	for _, change := range payment.Change {
+		_, err = w.Ingest(change.Slot)
+		errors.Handle(err)
+	}
+	if balance, _ := w.Balance(privacy.NativeAsset); balance.Int64() != 400200 {
+		t.Errorf("wallet balance %d after payment, want 400200", balance)
+	}
+	if _, err = w.Pay(1, payee, big.NewInt(500000), nil, RandomSelection); err == nil {
+		t.Errorf("wallet paid more than its balance")
+	}
+}
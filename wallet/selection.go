@@ -0,0 +1,86 @@
+package wallet
+
+import (
+	"github.com/Acoustical/maskash/crypto/zkproofs"
+	"github.com/Acoustical/maskash/errors"
+	"github.com/Acoustical/maskash/storage"
+	"math/big"
+	"sort"
+)
+
+// MaxBranchAndBoundTries bounds the search of BranchAndBound before it falls back to LargestFirst
+const MaxBranchAndBoundTries int = 100000
+
+// Selector chooses unspent outputs paying at least target
+type Selector func(unspent []*storage.Record, target *big.Int) ([]*storage.Record, error)
+
+// LargestFirst spends the largest outputs first, it uses the fewest inputs
+func LargestFirst(unspent []*storage.Record, target *big.Int) ([]*storage.Record, error) {
+	sorted := sortedByAmount(unspent)
+	return accumulate(sorted, target)
+}
+
+// BranchAndBound searches a set of outputs paying exactly target so no change output is needed,
+// it falls back to LargestFirst if there is none
+func BranchAndBound(unspent []*storage.Record, target *big.Int) ([]*storage.Record, error) {
+	sorted := sortedByAmount(unspent)
+	remaining := make([]*big.Int, len(sorted)+1)
+	remaining[len(sorted)] = new(big.Int)
+	for i := len(sorted)-1; i >= 0; i-- {
+		remaining[i] = new(big.Int).Add(remaining[i+1], sorted[i].Amount)
+	}
+	if remaining[0].Cmp(target) < 0 {return nil, errors.NewInsufficientFundsError(remaining[0], target)}
+
+	tries := 0
+	chosen := make([]bool, len(sorted))
+	var search func(i int, sum *big.Int) bool
+	search = func(i int, sum *big.Int) bool {
+		tries++
+		switch sum.Cmp(target) {
+		case 0:
+			return true
+		case 1:
+			return false
+		}
+		if i == len(sorted) || tries > MaxBranchAndBoundTries {return false}
+		if new(big.Int).Add(sum, remaining[i]).Cmp(target) < 0 {return false}
+		chosen[i] = true
+		if search(i+1, new(big.Int).Add(sum, sorted[i].Amount)) {return true}
+		chosen[i] = false
+		return search(i+1, sum)
+	}
+	if !search(0, new(big.Int)) {return accumulate(sorted, target)}
+
+	selected := make([]*storage.Record, 0)
+	for i, record := range sorted {
+		if chosen[i] {selected = append(selected, record)}
+	}
+	return selected, nil
+}
+
+// RandomSelection spends outputs in a random order, so the inputs do not reveal the amounts held
+func RandomSelection(unspent []*storage.Record, target *big.Int) ([]*storage.Record, error) {
+	pi, err := zkproofs.RandomPermutation(len(unspent))
+	if err != nil {return nil, err}
+	shuffled := make([]*storage.Record, len(unspent))
+	for i, j := range pi {
+		shuffled[i] = unspent[j]
+	}
+	return accumulate(shuffled, target)
+}
+
+// accumulate takes outputs in order until they pay target
+func accumulate(records []*storage.Record, target *big.Int) ([]*storage.Record, error) {
+	sum := new(big.Int)
+	for i, record := range records {
+		sum.Add(sum, record.Amount)
+		if sum.Cmp(target) >= 0 {return records[:i+1], nil}
+	}
+	return nil, errors.NewInsufficientFundsError(sum, target)
+}
+
+func sortedByAmount(records []*storage.Record) []*storage.Record {
+	sorted := append([]*storage.Record{}, records...)
+	sort.SliceStable(sorted, func(i, j int) bool {return sorted[i].Amount.Cmp(sorted[j].Amount) > 0})
+	return sorted
+}
@@ -0,0 +1,56 @@
+package wallet
+
+import (
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/storage"
+	"math/big"
+	"testing"
+)
+
+func amountRecords(amounts ...int64) []*storage.Record {
+	records := make([]*storage.Record, len(amounts))
+	for i, amount := range amounts {
+		records[i] = &storage.Record{Hash: crypto.Hash_(big.NewInt(int64(i))), Amount: big.NewInt(amount)}
+	}
+	return records
+}
+
+func sumRecords(records []*storage.Record) int64 {
+	var sum int64
+	for _, record := range records {
+		sum += record.Amount.Int64()
+	}
+	return sum
+}
+
+func TestSelection(t *testing.T) {
+	unspent := amountRecords(5, 40, 12, 30, 8)
+	target := big.NewInt(50)
+
+	selected, err := LargestFirst(unspent, target)
+	if err != nil || len(selected) != 2 || sumRecords(selected) != 70 {
+		t.Errorf("largest first selected %d outputs of %d", len(selected), sumRecords(selected))
+	}
+	selected, err = BranchAndBound(unspent, target)
+	if err != nil || sumRecords(selected) != 50 {
+		t.Errorf("branch and bound selected %d, want an exact match", sumRecords(selected))
+	}
+	selected, err = BranchAndBound(unspent, big.NewInt(94))
+	if err != nil || sumRecords(selected) < 94 {
+		t.Errorf("branch and bound fallback selected %d", sumRecords(selected))
+	}
+	selected, err = RandomSelection(unspent, target)
+	if err != nil || sumRecords(selected) < 50 {
+		t.Errorf("random selection selected %d", sumRecords(selected))
+	}
+	if _, err = RandomSelection(unspent, big.NewInt(96)); err == nil {
+		t.Errorf("selection paid more than the unspent outputs")
+	}
+}
+
+func TestSplitAmount(t *testing.T) {
+	parts := SplitAmount(big.NewInt(2500000))
+	if len(parts) != 3 || parts[2].Int64() != 2500000 - 2 * (1 << 20 - 1) {
+		t.Errorf("split into %d parts", len(parts))
+	}
+}
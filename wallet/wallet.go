@@ -0,0 +1,209 @@
+package wallet
+
+import (
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/crypto/elgamal"
+	"github.com/Acoustical/maskash/crypto/privacy"
+	"github.com/Acoustical/maskash/storage"
+	"math/big"
+	"sync"
+)
+
+// Wallet recognizes the slots owned by its keys and tracks them in a Store,
+// Plaintext slots are owned by address, Secret slots by h and Anonymous slots by scanning
+type Wallet struct {
+	keys []*privacy.ViewKey
+	spends []*privacy.PrivateKey
+	secret map[string]int
+	plaintext map[string]int
+	store storage.Store
+	auditor *elgamal.PublicKey
+	mu sync.RWMutex
+}
+
+// New returns an empty wallet over store, a nil store keeps the records in memory. The outputs it builds carry an
+// AuditTag to auditor unless it is nil
+func New(store storage.Store, auditor *elgamal.PublicKey) *Wallet {
+	if store == nil {store = storage.NewMemoryStore()}
+	return &Wallet{
+		secret: make(map[string]int),
+		plaintext: make(map[string]int),
+		store: store,
+		auditor: auditor,
+	}
+}
+
+// AddKey adds a single key which both owns the plaintext address and solves the values
+func (w *Wallet) AddKey(prv *privacy.PrivateKey) {w.addKey(privacy.NewSeparateAccountKey(prv, prv).ViewKey(), prv)}
+
+// AddAccountKey adds an account, the wallet keeps its spend key to authorize the payments
+func (w *Wallet) AddAccountKey(key *privacy.AccountKey) {w.addKey(key.ViewKey(), key.SpendKey())}
+
+// AddViewKey adds a view key, the wallet tracks the slots of the account without being able to spend them
+func (w *Wallet) AddViewKey(key *privacy.ViewKey) {w.addKey(key, nil)}
+
+func (w *Wallet) addKey(key *privacy.ViewKey, spend *privacy.PrivateKey) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	i := len(w.keys)
+	w.keys = append(w.keys, key)
+	w.spends = append(w.spends, spend)
+	w.secret[string(key.GenSecretBase().Bytes())] = i
+	w.plaintext[string(key.GenPlaintextBase().Bytes())] = i
+}
+
+func (w *Wallet) Keys() []*privacy.ViewKey {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return append([]*privacy.ViewKey{}, w.keys...)
+}
+
+func (w *Wallet) Store() storage.Store {return w.store}
+
+// Ingest processes slots in order, owned outputs are recorded, inputs mark the outputs they spend,
+// Plaintext inputs of an owned address are recorded as debits. It returns the records added or changed
+func (w *Wallet) Ingest(slots ...privacy.Slot) ([]*storage.Record, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	changed := make([]*storage.Record, 0)
+	for _, slot := range slots {
+		var record *storage.Record
+		var err error
+		if slot.SlotMode() & common.TxSlotKind == common.OutputSlot {
+			record, err = w.ingestOutput(slot)
+		} else {
+			record, err = w.ingestInput(slot)
+		}
+		if err != nil {return nil, err}
+		if record != nil {changed = append(changed, record)}
+	}
+	return changed, nil
+}
+
+// ingestOutput records an owned output, the amount is nil if the value can not be solved
+func (w *Wallet) ingestOutput(slot privacy.Slot) (*storage.Record, error) {
+	i, ok := w.owner(slot)
+	if !ok {return nil, nil}
+	key := w.keys[i]
+	record := &storage.Record{
+		Hash: storage.OutputHash(slot),
+		Owner: key.GenSecretBase().Bytes(),
+		Slot: slot,
+		Asset: privacy.NativeAsset,
+	}
+	if old, err := w.store.Get(record.Hash); err == nil {record.Spent = old.Spent}
+	if slot.Value().Solvable() {
+		if assetTagged(slot) {
+			record.Asset, record.Amount, _ = key.OpenAsset(slot)
+		} else {
+			record.Amount, _ = key.SolveSlot(slot)
+		}
+	}
+	err := w.store.Put(record)
+	if err != nil {return nil, err}
+	return record, nil
+}
+
+// ingestInput marks the spent output or records the debit of a Plaintext input
+func (w *Wallet) ingestInput(slot privacy.Slot) (*storage.Record, error) {
+	if slot.SlotMode() & common.PrivacyMode == common.Plaintext {
+		i, ok := w.owner(slot)
+		if !ok {return nil, nil}
+		key := w.keys[i]
+		amount, err := key.SolveSlot(slot)
+		if err != nil {return nil, err}
+		record := &storage.Record{
+			Hash: crypto.Hash_(slot),
+			Owner: key.GenSecretBase().Bytes(),
+			Slot: slot,
+			Amount: amount,
+			Asset: privacy.NativeAsset,
+			Spent: true,
+		}
+		err = w.store.Put(record)
+		if err != nil {return nil, err}
+		return record, nil
+	}
+
+	record, err := w.store.Get(storage.OutputHash(slot))
+	if err != nil || record.Spent {return nil, nil}
+	record.Spent = true
+	err = w.store.Put(record)
+	if err != nil {return nil, err}
+	return record, nil
+}
+
+// owner returns the index of the key owning the slot, a Secret or Anonymous slot the key can not spend is not owned
+func (w *Wallet) owner(slot privacy.Slot) (int, bool) {
+	switch s := slot.(type) {
+	case *privacy.PlaintextSlot:
+		i, ok := w.plaintext[string(s.Base().Bytes())]
+		return i, ok
+	case *privacy.SecretSlot:
+		i, ok := w.secret[string(s.Base().Bytes())]
+		return i, ok && w.keys[i].Spendable(slot)
+	case *privacy.AnonymousSlot:
+		for i, key := range w.keys {
+			if key.Owns(s.AnonymousBase) {return i, key.Spendable(slot)}
+		}
+	}
+	return 0, false
+}
+
+func assetTagged(slot privacy.Slot) bool {
+	switch s := slot.(type) {
+	case *privacy.SecretSlot:
+		return s.SecretValue.Asset() != nil
+	case *privacy.AnonymousSlot:
+		return s.AnonymousValue.Asset() != nil
+	default:
+		return false
+	}
+}
+
+// Unspent returns the unspent Secret and Anonymous outputs with solved amounts
+func (w *Wallet) Unspent() ([]*storage.Record, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	records, err := w.store.Records()
+	if err != nil {return nil, err}
+	unspent := make([]*storage.Record, 0)
+	for _, record := range records {
+		if record.Spent || record.Amount == nil || record.Slot.SlotMode() & common.PrivacyMode == common.Plaintext {continue}
+		unspent = append(unspent, record)
+	}
+	return unspent, nil
+}
+
+// Balances returns the balance of each asset, Plaintext credits net of the Plaintext debits
+// plus the unspent Secret and Anonymous outputs
+func (w *Wallet) Balances() (map[privacy.AssetID]*big.Int, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	records, err := w.store.Records()
+	if err != nil {return nil, err}
+	balances := make(map[privacy.AssetID]*big.Int)
+	for _, record := range records {
+		if record.Amount == nil {continue}
+		balance, ok := balances[record.Asset]
+		if !ok {
+			balance = new(big.Int)
+			balances[record.Asset] = balance
+		}
+		if record.Slot.SlotMode() & common.TxSlotKind == common.InputSlot {
+			balance.Sub(balance, record.Amount)
+		} else if !record.Spent {
+			balance.Add(balance, record.Amount)
+		}
+	}
+	return balances, nil
+}
+
+// Balance returns the balance of asset
+func (w *Wallet) Balance(asset privacy.AssetID) (*big.Int, error) {
+	balances, err := w.Balances()
+	if err != nil {return nil, err}
+	if balance, ok := balances[asset]; ok {return balance, nil}
+	return new(big.Int), nil
+}
@@ -0,0 +1,90 @@
+package wallet
+
+import (
+	"github.com/Acoustical/maskash/common"
+	"github.com/Acoustical/maskash/crypto"
+	"github.com/Acoustical/maskash/crypto/privacy"
+	"github.com/Acoustical/maskash/errors"
+	"github.com/Acoustical/maskash/storage"
+	"math/big"
+	"path/filepath"
+	"testing"
+)
+
+func TestWallet(t *testing.T) {
+	prv := privacy.NewRandomPrivateKey()
+	w := New(nil, nil)
+	w.AddKey(privacy.NewRandomPrivateKey())
+	w.AddKey(prv)
+
+	rl, _ := crypto.RandomZq(4)
+	gold := privacy.NewAssetID("gold")
+	plaintext, err := prv.GenPlaintextBase().NewPlaintextOutputSlot(big.NewInt(100), common.NoneContractSlot, nil)
+	errors.Handle(err)
+	secret, err := prv.GenSecretBase().NewSecretOutputSlot(big.NewInt(1919), rl[0], true, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+	anonymous, err := prv.GenAnonymousBase().NewAnonymousOutputSlot(big.NewInt(810), rl[1], true, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+	asset, err := prv.GenAnonymousBase().NewAnonymousAssetOutputSlot(big.NewInt(7), rl[2], gold, []privacy.AssetID{privacy.NativeAsset, gold}, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+	foreign, err := privacy.NewRandomPrivateKey().GenSecretBase().NewSecretOutputSlot(big.NewInt(1), rl[3], true, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+	// an output to the base of prv without its spend base can not be spent, it is not counted
+	stripped := new(privacy.SecretBase)
+	errors.Handle(stripped.SetBytes(prv.GenSecretBase().Bytes()))
+	unspendable, err := stripped.NewSecretOutputSlot(big.NewInt(1000), rl[3], true, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+
+	records, err := w.Ingest(plaintext, secret, anonymous, asset, foreign, unspendable)
+	errors.Handle(err)
+	if len(records) != 4 {
+		t.Errorf("wallet recognized %d outputs, want 4", len(records))
+	}
+	if balance, _ := w.Balance(privacy.NativeAsset); balance.Int64() != 2829 {
+		t.Errorf("wallet native balance %d, want 2829", balance)
+	}
+	if balance, _ := w.Balance(gold); balance.Int64() != 7 {
+		t.Errorf("wallet gold balance %d, want 7", balance)
+	}
+
+	_, err = w.Ingest(privacy.NewSecretInputSlot(secret), prv.NewPlaintextInputSlot(big.NewInt(0), big.NewInt(50)))
+	errors.Handle(err)
+	if balance, _ := w.Balance(privacy.NativeAsset); balance.Int64() != 860 {
+		t.Errorf("wallet native balance %d after spending, want 860", balance)
+	}
+	if unspent, _ := w.Unspent(); len(unspent) != 2 {
+		t.Errorf("wallet has %d unspent outputs, want 2", len(unspent))
+	}
+	if owned, _ := w.Store().Owned(prv.GenSecretBase().Bytes()); len(owned) != 5 {
+		t.Errorf("wallet store has %d records of the key, want 5", len(owned))
+	}
+}
+
+func TestWalletCompaction(t *testing.T) {
+	store, err := storage.OpenFileStore(filepath.Join(t.TempDir(), "wallet.log"))
+	errors.Handle(err)
+	prv := privacy.NewRandomPrivateKey()
+	w := New(store, nil)
+	w.AddKey(prv)
+
+	rl, _ := crypto.RandomZq(1)
+	credit, err := prv.GenPlaintextBase().NewPlaintextOutputSlot(big.NewInt(100), common.NoneContractSlot, nil)
+	errors.Handle(err)
+	secret, err := prv.GenSecretBase().NewSecretOutputSlot(big.NewInt(1919), rl[0], true, common.NoneContractSlot, nil, nil)
+	errors.Handle(err)
+	_, err = w.Ingest(credit, secret, prv.NewPlaintextInputSlot(big.NewInt(0), big.NewInt(60)), privacy.NewSecretInputSlot(secret))
+	errors.Handle(err)
+	if balance, _ := w.Balance(privacy.NativeAsset); balance.Int64() != 40 {
+		t.Errorf("wallet balance %d before compaction, want 40", balance)
+	}
+
+	// the spent output is dropped, the Plaintext debit is kept
+	errors.Handle(store.Compact())
+	if balance, _ := w.Balance(privacy.NativeAsset); balance.Int64() != 40 {
+		t.Errorf("wallet balance %d after compaction, want 40", balance)
+	}
+	if records, _ := store.Records(); len(records) != 2 {
+		t.Errorf("compacted store has %d records, want 2", len(records))
+	}
+	errors.Handle(store.Close())
+}